@@ -0,0 +1,305 @@
+// Package alerts evaluates alert rules against the current state of assets
+// and opens and resolves alerts as their conditions start and stop holding.
+// It depends only on domain.AlertStore, so it runs the same against the
+// Postgres and in-memory stores.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"crud/domain"
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultInterval = 30 * time.Second
+
+	// ResolvedByEvaluator is recorded on alerts closed because their
+	// condition stopped holding.
+	ResolvedByEvaluator = "evaluator"
+)
+
+// Result counts the alerts a pass opened and resolved.
+type Result struct {
+	Opened   int
+	Resolved int
+}
+
+// Evaluator checks every rule on a timer, which catches conditions that
+// become true with the passage of time such as "offline for 10 minutes", and
+// re-checks the rules for an asset as soon as an event about it arrives.
+type Evaluator struct {
+	store    domain.AlertStore
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[uuid.UUID]struct{}
+	wake    chan struct{}
+}
+
+func NewEvaluator(store domain.AlertStore, interval time.Duration) *Evaluator {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Evaluator{
+		store:    store,
+		interval: interval,
+		now:      func() time.Time { return time.Now().UTC() },
+		pending:  make(map[uuid.UUID]struct{}),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle is an events.Handler. It queues the asset an event is about for
+// evaluation by Run rather than evaluating on the publisher's goroutine.
+func (e *Evaluator) Handle(ctx context.Context, ev events.Event) {
+	switch ev.Type {
+	case events.AssetStatusChanged, events.AssetTelemetry, events.AssetTransferred:
+	default:
+		return
+	}
+	if ev.AssetID == nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.pending[*ev.AssetID] = struct{}{}
+	e.mu.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run evaluates every rule each interval, and queued assets as they arrive,
+// until ctx is cancelled.
+func (e *Evaluator) Run(ctx context.Context) {
+	slog.Info("alert evaluator started", "interval", e.interval.String())
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("alert evaluator stopped")
+			return
+		case <-ticker.C:
+			e.Evaluate(ctx)
+		case <-e.wake:
+			e.mu.Lock()
+			pending := e.pending
+			e.pending = make(map[uuid.UUID]struct{})
+			e.mu.Unlock()
+
+			for id := range pending {
+				e.EvaluateAsset(ctx, id)
+			}
+		}
+	}
+}
+
+// Evaluate runs every rule against every asset in its scope.
+func (e *Evaluator) Evaluate(ctx context.Context) (Result, error) {
+	return e.evaluate(ctx, nil)
+}
+
+// EvaluateAsset runs every rule against a single asset.
+func (e *Evaluator) EvaluateAsset(ctx context.Context, assetID uuid.UUID) (Result, error) {
+	return e.evaluate(ctx, &assetID)
+}
+
+func (e *Evaluator) evaluate(ctx context.Context, assetID *uuid.UUID) (Result, error) {
+	var res Result
+
+	rules, err := e.store.GetAlertRules(ctx)
+	if err != nil {
+		slog.Error("alert evaluation failed", slog.Any("error", err))
+		return res, err
+	}
+
+	s := snapshot{store: e.store, assetID: assetID, readings: make(map[string][]model.LatestReading)}
+	now := e.now()
+
+	var errs []error
+	for _, r := range rules {
+		if assetID != nil && r.AssetID != nil && *r.AssetID != *assetID {
+			continue
+		}
+
+		if err := e.apply(ctx, r, &s, now, &res); err != nil {
+			slog.Error("alert rule evaluation failed", "ruleID", r.ID, slog.Any("error", err))
+			errs = append(errs, err)
+		}
+	}
+
+	if res.Opened > 0 || res.Resolved > 0 {
+		slog.Info("alerts evaluated", "opened", res.Opened, "resolved", res.Resolved)
+	}
+
+	return res, errors.Join(errs...)
+}
+
+// apply opens an alert for every asset the rule fires for that does not
+// already have one, and resolves the open alerts of assets it no longer
+// fires for.
+func (e *Evaluator) apply(ctx context.Context, r model.AlertRule, s *snapshot, now time.Time, res *Result) error {
+	firing, err := fire(ctx, r, s, now)
+	if err != nil {
+		return err
+	}
+
+	open, err := e.store.GetAlerts(ctx, model.AlertQuery{
+		Status:  model.AlertStatuses.Open,
+		RuleID:  r.ID,
+		AssetID: s.assetID,
+	})
+	if err != nil {
+		return err
+	}
+
+	isOpen := make(map[uuid.UUID]bool, len(open))
+	for _, a := range open {
+		isOpen[a.AssetID] = true
+
+		if _, ok := firing[a.AssetID]; ok {
+			continue
+		}
+
+		_, err := e.store.ResolveAlert(ctx, a.ID, ResolvedByEvaluator)
+		switch {
+		case err == nil:
+			res.Resolved++
+		case errors.Is(err, helpers.ErrAlertAlreadyResolved), errors.Is(err, helpers.ErrAlertDoesNotExist):
+			// resolved by hand or by a concurrent pass
+		default:
+			return err
+		}
+	}
+
+	for id, a := range firing {
+		if isOpen[id] {
+			continue
+		}
+
+		opened, err := e.store.OpenAlert(ctx, &a)
+		switch {
+		case err == nil:
+			if opened {
+				res.Opened++
+			}
+		case errors.Is(err, helpers.ErrAssetDoesNotExist), errors.Is(err, helpers.ErrAlertRuleDoesNotExist):
+			// deleted since the snapshot was read
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fire returns the alert the rule would open for each asset its condition
+// currently holds for.
+func fire(ctx context.Context, r model.AlertRule, s *snapshot, now time.Time) (map[uuid.UUID]model.Alert, error) {
+	firing := make(map[uuid.UUID]model.Alert)
+
+	switch r.Kind {
+	case model.AlertRuleKinds.Offline:
+		offline, err := s.offline(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		minimum := time.Duration(r.DurationSeconds) * time.Second
+		for _, a := range offline {
+			if !r.InScope(a.AssetID, a.LocationID) || now.Sub(a.SinceUTC) < minimum {
+				continue
+			}
+
+			firing[a.AssetID] = model.Alert{
+				RuleID:     *r.ID,
+				AssetID:    a.AssetID,
+				LocationID: a.LocationID,
+				Message:    fmt.Sprintf("%s: offline since %s", r.Name, a.SinceUTC.Format(time.RFC3339)),
+			}
+		}
+
+	case model.AlertRuleKinds.Threshold:
+		if r.Threshold == nil {
+			return firing, nil
+		}
+
+		readings, err := s.latest(ctx, r.Metric)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, reading := range readings {
+			if !r.InScope(reading.AssetID, reading.LocationID) || !r.Operator.Holds(reading.Value, *r.Threshold) {
+				continue
+			}
+
+			value := reading.Value
+			firing[reading.AssetID] = model.Alert{
+				RuleID:     *r.ID,
+				AssetID:    reading.AssetID,
+				LocationID: reading.LocationID,
+				Message:    fmt.Sprintf("%s: %s is %g (%s %g)", r.Name, r.Metric, value, r.Operator, *r.Threshold),
+				Value:      &value,
+			}
+		}
+	}
+
+	return firing, nil
+}
+
+// snapshot loads the asset state rules are checked against at most once per
+// pass, however many rules need it.
+type snapshot struct {
+	store   domain.AlertStore
+	assetID *uuid.UUID
+
+	offlineAssets []model.OfflineAsset
+	offlineLoaded bool
+	readings      map[string][]model.LatestReading
+}
+
+func (s *snapshot) offline(ctx context.Context) ([]model.OfflineAsset, error) {
+	if s.offlineLoaded {
+		return s.offlineAssets, nil
+	}
+
+	assets, err := s.store.GetOfflineAssets(ctx, s.assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.offlineAssets, s.offlineLoaded = assets, true
+	return assets, nil
+}
+
+func (s *snapshot) latest(ctx context.Context, metric string) ([]model.LatestReading, error) {
+	if readings, ok := s.readings[metric]; ok {
+		return readings, nil
+	}
+
+	readings, err := s.store.GetLatestReadings(ctx, metric, s.assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.readings[metric] = readings
+	return readings, nil
+}
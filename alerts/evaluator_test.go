@@ -0,0 +1,200 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crud/domain"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func newTestStore(t *testing.T, status model.Status) (*domain.MemoryStore, uuid.UUID) {
+	t.Helper()
+
+	store := domain.NewMemoryStore()
+	ctx := context.Background()
+
+	loc := &model.Location{Name: "Warehouse", Code: "WHSE"}
+	if err := store.CreateLocation(ctx, loc); err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+
+	asset := &model.CreateAssetRequest{Name: "tracker1", Status: status, LocationID: *loc.ID}
+	if err := store.CreateAsset(ctx, asset); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+
+	return store, *asset.ID
+}
+
+func createRule(t *testing.T, store *domain.MemoryStore, r model.AlertRule) model.AlertRule {
+	t.Helper()
+
+	if err := store.CreateAlertRule(context.Background(), &r); err != nil {
+		t.Fatalf("CreateAlertRule: %v", err)
+	}
+	return r
+}
+
+func record(t *testing.T, store *domain.MemoryStore, assetID uuid.UUID, metric string, value float64) {
+	t.Helper()
+
+	err := store.CreateTelemetry(context.Background(), assetID, []model.TelemetryReading{{
+		Metric:        metric,
+		Value:         &value,
+		RecordedAtUTC: time.Now().UTC(),
+	}})
+	if err != nil {
+		t.Fatalf("CreateTelemetry: %v", err)
+	}
+}
+
+func evaluate(t *testing.T, e *Evaluator, want Result) {
+	t.Helper()
+
+	got, err := e.Evaluate(context.Background())
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if got != want {
+		t.Errorf("Evaluate() = %+v, want %+v", got, want)
+	}
+}
+
+func alerts(t *testing.T, store *domain.MemoryStore, ruleID *uuid.UUID, status model.AlertStatus) []model.Alert {
+	t.Helper()
+
+	list, err := store.GetAlerts(context.Background(), model.AlertQuery{RuleID: ruleID, Status: status})
+	if err != nil {
+		t.Fatalf("GetAlerts: %v", err)
+	}
+	return list
+}
+
+func TestThresholdRule(t *testing.T) {
+	store, assetID := newTestStore(t, model.Statuses.Online)
+	threshold := 80.0
+	rule := createRule(t, store, model.AlertRule{
+		Name:      "Overheating",
+		Kind:      model.AlertRuleKinds.Threshold,
+		AssetID:   &assetID,
+		Metric:    "temperature",
+		Operator:  model.Comparisons.GreaterThan,
+		Threshold: &threshold,
+	})
+	e := NewEvaluator(store, 0)
+
+	// below the threshold nothing opens
+	record(t, store, assetID, "temperature", 75)
+	evaluate(t, e, Result{})
+
+	// a breach opens an alert
+	record(t, store, assetID, "temperature", 90)
+	evaluate(t, e, Result{Opened: 1})
+
+	open := alerts(t, store, rule.ID, model.AlertStatuses.Open)
+	if len(open) != 1 || open[0].AssetID != assetID || open[0].Value == nil || *open[0].Value != 90 {
+		t.Fatalf("open alerts = %+v, want one for the asset with value 90", open)
+	}
+
+	// a repeat breach keeps the alert open without opening another
+	record(t, store, assetID, "temperature", 95)
+	evaluate(t, e, Result{})
+	if open := alerts(t, store, rule.ID, model.AlertStatuses.Open); len(open) != 1 {
+		t.Fatalf("%d open alerts after a repeat breach, want 1", len(open))
+	}
+
+	// recovery resolves it
+	record(t, store, assetID, "temperature", 70)
+	evaluate(t, e, Result{Resolved: 1})
+
+	if open := alerts(t, store, rule.ID, model.AlertStatuses.Open); len(open) != 0 {
+		t.Errorf("open alerts after recovery = %+v, want none", open)
+	}
+	resolved := alerts(t, store, rule.ID, model.AlertStatuses.Resolved)
+	if len(resolved) != 1 || resolved[0].ResolvedBy != ResolvedByEvaluator {
+		t.Errorf("resolved alerts = %+v, want one resolved by the evaluator", resolved)
+	}
+}
+
+func TestOfflineRule(t *testing.T) {
+	store, assetID := newTestStore(t, model.Statuses.Online)
+	rule := createRule(t, store, model.AlertRule{
+		Name:            "Offline",
+		Kind:            model.AlertRuleKinds.Offline,
+		DurationSeconds: 600,
+	})
+
+	clock := time.Now().UTC()
+	e := NewEvaluator(store, 0)
+	e.now = func() time.Time { return clock }
+
+	ctx := context.Background()
+	if err := store.UpdateAssetStatus(ctx, assetID, model.Statuses.Offline, model.StatusChangeSources.Heartbeat); err != nil {
+		t.Fatalf("UpdateAssetStatus: %v", err)
+	}
+
+	// not offline for long enough yet
+	clock = clock.Add(5 * time.Minute)
+	evaluate(t, e, Result{})
+
+	clock = clock.Add(6 * time.Minute)
+	evaluate(t, e, Result{Opened: 1})
+	if open := alerts(t, store, rule.ID, model.AlertStatuses.Open); len(open) != 1 || open[0].AssetID != assetID {
+		t.Fatalf("open alerts = %+v, want one for the asset", open)
+	}
+
+	// still offline: no duplicate
+	clock = clock.Add(time.Minute)
+	evaluate(t, e, Result{})
+
+	// back online: resolved
+	if _, err := store.RecordHeartbeat(ctx, assetID); err != nil {
+		t.Fatalf("RecordHeartbeat: %v", err)
+	}
+	evaluate(t, e, Result{Resolved: 1})
+	if open := alerts(t, store, rule.ID, model.AlertStatuses.Open); len(open) != 0 {
+		t.Errorf("open alerts after coming online = %+v, want none", open)
+	}
+}
+
+func TestEvaluateAssetOnlyTouchesThatAsset(t *testing.T) {
+	store, first := newTestStore(t, model.Statuses.Online)
+	ctx := context.Background()
+
+	locationID, err := store.GetAssetLocationID(ctx, first)
+	if err != nil {
+		t.Fatalf("GetAssetLocationID: %v", err)
+	}
+	second := &model.CreateAssetRequest{Name: "tracker2", Status: model.Statuses.Online, LocationID: locationID}
+	if err := store.CreateAsset(ctx, second); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+
+	threshold := 10.0
+	createRule(t, store, model.AlertRule{
+		Name:      "Low battery",
+		Kind:      model.AlertRuleKinds.Threshold,
+		Metric:    "battery",
+		Operator:  model.Comparisons.LessThan,
+		Threshold: &threshold,
+	})
+
+	record(t, store, first, "battery", 5)
+	record(t, store, *second.ID, "battery", 5)
+
+	e := NewEvaluator(store, 0)
+	res, err := e.EvaluateAsset(ctx, first)
+	if err != nil {
+		t.Fatalf("EvaluateAsset: %v", err)
+	}
+	if res.Opened != 1 {
+		t.Errorf("EvaluateAsset opened %d alerts, want 1", res.Opened)
+	}
+
+	// the full pass picks up the other asset
+	evaluate(t, e, Result{Opened: 1})
+}
@@ -5,21 +5,34 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"strconv"
+	"sync"
 
+	"crud/auth"
 	"crud/db"
+	"crud/domain"
+	"crud/events"
+	"crud/problem"
+	"crud/requestid"
 	"crud/routes"
+	"crud/stream"
 
 	"github.com/joho/godotenv"
 )
 
-var router http.Handler
+var (
+	mu     sync.Mutex
+	router http.Handler
+)
 
-func init() {
+// setup builds the router on the first request so that cold starts do not
+// depend on package init side effects. It returns nil if any step fails.
+func setup() http.Handler {
 
 	// logging
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
 	// load env
@@ -28,22 +41,72 @@ func init() {
 	connStr := os.Getenv("DATABASE_STRING")
 	if connStr == "" {
 		slog.Error("DATABASE_STRING not set")
-		return
+		return nil
 	}
 
 	// init db
 	err := db.Init(context.Background(), connStr)
 	if err != nil {
 		slog.Error("DB init failed", slog.Any("error", err))
-		return
+		db.Close()
+		return nil
+	}
+
+	// There is no deploy step to run migrations in, so they can be applied on
+	// cold start instead. The migrator's lock serializes concurrent starts.
+	if migrate, _ := strconv.ParseBool(os.Getenv("MIGRATE_ON_START")); migrate {
+		if err := db.MigrateUp(context.Background(), db.DB); err != nil {
+			slog.Error("DB migration failed", slog.Any("error", err))
+			db.Close()
+			return nil
+		}
+	}
+
+	// The store queues webhook deliveries in the transaction of each change.
+	// Serverless functions do not run background workers, so they are sent,
+	// and alert rules evaluated, by a long-running server sharing the
+	// database.
+	bus := events.NewBus()
+	store := domain.NewPublisher(domain.NewPostgresStore(db.DB), bus)
+
+	// Streams only see changes made by this instance.
+	hub := stream.NewHub(stream.DefaultBufferSize)
+	bus.Subscribe(hub.Publish)
+
+	if err := auth.Bootstrap(context.Background(), store, os.Getenv("ADMIN_API_KEY")); err != nil {
+		slog.Error("API key bootstrap failed", slog.Any("error", err))
+		db.Close()
+		return nil
 	}
 
 	// init router
-	router = routes.SetupRouter()
+	router := routes.SetupRouter(store, hub, db.DB)
 
 	slog.Info("Vercel server initialized")
+	return router
+}
+
+// handler returns the router, building it if no earlier request managed to.
+// A failed setup, such as a database that was unreachable on cold start, is
+// retried on the next request instead of leaving a warm instance answering
+// 503 for its whole lifetime.
+func handler() http.Handler {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if router == nil {
+		router = setup()
+	}
+
+	return router
 }
 
 func Handler(w http.ResponseWriter, r *http.Request) {
+	router := handler()
+	if router == nil {
+		problem.Write(w, r, problem.New(http.StatusServiceUnavailable, problem.CodeUnavailable, "server not initialized"))
+		return
+	}
+
 	router.ServeHTTP(w, r)
 }
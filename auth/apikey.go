@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"crud/model"
+)
+
+const (
+	keyPrefix  = "iat_"
+	keyBytes   = 32
+	prefixSize = 12
+)
+
+// GenerateKey returns a new random API key together with the short prefix
+// shown in listings and the hash that is stored.
+func GenerateKey() (key, prefix, hash string, err error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	key = keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, Prefix(key), HashKey(key), nil
+}
+
+// HashKey returns the hex SHA-256 of key. Keys are high-entropy random
+// strings, so a fast hash is sufficient.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func Prefix(key string) string {
+	if len(key) <= prefixSize {
+		return key
+	}
+	return key[:prefixSize]
+}
+
+type contextKey struct{}
+
+// WithAPIKey returns a copy of ctx carrying the authenticated key.
+func WithAPIKey(ctx context.Context, key *model.APIKey) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// APIKeyFromContext returns the authenticated key, or nil for anonymous
+// requests.
+func APIKeyFromContext(ctx context.Context) *model.APIKey {
+	key, _ := ctx.Value(contextKey{}).(*model.APIKey)
+	return key
+}
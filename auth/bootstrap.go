@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"crud/helpers"
+	"crud/model"
+)
+
+type apiKeyCreator interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error
+}
+
+// Bootstrap makes sure key exists as an admin API key, so a fresh deployment
+// has a way to create the rest. It is a no-op when the key is already stored.
+func Bootstrap(ctx context.Context, store apiKeyCreator, key string) error {
+	if key == "" {
+		return nil
+	}
+
+	err := store.CreateAPIKey(ctx, &model.APIKey{
+		Name:   "bootstrap admin",
+		Prefix: Prefix(key),
+		Role:   model.Roles.Admin,
+	}, HashKey(key))
+
+	if errors.Is(err, helpers.ErrAPIKeyAlreadyExists) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	slog.Info("bootstrap admin api key created", "prefix", Prefix(key))
+	return nil
+}
@@ -0,0 +1,301 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationsTable matches the table the migrate CLI keeps, so databases
+// migrated with either tool can be managed by the other. It holds a single
+// row with the current version.
+const migrationsTable = "schema_migrations"
+
+// migrationLockID keys the advisory lock that keeps concurrent instances from
+// migrating at the same time.
+const migrationLockID = 7164023549
+
+var (
+	ErrDirtyDatabase    = errors.New("database is dirty")
+	ErrUnknownMigration = errors.New("database version has no migration")
+)
+
+var migrationName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is the state of the schema: the applied version, 0 if
+// none, and the migrations not yet applied.
+type MigrationStatus struct {
+	Version int
+	Dirty   bool
+	Pending []Migration
+}
+
+// Migrations returns the embedded migrations ordered by version.
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, e := range entries {
+		m := migrationName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must be <version>_<name>.<up|down>.sql", e.Name())
+		}
+
+		version, _ := strconv.Atoi(m[1])
+		b, err := migrationFiles.ReadFile(path.Join("migrations", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d: conflicting names %s and %s", version, mig.Name, m[2])
+		}
+
+		if m[3] == "up" {
+			mig.Up = string(b)
+		} else {
+			mig.Down = string(b)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s: needs both an up and a down file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+
+	return migrations, nil
+}
+
+// Migrator applies the embedded migrations to a database.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version <= version {
+				continue
+			}
+
+			slog.InfoContext(ctx, "applying migration", "version", mig.Version, "name", mig.Name)
+			if err := m.apply(ctx, conn, mig.Up, mig.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// MigrateUp applies all pending migrations to db. Both the server and the
+// serverless entry point call it.
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "migrations applied", "count", len(applied))
+	return nil
+}
+
+// Down reverts up to steps migrations, newest first, and returns the ones
+// reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		version, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if mig.Version > version {
+				continue
+			}
+
+			previous := 0
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			slog.InfoContext(ctx, "reverting migration", "version", mig.Version, "name", mig.Name)
+			if err := m.apply(ctx, conn, mig.Down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status reports the applied version and the pending migrations.
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	var st MigrationStatus
+
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		st.Version, st.Dirty, err = m.version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			if mig.Version > st.Version {
+				st.Pending = append(st.Pending, mig)
+			}
+		}
+
+		return nil
+	})
+
+	return st, err
+}
+
+// locked runs fn on a single connection holding the migration lock, after
+// making sure the version table exists.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1);`, migrationLockID); err != nil {
+		return err
+	}
+	defer func() {
+		// the lock is released with the session if this fails
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1);`, migrationLockID); err != nil {
+			slog.ErrorContext(ctx, "failed to release migration lock", slog.Any("error", err))
+		}
+	}()
+
+	query := `CREATE TABLE IF NOT EXISTS ` + migrationsTable + ` (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) version(ctx context.Context, conn *sql.Conn) (int, bool, error) {
+	var (
+		version int
+		dirty   bool
+	)
+
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM `+migrationsTable+` LIMIT 1;`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// current returns the applied version, refusing to continue from a dirty or
+// unknown one.
+func (m *Migrator) current(ctx context.Context, conn *sql.Conn) (int, error) {
+	version, dirty, err := m.version(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d: repair the schema and reset the version in %s", ErrDirtyDatabase, version, migrationsTable)
+	}
+
+	if version != 0 && !slices.ContainsFunc(m.migrations, func(mig Migration) bool { return mig.Version == version }) {
+		return 0, fmt.Errorf("%w: version %d", ErrUnknownMigration, version)
+	}
+
+	return version, nil
+}
+
+// apply runs one migration and records the resulting version in the same
+// transaction, so a failed migration leaves no trace.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, query string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+migrationsTable+`;`); err != nil {
+		return err
+	}
+
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO `+migrationsTable+` (version, dirty) VALUES ($1, FALSE);`, version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "telemetry" (
+    "ID"            BIGSERIAL PRIMARY KEY,
+    "assetID"       UUID NOT NULL REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "metric"        VARCHAR(100) NOT NULL,
+    "value"         DOUBLE PRECISION NOT NULL,
+    "unit"          VARCHAR(20) NOT NULL DEFAULT '',
+    "recordedAtUTC" TIMESTAMP(3) NOT NULL,
+    "createdAtUTC"  TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "telemetry_assetID_recordedAtUTC_idx"
+    ON "telemetry" ("assetID", "recordedAtUTC");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "assets_status_lastSeenAtUTC_idx";
+
+ALTER TABLE "assets" DROP COLUMN IF EXISTS "lastSeenAtUTC";
@@ -0,0 +1,4 @@
+ALTER TABLE "assets" ADD COLUMN IF NOT EXISTS "lastSeenAtUTC" TIMESTAMP(3);
+
+CREATE INDEX IF NOT EXISTS "assets_status_lastSeenAtUTC_idx"
+    ON "assets" ("status", "lastSeenAtUTC");
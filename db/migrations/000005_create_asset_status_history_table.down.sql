@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "asset_status_history";
+
+DROP TYPE IF EXISTS "status_change_source";
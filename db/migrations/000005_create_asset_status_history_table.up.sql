@@ -0,0 +1,17 @@
+CREATE TYPE "status_change_source" AS ENUM (
+    'manual',
+    'heartbeat',
+    'import'
+);
+
+CREATE TABLE IF NOT EXISTS "asset_status_history" (
+    "ID"           BIGSERIAL PRIMARY KEY,
+    "assetID"      UUID NOT NULL REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "oldStatus"    "asset_status" NOT NULL,
+    "newStatus"    "asset_status" NOT NULL,
+    "source"       "status_change_source" NOT NULL,
+    "changedAtUTC" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "asset_status_history_assetID_changedAtUTC_idx"
+    ON "asset_status_history" ("assetID", "changedAtUTC");
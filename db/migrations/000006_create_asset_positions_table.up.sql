@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "asset_positions" (
+    "ID"            BIGSERIAL PRIMARY KEY,
+    "assetID"       UUID NOT NULL REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "latitude"      DOUBLE PRECISION NOT NULL CHECK ("latitude" BETWEEN -90 AND 90),
+    "longitude"     DOUBLE PRECISION NOT NULL CHECK ("longitude" BETWEEN -180 AND 180),
+    "accuracy"      DOUBLE PRECISION CHECK ("accuracy" >= 0),
+    "recordedAtUTC" TIMESTAMP(3) NOT NULL,
+    "createdAtUTC"  TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "asset_positions_assetID_recordedAtUTC_idx"
+    ON "asset_positions" ("assetID", "recordedAtUTC" DESC);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "geofence_events";
+
+DROP TYPE IF EXISTS "geofence_event_type";
+
+DROP TABLE IF EXISTS "geofences";
+
+DROP TYPE IF EXISTS "geofence_shape";
@@ -0,0 +1,41 @@
+CREATE TYPE "geofence_shape" AS ENUM (
+    'circle',
+    'polygon'
+);
+
+CREATE TABLE IF NOT EXISTS "geofences" (
+    "locationID"       UUID PRIMARY KEY REFERENCES "locations"("ID") ON DELETE CASCADE,
+    "shape"            "geofence_shape" NOT NULL,
+    "centerLatitude"   DOUBLE PRECISION,
+    "centerLongitude"  DOUBLE PRECISION,
+    "radiusMeters"     DOUBLE PRECISION,
+    "polygon"          JSONB,
+    "createdAtUTC"     TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "lastUpdatedAtUTC" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    CHECK (
+        ("shape" = 'circle' AND "centerLatitude" IS NOT NULL AND "centerLongitude" IS NOT NULL AND "radiusMeters" > 0)
+        OR ("shape" = 'polygon' AND "polygon" IS NOT NULL)
+    )
+);
+
+CREATE TYPE "geofence_event_type" AS ENUM (
+    'enter',
+    'exit'
+);
+
+CREATE TABLE IF NOT EXISTS "geofence_events" (
+    "ID"            BIGSERIAL PRIMARY KEY,
+    "assetID"       UUID NOT NULL REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "locationID"    UUID NOT NULL REFERENCES "locations"("ID") ON DELETE CASCADE,
+    "event"         "geofence_event_type" NOT NULL,
+    "latitude"      DOUBLE PRECISION NOT NULL,
+    "longitude"     DOUBLE PRECISION NOT NULL,
+    "occurredAtUTC" TIMESTAMP(3) NOT NULL,
+    "createdAtUTC"  TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "geofence_events_assetID_occurredAtUTC_idx"
+    ON "geofence_events" ("assetID", "occurredAtUTC");
+
+CREATE INDEX IF NOT EXISTS "geofence_events_locationID_occurredAtUTC_idx"
+    ON "geofence_events" ("locationID", "occurredAtUTC");
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "api_keys";
+
+DROP TYPE IF EXISTS "api_key_role";
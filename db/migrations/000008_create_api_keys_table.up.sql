@@ -0,0 +1,16 @@
+CREATE TYPE "api_key_role" AS ENUM (
+    'viewer',
+    'operator',
+    'admin'
+);
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "ID"            UUID PRIMARY KEY DEFAULT UUID_GENERATE_V4(),
+    "name"          VARCHAR(255) NOT NULL,
+    "prefix"        VARCHAR(16) NOT NULL,
+    "keyHash"       CHAR(64) NOT NULL UNIQUE,
+    "role"          "api_key_role" NOT NULL,
+    "createdAtUTC"  TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "lastUsedAtUTC" TIMESTAMP(3),
+    "revokedAtUTC"  TIMESTAMP(3)
+);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "asset_transfers" (
+    "ID"               BIGSERIAL PRIMARY KEY,
+    "assetID"          UUID NOT NULL REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "fromLocationID"   UUID REFERENCES "locations"("ID") ON DELETE SET NULL,
+    "toLocationID"     UUID REFERENCES "locations"("ID") ON DELETE SET NULL,
+    "reason"           TEXT NOT NULL,
+    "transferredAtUTC" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS "asset_transfers_assetID_transferredAtUTC_idx"
+    ON "asset_transfers" ("assetID", "transferredAtUTC");
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS "webhooks" (
+    "ID"               UUID PRIMARY KEY DEFAULT UUID_GENERATE_V4(),
+    "url"              TEXT NOT NULL,
+    "events"           TEXT[] NOT NULL DEFAULT '{}',
+    "secret"           VARCHAR(128) NOT NULL,
+    "createdAtUTC"     TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "lastUpdatedAtUTC" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+CREATE TYPE "webhook_delivery_status" AS ENUM (
+    'pending',
+    'delivered',
+    'dead'
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_deliveries" (
+    "ID"               UUID PRIMARY KEY DEFAULT UUID_GENERATE_V4(),
+    "webhookID"        UUID NOT NULL REFERENCES "webhooks"("ID") ON DELETE CASCADE,
+    "eventID"          UUID NOT NULL,
+    "eventType"        VARCHAR(64) NOT NULL,
+    "payload"          JSONB NOT NULL,
+    "status"           "webhook_delivery_status" NOT NULL DEFAULT 'pending',
+    "attempts"         INTEGER NOT NULL DEFAULT 0,
+    "nextAttemptAtUTC" TIMESTAMP(3) DEFAULT NOW(),
+    "lastAttemptAtUTC" TIMESTAMP(3),
+    "responseStatus"   INTEGER,
+    "lastError"        TEXT NOT NULL DEFAULT '',
+    "createdAtUTC"     TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "deliveredAtUTC"   TIMESTAMP(3),
+    UNIQUE ("webhookID", "eventID")
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_due_idx"
+    ON "webhook_deliveries" ("nextAttemptAtUTC")
+    WHERE "status" = 'pending';
+
+CREATE INDEX IF NOT EXISTS "webhook_deliveries_webhookID_createdAtUTC_idx"
+    ON "webhook_deliveries" ("webhookID", "createdAtUTC" DESC);
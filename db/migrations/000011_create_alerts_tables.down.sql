@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "telemetry_metric_assetID_recordedAtUTC_idx";
+DROP TABLE IF EXISTS "alerts";
+DROP TYPE IF EXISTS "alert_status";
+DROP TABLE IF EXISTS "alert_rules";
+DROP TYPE IF EXISTS "alert_rule_kind";
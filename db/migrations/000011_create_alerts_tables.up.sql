@@ -0,0 +1,49 @@
+CREATE TYPE "alert_rule_kind" AS ENUM (
+    'offline',
+    'threshold'
+);
+
+CREATE TABLE IF NOT EXISTS "alert_rules" (
+    "ID"               UUID PRIMARY KEY DEFAULT UUID_GENERATE_V4(),
+    "name"             VARCHAR(100) NOT NULL,
+    "kind"             "alert_rule_kind" NOT NULL,
+    "assetID"          UUID REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "locationID"       UUID REFERENCES "locations"("ID") ON DELETE CASCADE,
+    "metric"           VARCHAR(100) NOT NULL DEFAULT '',
+    "operator"         VARCHAR(3) NOT NULL DEFAULT '',
+    "threshold"        DOUBLE PRECISION,
+    "durationSeconds"  INTEGER NOT NULL DEFAULT 0,
+    "createdAtUTC"     TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "lastUpdatedAtUTC" TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    CONSTRAINT "alert_rules_scope_check" CHECK ("assetID" IS NULL OR "locationID" IS NULL)
+);
+
+CREATE TYPE "alert_status" AS ENUM (
+    'open',
+    'resolved'
+);
+
+CREATE TABLE IF NOT EXISTS "alerts" (
+    "ID"            UUID PRIMARY KEY DEFAULT UUID_GENERATE_V4(),
+    "ruleID"        UUID NOT NULL REFERENCES "alert_rules"("ID") ON DELETE CASCADE,
+    "assetID"       UUID NOT NULL REFERENCES "assets"("ID") ON DELETE CASCADE,
+    "locationID"    UUID NOT NULL,
+    "status"        "alert_status" NOT NULL DEFAULT 'open',
+    "message"       TEXT NOT NULL,
+    "value"         DOUBLE PRECISION,
+    "openedAtUTC"   TIMESTAMP(3) NOT NULL DEFAULT NOW(),
+    "resolvedAtUTC" TIMESTAMP(3),
+    "resolvedBy"    VARCHAR(100) NOT NULL DEFAULT ''
+);
+
+-- at most one open alert per rule and asset
+CREATE UNIQUE INDEX IF NOT EXISTS "alerts_ruleID_assetID_open_key"
+    ON "alerts" ("ruleID", "assetID")
+    WHERE "status" = 'open';
+
+CREATE INDEX IF NOT EXISTS "alerts_openedAtUTC_idx"
+    ON "alerts" ("openedAtUTC" DESC);
+
+-- latest reading of a metric per asset, for threshold rules
+CREATE INDEX IF NOT EXISTS "telemetry_metric_assetID_recordedAtUTC_idx"
+    ON "telemetry" ("metric", "assetID", "recordedAtUTC" DESC);
@@ -0,0 +1,2 @@
+ALTER TABLE "assets" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "locations" DROP COLUMN IF EXISTS "version";
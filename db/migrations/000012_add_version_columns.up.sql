@@ -0,0 +1,2 @@
+ALTER TABLE "locations" ADD COLUMN IF NOT EXISTS "version" INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE "assets" ADD COLUMN IF NOT EXISTS "version" INTEGER NOT NULL DEFAULT 1;
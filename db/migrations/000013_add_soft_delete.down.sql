@@ -0,0 +1,17 @@
+-- deleted rows may share names with live ones, so they cannot survive the
+-- return of the full unique constraints
+DELETE FROM "assets" WHERE "deletedAtUTC" IS NOT NULL;
+DELETE FROM "locations" WHERE "deletedAtUTC" IS NOT NULL;
+
+DROP INDEX IF EXISTS "assets_deletedAtUTC_idx";
+DROP INDEX IF EXISTS "locations_deletedAtUTC_idx";
+DROP INDEX IF EXISTS "assets_name_key";
+DROP INDEX IF EXISTS "locations_code_key";
+DROP INDEX IF EXISTS "locations_name_key";
+
+ALTER TABLE "assets" ADD CONSTRAINT "assets_name_key" UNIQUE ("name");
+ALTER TABLE "locations" ADD CONSTRAINT "locations_code_key" UNIQUE ("code");
+ALTER TABLE "locations" ADD CONSTRAINT "locations_name_key" UNIQUE ("name");
+
+ALTER TABLE "assets" DROP COLUMN IF EXISTS "deletedAtUTC";
+ALTER TABLE "locations" DROP COLUMN IF EXISTS "deletedAtUTC";
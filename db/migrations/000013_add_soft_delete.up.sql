@@ -0,0 +1,22 @@
+ALTER TABLE "locations" ADD COLUMN IF NOT EXISTS "deletedAtUTC" TIMESTAMP(3);
+ALTER TABLE "assets" ADD COLUMN IF NOT EXISTS "deletedAtUTC" TIMESTAMP(3);
+
+-- Names and codes only need to be unique among rows that have not been
+-- deleted. The partial indexes keep the constraint names so violations are
+-- still recognised.
+ALTER TABLE "locations" DROP CONSTRAINT IF EXISTS "locations_name_key";
+ALTER TABLE "locations" DROP CONSTRAINT IF EXISTS "locations_code_key";
+ALTER TABLE "assets" DROP CONSTRAINT IF EXISTS "assets_name_key";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "locations_name_key"
+    ON "locations" ("name") WHERE "deletedAtUTC" IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "locations_code_key"
+    ON "locations" ("code") WHERE "deletedAtUTC" IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS "assets_name_key"
+    ON "assets" ("name") WHERE "deletedAtUTC" IS NULL;
+
+-- for the purge job
+CREATE INDEX IF NOT EXISTS "locations_deletedAtUTC_idx"
+    ON "locations" ("deletedAtUTC") WHERE "deletedAtUTC" IS NOT NULL;
+CREATE INDEX IF NOT EXISTS "assets_deletedAtUTC_idx"
+    ON "assets" ("deletedAtUTC") WHERE "deletedAtUTC" IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "locations_parentID_idx";
+
+ALTER TABLE "locations" DROP COLUMN IF EXISTS "parentID";
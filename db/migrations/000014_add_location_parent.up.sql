@@ -0,0 +1,3 @@
+ALTER TABLE "locations" ADD COLUMN IF NOT EXISTS "parentID" UUID REFERENCES "locations" ("ID");
+
+CREATE INDEX IF NOT EXISTS "locations_parentID_idx" ON "locations" ("parentID");
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "assets_attributes_idx";
+DROP INDEX IF EXISTS "assets_typeID_idx";
+
+ALTER TABLE "assets" DROP COLUMN IF EXISTS "attributes";
+ALTER TABLE "assets" DROP COLUMN IF EXISTS "typeID";
+
+DROP TABLE IF EXISTS "asset_types";
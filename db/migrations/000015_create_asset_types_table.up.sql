@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "asset_types" (
+    "ID"           UUID PRIMARY KEY DEFAULT UUID_GENERATE_V4(),
+    "name"         VARCHAR(50) NOT NULL UNIQUE,
+    "schema"       JSONB NOT NULL,
+    "createdAtUTC" TIMESTAMP(3) NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE "assets" ADD COLUMN IF NOT EXISTS "typeID" UUID REFERENCES "asset_types"("ID");
+ALTER TABLE "assets" ADD COLUMN IF NOT EXISTS "attributes" JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS "assets_typeID_idx" ON "assets" ("typeID");
+
+-- for attribute filters, which use containment
+CREATE INDEX IF NOT EXISTS "assets_attributes_idx" ON "assets" USING GIN ("attributes" jsonb_path_ops);
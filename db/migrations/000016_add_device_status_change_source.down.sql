@@ -0,0 +1,16 @@
+-- Enum values cannot be dropped, so the type is rebuilt without 'device'.
+-- Transitions a device reported are kept as heartbeat transitions.
+UPDATE "asset_status_history" SET "source" = 'heartbeat' WHERE "source" = 'device';
+
+ALTER TYPE "status_change_source" RENAME TO "status_change_source_old";
+
+CREATE TYPE "status_change_source" AS ENUM (
+    'manual',
+    'heartbeat',
+    'import'
+);
+
+ALTER TABLE "asset_status_history"
+    ALTER COLUMN "source" TYPE "status_change_source" USING "source"::TEXT::"status_change_source";
+
+DROP TYPE "status_change_source_old";
@@ -0,0 +1,2 @@
+-- Adding an enum value inside a transaction needs PostgreSQL 12 or later.
+ALTER TYPE "status_change_source" ADD VALUE IF NOT EXISTS 'device';
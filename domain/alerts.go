@@ -0,0 +1,380 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCreateAlertRuleFailed = errors.New("failed to create alert rule")
+	ErrGetAlertRulesFailed   = errors.New("failed to get alert rules")
+	ErrDeleteAlertRuleFailed = errors.New("failed to delete alert rule")
+	ErrGetAlertsFailed       = errors.New("failed to get alerts")
+	ErrOpenAlertFailed       = errors.New("failed to open alert")
+	ErrResolveAlertFailed    = errors.New("failed to resolve alert")
+	ErrEvaluateAlertsFailed  = errors.New("failed to read asset state for alerts")
+)
+
+const selectAlertRules = `
+	SELECT "ID", "name", "kind", "assetID", "locationID", "metric", "operator", "threshold", "durationSeconds", "createdAtUTC", "lastUpdatedAtUTC"
+	FROM alert_rules
+`
+
+func scanAlertRule(row rowScanner) (model.AlertRule, error) {
+	var r model.AlertRule
+	err := row.Scan(&r.ID, &r.Name, &r.Kind, &r.AssetID, &r.LocationID, &r.Metric, &r.Operator, &r.Threshold, &r.DurationSeconds, &r.CreatedAtUTC, &r.LastUpdatedAtUTC)
+	return r, err
+}
+
+func (s *PostgresStore) CreateAlertRule(ctx context.Context, r *model.AlertRule) error {
+	query := `
+		INSERT INTO alert_rules ("name", "kind", "assetID", "locationID", "metric", "operator", "threshold", "durationSeconds")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING "ID", "createdAtUTC", "lastUpdatedAtUTC";
+	`
+
+	err := s.db.QueryRowContext(ctx, query, r.Name, r.Kind, r.AssetID, r.LocationID, r.Metric, r.Operator, r.Threshold, r.DurationSeconds).
+		Scan(&r.ID, &r.CreatedAtUTC, &r.LastUpdatedAtUTC)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return err
+		}
+
+		return ErrCreateAlertRuleFailed
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetAlertRules(ctx context.Context) ([]model.AlertRule, error) {
+	rows, err := s.db.QueryContext(ctx, selectAlertRules+`ORDER BY "createdAtUTC", "ID";`)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAlertRulesFailed
+	}
+	defer rows.Close()
+
+	rules := []model.AlertRule{}
+
+	for rows.Next() {
+		r, err := scanAlertRule(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetAlertRulesFailed
+		}
+
+		rules = append(rules, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAlertRulesFailed
+	}
+
+	return rules, nil
+}
+
+func (s *PostgresStore) GetAlertRule(ctx context.Context, id uuid.UUID) (*model.AlertRule, error) {
+	r, err := scanAlertRule(s.db.QueryRowContext(ctx, selectAlertRules+`WHERE "ID" = $1;`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, helpers.ErrAlertRuleDoesNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAlertRulesFailed
+	}
+
+	return &r, nil
+}
+
+// DeleteAlertRule removes the rule together with the alerts it opened.
+func (s *PostgresStore) DeleteAlertRule(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM alert_rules WHERE "ID" = $1;`, id)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteAlertRuleFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return helpers.ErrAlertRuleDoesNotExist
+	}
+
+	return nil
+}
+
+const selectAlerts = `
+	SELECT "ID", "ruleID", "assetID", "locationID", "status", "message", "value", "openedAtUTC", "resolvedAtUTC", "resolvedBy"
+	FROM alerts
+`
+
+func scanAlert(row rowScanner) (model.Alert, error) {
+	var a model.Alert
+	err := row.Scan(&a.ID, &a.RuleID, &a.AssetID, &a.LocationID, &a.Status, &a.Message, &a.Value, &a.OpenedAtUTC, &a.ResolvedAtUTC, &a.ResolvedBy)
+	return a, err
+}
+
+// GetAlerts returns alerts newest first.
+func (s *PostgresStore) GetAlerts(ctx context.Context, q model.AlertQuery) ([]model.Alert, error) {
+	var b strings.Builder
+	args := []any{}
+	arg := func(v any) int {
+		args = append(args, v)
+		return len(args)
+	}
+
+	b.WriteString(selectAlerts)
+	b.WriteString(`WHERE TRUE`)
+
+	if q.Status != "" {
+		fmt.Fprintf(&b, ` AND "status" = $%d`, arg(q.Status))
+	}
+	if q.RuleID != nil {
+		fmt.Fprintf(&b, ` AND "ruleID" = $%d`, arg(*q.RuleID))
+	}
+	if q.AssetID != nil {
+		fmt.Fprintf(&b, ` AND "assetID" = $%d`, arg(*q.AssetID))
+	}
+
+	b.WriteString(` ORDER BY "openedAtUTC" DESC, "ID"`)
+	if q.Limit > 0 {
+		fmt.Fprintf(&b, ` LIMIT $%d`, arg(q.Limit))
+	}
+
+	rows, err := s.db.QueryContext(ctx, b.String(), args...)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAlertsFailed
+	}
+	defer rows.Close()
+
+	alerts := []model.Alert{}
+
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetAlertsFailed
+		}
+
+		alerts = append(alerts, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAlertsFailed
+	}
+
+	return alerts, nil
+}
+
+func (s *PostgresStore) GetAlert(ctx context.Context, id uuid.UUID) (*model.Alert, error) {
+	a, err := scanAlert(s.db.QueryRowContext(ctx, selectAlerts+`WHERE "ID" = $1;`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, helpers.ErrAlertDoesNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAlertsFailed
+	}
+
+	return &a, nil
+}
+
+// OpenAlert inserts a as an open alert unless one is already open for the
+// same rule and asset. It reports whether a was inserted, filling in its ID,
+// status and opening time if so.
+func (s *PostgresStore) OpenAlert(ctx context.Context, a *model.Alert) (bool, error) {
+	query := `
+		INSERT INTO alerts ("ruleID", "assetID", "locationID", "message", "value")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT ("ruleID", "assetID") WHERE "status" = 'open' DO NOTHING
+		RETURNING "ID", "status", "openedAtUTC";
+	`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return false, ErrOpenAlertFailed
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, a.RuleID, a.AssetID, a.LocationID, a.Message, a.Value).
+		Scan(&a.ID, &a.Status, &a.OpenedAtUTC)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return false, err
+		}
+
+		return false, ErrOpenAlertFailed
+	}
+
+	assetID, locationID := a.AssetID, a.LocationID
+	if err := recordEvent(ctx, tx, events.New(events.AlertOpened, &assetID, &locationID, *a)); err != nil {
+		return false, ErrOpenAlertFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return false, ErrOpenAlertFailed
+	}
+
+	return true, nil
+}
+
+// ResolveAlert closes an open alert and returns it as resolved.
+func (s *PostgresStore) ResolveAlert(ctx context.Context, id uuid.UUID, resolvedBy string) (*model.Alert, error) {
+	query := `
+		UPDATE alerts
+		SET "status" = 'resolved', "resolvedAtUTC" = NOW(), "resolvedBy" = $2
+		WHERE "ID" = $1 AND "status" = 'open'
+		RETURNING "ID", "ruleID", "assetID", "locationID", "status", "message", "value", "openedAtUTC", "resolvedAtUTC", "resolvedBy";
+	`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrResolveAlertFailed
+	}
+	defer tx.Rollback()
+
+	a, err := scanAlert(tx.QueryRowContext(ctx, query, id, resolvedBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := s.GetAlert(ctx, id); err != nil {
+			return nil, err
+		}
+
+		return nil, helpers.ErrAlertAlreadyResolved
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrResolveAlertFailed
+	}
+
+	assetID, locationID := a.AssetID, a.LocationID
+	if err := recordEvent(ctx, tx, events.New(events.AlertResolved, &assetID, &locationID, a)); err != nil {
+		return nil, ErrResolveAlertFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrResolveAlertFailed
+	}
+
+	return &a, nil
+}
+
+// GetOfflineAssets returns the assets that are offline, or just assetID if
+// it is set, with the time of their last transition to offline. Assets
+// created offline count from their creation.
+func (s *PostgresStore) GetOfflineAssets(ctx context.Context, assetID *uuid.UUID) ([]model.OfflineAsset, error) {
+	query := `
+		SELECT a."ID", a."locationID",
+		       COALESCE(
+		           (SELECT MAX(h."changedAtUTC") FROM asset_status_history h
+		            WHERE h."assetID" = a."ID" AND h."newStatus" = 'offline'),
+		           a."createdAtUTC")
+		FROM assets a
+		WHERE a."status" = 'offline' AND a."deletedAtUTC" IS NULL AND ($1::uuid IS NULL OR a."ID" = $1);
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, assetID)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrEvaluateAlertsFailed
+	}
+	defer rows.Close()
+
+	assets := []model.OfflineAsset{}
+
+	for rows.Next() {
+		var a model.OfflineAsset
+		if err := rows.Scan(&a.AssetID, &a.LocationID, &a.SinceUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrEvaluateAlertsFailed
+		}
+
+		assets = append(assets, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrEvaluateAlertsFailed
+	}
+
+	return assets, nil
+}
+
+// GetLatestReadings returns the most recent reading of metric for every
+// asset that has reported it, or just assetID if it is set.
+func (s *PostgresStore) GetLatestReadings(ctx context.Context, metric string, assetID *uuid.UUID) ([]model.LatestReading, error) {
+	query := `
+		SELECT DISTINCT ON (t."assetID") t."assetID", a."locationID", t."value", t."recordedAtUTC"
+		FROM telemetry t
+		JOIN assets a ON a."ID" = t."assetID"
+		WHERE t."metric" = $1 AND a."deletedAtUTC" IS NULL AND ($2::uuid IS NULL OR t."assetID" = $2)
+		ORDER BY t."assetID", t."recordedAtUTC" DESC, t."ID" DESC;
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, metric, assetID)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrEvaluateAlertsFailed
+	}
+	defer rows.Close()
+
+	readings := []model.LatestReading{}
+
+	for rows.Next() {
+		var r model.LatestReading
+		if err := rows.Scan(&r.AssetID, &r.LocationID, &r.Value, &r.RecordedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrEvaluateAlertsFailed
+		}
+
+		readings = append(readings, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrEvaluateAlertsFailed
+	}
+
+	return readings, nil
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// lastUsedResolution bounds how often authenticating a key writes back its
+// lastUsedAtUTC, so busy keys do not turn every read into a write.
+const lastUsedResolution = time.Minute
+
+var (
+	ErrCreateAPIKeyFailed       = errors.New("failed to create api key")
+	ErrAuthenticateAPIKeyFailed = errors.New("failed to authenticate api key")
+	ErrGetAPIKeysFailed         = errors.New("failed to get api keys")
+	ErrRevokeAPIKeyFailed       = errors.New("failed to revoke api key")
+)
+
+func (s *PostgresStore) CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error {
+	query := `
+		INSERT INTO api_keys ("name", "prefix", "keyHash", "role")
+		VALUES ($1, $2, $3, $4)
+		RETURNING "ID", "createdAtUTC";
+	`
+
+	if err := s.db.QueryRowContext(ctx, query, key.Name, key.Prefix, hash, key.Role).Scan(&key.ID, &key.CreatedAtUTC); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return err
+		}
+
+		return ErrCreateAPIKeyFailed
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey looks up an active key by hash.
+func (s *PostgresStore) AuthenticateAPIKey(ctx context.Context, hash string) (*model.APIKey, error) {
+	query := `
+		SELECT "ID", "name", "prefix", "role", "createdAtUTC", "lastUsedAtUTC", "revokedAtUTC"
+		FROM api_keys
+		WHERE "keyHash" = $1 AND "revokedAtUTC" IS NULL;
+	`
+
+	key := &model.APIKey{}
+	if err := s.db.QueryRowContext(ctx, query, hash).Scan(
+		&key.ID, &key.Name, &key.Prefix, &key.Role, &key.CreatedAtUTC, &key.LastUsedAtUTC, &key.RevokedAtUTC,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrAPIKeyDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrAuthenticateAPIKeyFailed
+	}
+
+	if key.LastUsedAtUTC == nil || time.Since(*key.LastUsedAtUTC) > lastUsedResolution {
+		if _, err := s.db.ExecContext(ctx, `UPDATE api_keys SET "lastUsedAtUTC" = NOW() WHERE "ID" = $1`, key.ID); err != nil {
+			slog.WarnContext(ctx, "failed to update api key last use", slog.Any("error", err))
+		}
+	}
+
+	return key, nil
+}
+
+func (s *PostgresStore) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	query := `
+		SELECT "ID", "name", "prefix", "role", "createdAtUTC", "lastUsedAtUTC", "revokedAtUTC"
+		FROM api_keys
+		ORDER BY "createdAtUTC";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAPIKeysFailed
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+
+	for rows.Next() {
+		var k model.APIKey
+
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.Role, &k.CreatedAtUTC, &k.LastUsedAtUTC, &k.RevokedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetAPIKeysFailed
+		}
+
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAPIKeysFailed
+	}
+
+	return keys, nil
+}
+
+func (s *PostgresStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	query := `
+		UPDATE api_keys
+		SET "revokedAtUTC" = NOW()
+		WHERE "ID" = $1 AND "revokedAtUTC" IS NULL;
+	`
+
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrRevokeAPIKeyFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return helpers.ErrAPIKeyDoesNotExist
+	}
+
+	return nil
+}
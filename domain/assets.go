@@ -2,9 +2,10 @@ package domain
 
 import (
 	"context"
-	"crud/db"
+	"crud/events"
 	"crud/helpers"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -18,101 +19,286 @@ import (
 var (
 	ErrGetAllAssetsFailed       = errors.New("failed to get all assets")
 	ErrGetAssetByLocationFailed = errors.New("failed to get assets")
+	ErrGetAssetFailed           = errors.New("failed to get asset")
 	ErrCreateAssetFailed        = errors.New("failed to create asset")
 	ErrUpdateAssetFailed        = errors.New("failed to update asset")
 	ErrDeleteAssetFailed        = errors.New("failed to delete asset")
+	ErrRestoreAssetFailed       = errors.New("failed to restore asset")
+	ErrCountAssetsFailed        = errors.New("failed to count assets")
 )
 
-func GetAllAssets(ctx context.Context) ([]model.Asset, error) {
-	query := `
-		SELECT a."ID", a."name", a."status", l."name" AS location, a."lastUpdatedAtUTC", a."createdAtUTC"
-		FROM assets a
-		JOIN locations l ON a."locationID" = l."ID";
-	`
+// selectAssets is the shared projection for asset listings. It joins the
+// location name and the most recent position report.
+const selectAssets = `
+	SELECT a."ID", a."name", a."status", l."name" AS location, a."typeID", a."attributes", a."lastSeenAtUTC", a."lastUpdatedAtUTC", a."createdAtUTC", a."deletedAtUTC", a."version",
+	       p."latitude", p."longitude", p."accuracy", p."recordedAtUTC"
+	FROM assets a
+	JOIN locations l ON a."locationID" = l."ID"
+	LEFT JOIN LATERAL (
+		SELECT "latitude", "longitude", "accuracy", "recordedAtUTC"
+		FROM asset_positions
+		WHERE "assetID" = a."ID"
+		ORDER BY "recordedAtUTC" DESC
+		LIMIT 1
+	) p ON TRUE
+`
+
+func scanAsset(rows *sql.Rows) (model.Asset, error) {
+	var (
+		a        model.Asset
+		lat, lng sql.NullFloat64
+		accuracy sql.NullFloat64
+		fixedAt  sql.NullTime
+		attrs    []byte
+	)
+
+	if err := rows.Scan(&a.ID, &a.Name, &a.Status, &a.Location, &a.TypeID, &attrs, &a.LastSeenAtUTC, &a.LastUpdatedAtUTC, &a.CreatedAtUTC, &a.DeletedAtUTC, &a.Version,
+		&lat, &lng, &accuracy, &fixedAt); err != nil {
+		return a, err
+	}
+
+	if err := json.Unmarshal(attrs, &a.Attributes); err != nil {
+		return a, err
+	}
+	if len(a.Attributes) == 0 {
+		a.Attributes = nil
+	}
+
+	if lat.Valid && lng.Valid && fixedAt.Valid {
+		a.LastPosition = &model.Position{
+			Latitude:      &lat.Float64,
+			Longitude:     &lng.Float64,
+			RecordedAtUTC: fixedAt.Time,
+		}
+		if accuracy.Valid {
+			a.LastPosition.Accuracy = &accuracy.Float64
+		}
+	}
+
+	return a, nil
+}
+
+// assetSortColumns maps the sort fields accepted by asset listings onto
+// columns of selectAssets.
+var assetSortColumns = map[string]string{
+	model.SortByName:             `a."name"`,
+	model.SortByCreatedAtUTC:     `a."createdAtUTC"`,
+	model.SortByLastUpdatedAtUTC: `a."lastUpdatedAtUTC"`,
+}
+
+func (s *PostgresStore) GetAllAssets(ctx context.Context, q model.AssetListQuery) ([]model.Asset, string, error) {
+	return s.listAssets(ctx, q, ErrGetAllAssetsFailed)
+}
+
+func (s *PostgresStore) GetAssetsByLocation(ctx context.Context, locationID uuid.UUID, q model.AssetListQuery) ([]model.Asset, string, error) {
+	q.LocationID = &locationID
+
+	return s.listAssets(ctx, q, ErrGetAssetByLocationFailed)
+}
+
+// listAssets returns one page of assets matching q using keyset pagination
+// on the sort column and "ID", along with the cursor of the next page.
+func (s *PostgresStore) listAssets(ctx context.Context, q model.AssetListQuery, failed error) ([]model.Asset, string, error) {
+	normalizeSort(&q.ListQuery)
+	column, ok := assetSortColumns[q.Sort]
+	if !ok {
+		return nil, "", helpers.ErrInvalidSort
+	}
 
-	rows, err := db.DB.QueryContext(ctx, query)
+	after, err := decodeCursor(q.ListQuery)
 	if err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		return nil, "", err
+	}
+
+	var b strings.Builder
+	args := []any{}
+	arg := func(v any) int {
+		args = append(args, v)
+		return len(args)
+	}
 
-		return nil, ErrGetAllAssetsFailed
+	b.WriteString(selectAssets)
+	b.WriteString(`WHERE TRUE`)
+
+	if !q.IncludeDeleted {
+		b.WriteString(` AND a."deletedAtUTC" IS NULL`)
+	}
+	if q.LocationID != nil && q.Recursive {
+		fmt.Fprintf(&b, ` AND a."locationID" IN (%s)`, subtreeQuery(arg(*q.LocationID), q.IncludeDeleted))
+	} else if q.LocationID != nil {
+		fmt.Fprintf(&b, ` AND a."locationID" = $%d`, arg(*q.LocationID))
+	}
+	if q.Status != nil {
+		fmt.Fprintf(&b, ` AND a."status" = $%d`, arg(*q.Status))
+	}
+	if q.NamePrefix != "" {
+		fmt.Fprintf(&b, ` AND a."name" ILIKE $%d`, arg(likePrefix(q.NamePrefix)))
+	}
+	if q.TypeID != nil {
+		fmt.Fprintf(&b, ` AND a."typeID" = $%d`, arg(*q.TypeID))
+	}
+	if len(q.Attributes) > 0 {
+		// containment is served by the GIN index on "attributes"
+		fmt.Fprintf(&b, ` AND a."attributes" @> $%d::JSONB`, arg(attributesJSON(q.Attributes)))
+	}
+	if q.UpdatedAfter != nil {
+		fmt.Fprintf(&b, ` AND a."lastUpdatedAtUTC" >= $%d`, arg(*q.UpdatedAfter))
+	}
+	if q.UpdatedBefore != nil {
+		fmt.Fprintf(&b, ` AND a."lastUpdatedAtUTC" < $%d`, arg(*q.UpdatedBefore))
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after != nil {
+		value, err := cursorArg(q.Sort, after.Value)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Fprintf(&b, ` AND (%s, a."ID") %s ($%d, $%d)`, column, comparison, arg(value), arg(after.ID))
+	}
+
+	limit := pageLimit(q.ListQuery)
+	fmt.Fprintf(&b, ` ORDER BY %s %s, a."ID" %s LIMIT $%d;`, column, direction, direction, arg(limit+1))
+
+	rows, err := s.db.QueryContext(ctx, b.String(), args...)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, "", failed
 	}
 	defer rows.Close()
 
 	assets := []model.Asset{}
 
 	for rows.Next() {
-		var a model.Asset
+		a, err := scanAsset(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-		if err := rows.Scan(&a.ID, &a.Name, &a.Status, &a.Location, &a.LastUpdatedAtUTC, &a.CreatedAtUTC); err != nil {
-			slog.Error(`{"error":"` + err.Error() + `"}`)
-
-			return nil, ErrGetAllAssetsFailed
+			return nil, "", failed
 		}
 
 		assets = append(assets, a)
 	}
 
 	if err := rows.Err(); err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-		return nil, ErrGetAllAssetsFailed
+		return nil, "", failed
 	}
 
-	return assets, nil
+	assets, next := assetPage(q.ListQuery, assets, limit)
+
+	return assets, next, nil
 }
 
-func GetAssetsByLocation(ctx context.Context, locationID uuid.UUID) ([]model.Asset, error) {
-	query := `
-	SELECT a."ID", a."name", a."status", l."name" AS location, a."lastUpdatedAtUTC", a."createdAtUTC"
-	FROM assets a
-	JOIN locations l ON a."locationID" = l."ID"
-    WHERE a."locationID" = $1;
-	`
+func (s *PostgresStore) GetAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.AssetDetail, error) {
+	query := selectAssets + `WHERE a."ID" = $1 AND a."locationID" = $2 AND a."deletedAtUTC" IS NULL;`
 
-	rows, err := db.DB.QueryContext(ctx, query, locationID)
+	rows, err := s.db.QueryContext(ctx, query, assetID, locationID)
 	if err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-		return nil, ErrGetAssetByLocationFailed
+		return nil, ErrGetAssetFailed
 	}
 	defer rows.Close()
 
-	assets := []model.Asset{}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-	for rows.Next() {
-		var a model.Asset
+			return nil, ErrGetAssetFailed
+		}
 
-		if err := rows.Scan(&a.ID, &a.Name, &a.Status, &a.Location, &a.LastUpdatedAtUTC, &a.CreatedAtUTC); err != nil {
-			slog.Error(`{"error":"` + err.Error() + `"}`)
+		return nil, helpers.ErrAssetDoesNotExist
+	}
 
-			return nil, ErrGetAssetByLocationFailed
-		}
+	a, err := scanAsset(rows)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-		assets = append(assets, a)
+		return nil, ErrGetAssetFailed
 	}
+	rows.Close()
 
-	if err := rows.Err(); err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+	loc, err := s.GetLocation(ctx, locationID)
+	if err != nil {
+		// The asset references the location, so it can only be missing if
+		// both were deleted between the two queries.
+		if errors.Is(err, helpers.ErrLocationDoesNotExist) {
+			return nil, helpers.ErrAssetDoesNotExist
+		}
+		return nil, ErrGetAssetFailed
+	}
+
+	return &model.AssetDetail{Asset: a, Location: *loc}, nil
+}
+
+// GetAssetLocationID returns the location an asset currently belongs to.
+func (s *PostgresStore) GetAssetLocationID(ctx context.Context, assetID uuid.UUID) (uuid.UUID, error) {
+	var locationID uuid.UUID
 
-		return nil, ErrGetAssetByLocationFailed
+	err := s.db.QueryRowContext(ctx, `SELECT "locationID" FROM assets WHERE "ID" = $1 AND "deletedAtUTC" IS NULL;`, assetID).Scan(&locationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, helpers.ErrAssetDoesNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return uuid.Nil, ErrGetAssetFailed
 	}
 
-	return assets, nil
+	return locationID, nil
 }
 
-func CreateAsset(ctx context.Context, a *model.CreateAssetRequest) error {
+func (s *PostgresStore) CreateAsset(ctx context.Context, a *model.CreateAssetRequest) error {
+	// asset types cannot be changed, so the schema read here is the one the
+	// foreign key below refers to
+	var assetType *model.AssetType
+	if a.TypeID != nil {
+		var err error
+		if assetType, err = s.getAssetType(ctx, s.db, *a.TypeID); err != nil {
+			return err
+		}
+	}
+	if err := checkAttributes(assetType, a.Attributes); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateAssetFailed
+	}
+	defer tx.Rollback()
+
+	// the foreign key would accept a deleted location, so the insert reads
+	// it, locking it against a concurrent delete
 	query := `
-		INSERT INTO assets ("name", "status", "locationID")
-		VALUES ($1,$2,$3)
+		INSERT INTO assets ("name", "status", "locationID", "typeID", "attributes")
+		SELECT $1, $2, "ID", $4, $5 FROM locations
+		WHERE "ID" = $3 AND "deletedAtUTC" IS NULL
+		FOR SHARE
 		RETURNING "ID";
 	`
 
-	if err := db.DB.QueryRowContext(ctx, query,
+	if err := tx.QueryRowContext(ctx, query,
 		a.Name,
 		a.Status,
 		a.LocationID,
+		a.TypeID,
+		attributesJSON(a.Attributes),
 	).Scan(&a.ID); err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		if errors.Is(err, sql.ErrNoRows) {
+			return helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
 		if err := helpers.HandlePostgresError(err); err != nil {
 			return err
@@ -121,15 +307,26 @@ func CreateAsset(ctx context.Context, a *model.CreateAssetRequest) error {
 		return ErrCreateAssetFailed
 	}
 
+	locationID := a.LocationID
+	if err := recordEvent(ctx, tx, events.New(events.AssetCreated, a.ID, &locationID, *a)); err != nil {
+		return ErrCreateAssetFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateAssetFailed
+	}
+
 	return nil
 }
 
-func UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, patch model.AssetPatch) (*model.Asset, error) {
+func (s *PostgresStore) UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, patch model.AssetPatch) (*model.Asset, error) {
 	var b strings.Builder
 	var args []any
 	argIdx := 1
 
-	b.WriteString(`UPDATE assets SET "lastUpdatedAtUTC" = NOW(), `)
+	b.WriteString(`UPDATE assets SET "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1, `)
 
 	first := true
 
@@ -154,23 +351,81 @@ func UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, p
 		first = false
 	}
 
+	if patch.TypeID != nil {
+		if !first {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `"typeID" = $%d`, argIdx)
+		args = append(args, patch.TypeID)
+		argIdx++
+		first = false
+	}
+
+	if patch.Attributes != nil {
+		if !first {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, `"attributes" = $%d`, argIdx)
+		args = append(args, attributesJSON(patch.Attributes))
+		argIdx++
+		first = false
+	}
+
 	// TODO: move this to handler
 	if first {
 		return nil, errors.New("no valid fields to update")
 	}
 
-	fmt.Fprintf(&b, ` WHERE "ID" = $%d AND "locationID" = $%d RETURNING "ID"`, argIdx, argIdx+1)
+	fmt.Fprintf(&b, ` WHERE "ID" = $%d AND "locationID" = $%d RETURNING "ID", "status", "version"`, argIdx, argIdx+1)
 	args = append(args, assetID, locationID)
 
 	query := b.String()
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrUpdateAssetFailed
+	}
+	defer tx.Rollback()
+
+	// lock the row so the status we record as "old" is the one we replace
+	var (
+		oldStatus model.Status
+		version   int
+		typeID    *uuid.UUID
+		attrs     []byte
+	)
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "status", "version", "typeID", "attributes" FROM assets WHERE "ID" = $1 AND "locationID" = $2 AND "deletedAtUTC" IS NULL FOR UPDATE`,
+		assetID, locationID,
+	).Scan(&oldStatus, &version, &typeID, &attrs); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrUpdateAssetFailed
+	}
+
+	if patch.Version != nil && *patch.Version != version {
+		return nil, helpers.ErrVersionMismatch
+	}
+
+	if patch.TypeID != nil || patch.Attributes != nil {
+		if err := s.checkPatchedAttributes(ctx, tx, typeID, attrs, patch); err != nil {
+			return nil, err
+		}
+	}
+
 	asset := &model.Asset{}
-	if err := db.DB.QueryRowContext(ctx, query, args...).Scan(&asset.ID); err != nil {
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&asset.ID, &asset.Status, &asset.Version); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, helpers.ErrAssetDoesNotExist
 		}
 
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
 		if err := helpers.HandlePostgresError(err); err != nil {
 			return nil, err
@@ -179,21 +434,272 @@ func UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, p
 		return nil, ErrUpdateAssetFailed
 	}
 
+	if err := recordEvent(ctx, tx, events.New(events.AssetUpdated, &assetID, &locationID, patch)); err != nil {
+		return nil, ErrUpdateAssetFailed
+	}
+
+	if asset.Status != oldStatus {
+		if err := recordStatusChange(ctx, tx, assetID, oldStatus, asset.Status, model.StatusChangeSources.Manual); err != nil {
+			return nil, ErrUpdateAssetFailed
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrUpdateAssetFailed
+	}
+
 	return asset, nil
 }
 
-func DeleteAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) error {
+// checkPatchedAttributes validates the attributes an asset of type typeID,
+// holding attrs, ends up with once patch is applied.
+func (s *PostgresStore) checkPatchedAttributes(ctx context.Context, q queryer, typeID *uuid.UUID, attrs []byte, patch model.AssetPatch) error {
+	if patch.TypeID != nil {
+		typeID = patch.TypeID
+	}
+
+	values := patch.Attributes
+	if values == nil {
+		if err := json.Unmarshal(attrs, &values); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return ErrUpdateAssetFailed
+		}
+	}
+
+	var assetType *model.AssetType
+	if typeID != nil {
+		var err error
+		if assetType, err = s.getAssetType(ctx, q, *typeID); err != nil {
+			return err
+		}
+	}
+
+	return checkAttributes(assetType, values)
+}
+
+// UpdateAssetStatus sets an asset's status by ID alone, for callers such as
+// devices that do not know which location the asset belongs to.
+func (s *PostgresStore) UpdateAssetStatus(ctx context.Context, assetID uuid.UUID, status model.Status, source model.StatusChangeSource) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrUpdateAssetFailed
+	}
+	defer tx.Rollback()
+
+	var oldStatus model.Status
+	if err := tx.QueryRowContext(ctx, `SELECT "status" FROM assets WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR UPDATE`, assetID).Scan(&oldStatus); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrUpdateAssetFailed
+	}
+
+	if oldStatus == status {
+		return nil
+	}
+
+	query := `
+		UPDATE assets
+		SET "status" = $1, "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "ID" = $2;
+	`
+
+	if _, err := tx.ExecContext(ctx, query, status, assetID); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrUpdateAssetFailed
+	}
+
+	if err := recordStatusChange(ctx, tx, assetID, oldStatus, status, source); err != nil {
+		return ErrUpdateAssetFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrUpdateAssetFailed
+	}
+
+	return nil
+}
+
+// DeleteAsset soft-deletes the asset. It keeps its history and can be
+// restored until PurgeDeleted removes it.
+func (s *PostgresStore) DeleteAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, version *int) error {
 	query := `
-        DELETE FROM assets 
-        WHERE "locationID" = $1 AND "ID" = $2;
-    `
+		UPDATE assets
+		SET "deletedAtUTC" = NOW(), "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "locationID" = $1 AND "ID" = $2 AND "deletedAtUTC" IS NULL
+		  AND ($3::INTEGER IS NULL OR "version" = $3);
+	`
 
-	_, err := db.DB.ExecContext(ctx, query, locationID, assetID)
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteAssetFailed
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, query, locationID, assetID, version)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteAssetFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if version == nil {
+			return helpers.ErrAssetDoesNotExist
+		}
+
+		// tell a stale version apart from a missing asset
+		var exists bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM assets WHERE "locationID" = $1 AND "ID" = $2 AND "deletedAtUTC" IS NULL)`,
+			locationID, assetID,
+		).Scan(&exists); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return ErrDeleteAssetFailed
+		}
+		if exists {
+			return helpers.ErrVersionMismatch
+		}
+
+		return helpers.ErrAssetDoesNotExist
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.AssetDeleted, &assetID, &locationID, nil)); err != nil {
+		return ErrDeleteAssetFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
 		return ErrDeleteAssetFailed
 	}
 
 	return nil
 }
+
+// RestoreAsset undoes a soft delete. It fails if the asset's location has
+// been deleted since, or if another asset has taken its name.
+func (s *PostgresStore) RestoreAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.Asset, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreAssetFailed
+	}
+	defer tx.Rollback()
+
+	var deletedAt sql.NullTime
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "deletedAtUTC" FROM assets WHERE "ID" = $1 AND "locationID" = $2 FOR UPDATE`,
+		assetID, locationID,
+	).Scan(&deletedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreAssetFailed
+	}
+
+	if !deletedAt.Valid {
+		return nil, helpers.ErrAssetNotDeleted
+	}
+
+	var id uuid.UUID
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "ID" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR SHARE`,
+		locationID,
+	).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreAssetFailed
+	}
+
+	query := `
+		UPDATE assets
+		SET "deletedAtUTC" = NULL, "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "ID" = $1
+		RETURNING "ID", "version";
+	`
+
+	asset := &model.Asset{}
+	if err := tx.QueryRowContext(ctx, query, assetID).Scan(&asset.ID, &asset.Version); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrRestoreAssetFailed
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.AssetRestored, &assetID, &locationID, nil)); err != nil {
+		return nil, ErrRestoreAssetFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreAssetFailed
+	}
+
+	return asset, nil
+}
+
+// CountAssets returns the number of assets per location and status.
+func (s *PostgresStore) CountAssets(ctx context.Context) ([]model.AssetCount, error) {
+	query := `
+		SELECT a."locationID", l."code", a."status", COUNT(*)
+		FROM assets a
+		JOIN locations l ON a."locationID" = l."ID"
+		WHERE a."deletedAtUTC" IS NULL
+		GROUP BY a."locationID", l."code", a."status"
+		ORDER BY l."code", a."status";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCountAssetsFailed
+	}
+	defer rows.Close()
+
+	var counts []model.AssetCount
+	for rows.Next() {
+		var c model.AssetCount
+		if err := rows.Scan(&c.LocationID, &c.LocationCode, &c.Status, &c.Count); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrCountAssetsFailed
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCountAssetsFailed
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,159 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"crud/helpers"
+	"crud/jsonschema"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCreateAssetTypeFailed = errors.New("failed to create asset type")
+	ErrGetAssetTypesFailed   = errors.New("failed to get asset types")
+	ErrDeleteAssetTypeFailed = errors.New("failed to delete asset type")
+)
+
+const selectAssetTypes = `
+	SELECT "ID", "name", "schema", "createdAtUTC"
+	FROM asset_types
+`
+
+func scanAssetType(row rowScanner) (model.AssetType, error) {
+	var t model.AssetType
+	err := row.Scan(&t.ID, &t.Name, &t.Schema, &t.CreatedAtUTC)
+	return t, err
+}
+
+func (s *PostgresStore) CreateAssetType(ctx context.Context, t *model.AssetType) error {
+	query := `
+		INSERT INTO asset_types ("name", "schema")
+		VALUES ($1, $2)
+		RETURNING "ID", "createdAtUTC";
+	`
+
+	if err := s.db.QueryRowContext(ctx, query, t.Name, []byte(t.Schema)).Scan(&t.ID, &t.CreatedAtUTC); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return err
+		}
+
+		return ErrCreateAssetTypeFailed
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetAssetTypes(ctx context.Context) ([]model.AssetType, error) {
+	rows, err := s.db.QueryContext(ctx, selectAssetTypes+`ORDER BY "name", "ID";`)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAssetTypesFailed
+	}
+	defer rows.Close()
+
+	assetTypes := []model.AssetType{}
+
+	for rows.Next() {
+		t, err := scanAssetType(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetAssetTypesFailed
+		}
+
+		assetTypes = append(assetTypes, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAssetTypesFailed
+	}
+
+	return assetTypes, nil
+}
+
+func (s *PostgresStore) GetAssetType(ctx context.Context, id uuid.UUID) (*model.AssetType, error) {
+	return s.getAssetType(ctx, s.db, id)
+}
+
+// getAssetType reads a type through q, which is either the pool or the
+// transaction of a write that validates attributes against it.
+func (s *PostgresStore) getAssetType(ctx context.Context, q queryer, id uuid.UUID) (*model.AssetType, error) {
+	t, err := scanAssetType(q.QueryRowContext(ctx, selectAssetTypes+`WHERE "ID" = $1;`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, helpers.ErrAssetTypeDoesNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAssetTypesFailed
+	}
+
+	return &t, nil
+}
+
+func (s *PostgresStore) DeleteAssetType(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM asset_types WHERE "ID" = $1;`, id)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		// assets_typeID_fkey reads as a missing type when an asset is
+		// written; here it means the type is still referenced
+		if errors.Is(helpers.HandlePostgresError(err), helpers.ErrAssetTypeDoesNotExist) {
+			return helpers.ErrAssetTypeInUse
+		}
+
+		return ErrDeleteAssetTypeFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return helpers.ErrAssetTypeDoesNotExist
+	}
+
+	return nil
+}
+
+// checkAttributes validates the attributes of an asset of type t, or, when
+// t is nil, that an untyped asset has none. Schema violations are returned
+// as a *jsonschema.ValidationError.
+func checkAttributes(t *model.AssetType, attrs map[string]any) error {
+	if t == nil {
+		if len(attrs) > 0 {
+			return helpers.ErrAttributesWithoutType
+		}
+		return nil
+	}
+
+	schema, err := jsonschema.Compile(t.Schema)
+	if err != nil {
+		// schemas are compiled before they are stored
+		return err
+	}
+
+	if attrs == nil {
+		attrs = map[string]any{}
+	}
+
+	return schema.Validate("attributes", attrs)
+}
+
+// attributesJSON encodes attributes for the JSONB column, which holds an
+// empty object rather than null for untyped assets.
+func attributesJSON(attrs map[string]any) []byte {
+	if len(attrs) == 0 {
+		return []byte(`{}`)
+	}
+
+	data, _ := json.Marshal(attrs)
+	return data
+}
@@ -0,0 +1,429 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrImportFailed = errors.New("import failed")
+	ErrExportFailed = errors.New("export failed")
+)
+
+// errLocationTaken is reported for import rows skipped because a location
+// with the same name or code already exists.
+var errLocationTaken = errors.New("location name or code already exists")
+
+// ImportLocations inserts the locations in one transaction. Rows that clash
+// with an existing name or code, including other rows of the same import,
+// are skipped. A row's parentID may name an existing location or the ID of
+// another row that was created; otherwise the row fails. The results are in
+// the order of locations, whose ParentIDs are set to the parents used.
+func (s *PostgresStore) ImportLocations(ctx context.Context, locations []model.LocationImportRow) ([]model.ImportRowResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+	defer tx.Rollback()
+
+	// FOR SHARE keeps an existing parent from being deleted before the
+	// transaction commits.
+	parentStmt, err := tx.PrepareContext(ctx, `
+		SELECT "ID" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR SHARE;
+	`)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+	defer parentStmt.Close()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO locations ("name", "code", "parentID")
+		VALUES ($1, $2, $3)
+		ON CONFLICT DO NOTHING
+		RETURNING "ID";
+	`)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+	defer stmt.Close()
+
+	results := make([]model.ImportRowResult, len(locations))
+	// created maps the IDs rows carried to the IDs of the locations created
+	created := make(map[uuid.UUID]uuid.UUID)
+
+	for _, i := range importOrder(locations) {
+		l := &locations[i]
+
+		if l.ParentID != nil {
+			if id, ok := created[*l.ParentID]; ok {
+				l.ParentID = &id
+			} else {
+				var id uuid.UUID
+				err := parentStmt.QueryRowContext(ctx, *l.ParentID).Scan(&id)
+				if errors.Is(err, sql.ErrNoRows) {
+					results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: helpers.ErrParentLocationDoesNotExist.Error()}
+					continue
+				}
+				if err != nil {
+					slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+					return nil, ErrImportFailed
+				}
+			}
+		}
+
+		var id uuid.UUID
+
+		err := stmt.QueryRowContext(ctx, l.Name, l.Code, l.ParentID).Scan(&id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Skipped, Error: errLocationTaken.Error()}
+		case err != nil:
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrImportFailed
+		default:
+			if l.ID != nil {
+				created[*l.ID] = id
+			}
+			location := model.Location{ID: &id, Name: l.Name, Code: l.Code, ParentID: l.ParentID}
+			if err := recordEvent(ctx, tx, events.New(events.LocationCreated, nil, &id, location)); err != nil {
+				return nil, ErrImportFailed
+			}
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Created, ID: &id}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+
+	return results, nil
+}
+
+// importOrder returns the indexes of rows in the order they are created: a
+// row naming another row's ID as its parent comes after that row, and rows
+// are otherwise kept in file order. Rows in a cycle keep file order and fail
+// for want of a parent.
+func importOrder(rows []model.LocationImportRow) []int {
+	index := make(map[uuid.UUID]int)
+	for i, l := range rows {
+		if l.ID == nil {
+			continue
+		}
+		if _, ok := index[*l.ID]; !ok {
+			index[*l.ID] = i
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(rows))
+	order := make([]int, 0, len(rows))
+
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != unvisited {
+			return
+		}
+		state[i] = visiting
+		if p := rows[i].ParentID; p != nil {
+			if j, ok := index[*p]; ok {
+				visit(j)
+			}
+		}
+		state[i] = visited
+		order = append(order, i)
+	}
+
+	for i := range rows {
+		visit(i)
+	}
+
+	return order
+}
+
+// ImportAssets inserts the assets in one transaction. Rows whose location
+// or type does not exist, or whose attributes do not satisfy the type's
+// schema, fail, and rows whose name is already taken are skipped. The
+// results are in the order of assets.
+func (s *PostgresStore) ImportAssets(ctx context.Context, assets []model.AssetImportRow) ([]model.ImportRowResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+	defer tx.Rollback()
+
+	ids := make([]string, 0, len(assets))
+	for _, a := range assets {
+		ids = append(ids, a.LocationID.String())
+	}
+
+	// FOR SHARE keeps the referenced locations from being deleted before
+	// the transaction commits.
+	rows, err := tx.QueryContext(ctx,
+		`SELECT "ID" FROM locations WHERE "ID" = ANY($1::uuid[]) AND "deletedAtUTC" IS NULL FOR SHARE;`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+
+	known := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrImportFailed
+		}
+		known[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+
+	assetTypes, err := importAssetTypes(ctx, tx, assets)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO assets ("name", "status", "locationID", "typeID", "attributes")
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+		RETURNING "ID";
+	`)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+	defer stmt.Close()
+
+	results := make([]model.ImportRowResult, len(assets))
+
+	for i, a := range assets {
+		if !known[a.LocationID] {
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: helpers.ErrLocationDoesNotExist.Error()}
+			continue
+		}
+
+		var assetType *model.AssetType
+		if a.TypeID != nil {
+			if assetType = assetTypes[*a.TypeID]; assetType == nil {
+				results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: helpers.ErrAssetTypeDoesNotExist.Error()}
+				continue
+			}
+		}
+		if err := checkAttributes(assetType, a.Attributes); err != nil {
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: err.Error()}
+			continue
+		}
+
+		var id uuid.UUID
+
+		err := stmt.QueryRowContext(ctx, a.Name, a.Status, a.LocationID, a.TypeID, attributesJSON(a.Attributes)).Scan(&id)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Skipped, Error: helpers.ErrAssetAlreadyExists.Error()}
+		case err != nil:
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrImportFailed
+		default:
+			status := model.Status(a.Status)
+			if err := recordStatusChange(ctx, tx, id, status, status, model.StatusChangeSources.Import); err != nil {
+				return nil, ErrImportFailed
+			}
+			locationID := a.LocationID
+			asset := model.CreateAssetRequest{
+				ID:         &id,
+				Name:       a.Name,
+				Status:     status,
+				LocationID: locationID,
+				TypeID:     a.TypeID,
+				Attributes: a.Attributes,
+			}
+			if err := recordEvent(ctx, tx, events.New(events.AssetCreated, &id, &locationID, asset)); err != nil {
+				return nil, ErrImportFailed
+			}
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Created, ID: &id}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+
+	return results, nil
+}
+
+// importAssetTypes loads the types the import rows refer to, keyed by ID.
+// Like the referenced locations they are locked FOR SHARE, so a concurrent
+// delete cannot turn a row into a foreign key violation that fails the
+// whole import.
+func importAssetTypes(ctx context.Context, tx *sql.Tx, assets []model.AssetImportRow) (map[uuid.UUID]*model.AssetType, error) {
+	ids := []string{}
+	for _, a := range assets {
+		if a.TypeID != nil {
+			ids = append(ids, a.TypeID.String())
+		}
+	}
+
+	assetTypes := make(map[uuid.UUID]*model.AssetType)
+	if len(ids) == 0 {
+		return assetTypes, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, selectAssetTypes+`WHERE "ID" = ANY($1::uuid[]) FOR SHARE;`, pq.Array(ids))
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t, err := scanAssetType(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrImportFailed
+		}
+		assetTypes[*t.ID] = &t
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrImportFailed
+	}
+
+	return assetTypes, nil
+}
+
+// ExportLocations calls fn for every location in creation order, streaming
+// rows from the database. It stops at the first error fn returns.
+func (s *PostgresStore) ExportLocations(ctx context.Context, fn func(model.Location) error) error {
+	query := `
+		SELECT "ID", "name", "code", "parentID", "createdAtUTC", "lastUpdatedAtUTC"
+		FROM locations
+		WHERE "deletedAtUTC" IS NULL
+		ORDER BY "createdAtUTC", "ID";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrExportFailed
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var loc model.Location
+
+		if err := rows.Scan(&loc.ID, &loc.Name, &loc.Code, &loc.ParentID, &loc.CreatedAtUTC, &loc.LastUpdatedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return ErrExportFailed
+		}
+
+		if err := fn(loc); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrExportFailed
+	}
+
+	return nil
+}
+
+// ExportAssets calls fn for every asset in creation order, streaming rows
+// from the database. It stops at the first error fn returns.
+func (s *PostgresStore) ExportAssets(ctx context.Context, fn func(model.AssetExportRow) error) error {
+	query := `
+		SELECT "ID", "name", "status", "locationID", "typeID", "attributes", "lastSeenAtUTC", "createdAtUTC", "lastUpdatedAtUTC"
+		FROM assets
+		WHERE "deletedAtUTC" IS NULL
+		ORDER BY "createdAtUTC", "ID";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrExportFailed
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			a     model.AssetExportRow
+			attrs []byte
+		)
+
+		if err := rows.Scan(&a.ID, &a.Name, &a.Status, &a.LocationID, &a.TypeID, &attrs, &a.LastSeenAtUTC, &a.CreatedAtUTC, &a.LastUpdatedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return ErrExportFailed
+		}
+
+		if err := json.Unmarshal(attrs, &a.Attributes); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return ErrExportFailed
+		}
+		if len(a.Attributes) == 0 {
+			a.Attributes = nil
+		}
+
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrExportFailed
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"testing"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func TestImportLocationTree(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	existing := &model.Location{Name: "Existing", Code: "EXST"}
+	if err := s.CreateLocation(ctx, existing); err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+
+	site, building, missing := uuid.New(), uuid.New(), uuid.New()
+	row := func(name, code string, id, parentID *uuid.UUID) model.LocationImportRow {
+		return model.LocationImportRow{
+			LocationInput: model.LocationInput{Name: name, Code: code, ParentID: parentID},
+			ID:            id,
+		}
+	}
+
+	rows := []model.LocationImportRow{
+		// the floor comes before the building it is in
+		row("Floor One", "FLR1", nil, &building),
+		row("Building A", "BLDA", &building, &site),
+		row("Site One", "SITE", &site, nil),
+		row("Annex", "ANEX", nil, existing.ID),
+		row("Orphan", "ORPH", nil, &missing),
+	}
+
+	results, err := s.ImportLocations(ctx, rows)
+	if err != nil {
+		t.Fatalf("ImportLocations: %v", err)
+	}
+
+	for i, want := range []model.ImportRowStatus{
+		model.ImportRowStatuses.Created,
+		model.ImportRowStatuses.Created,
+		model.ImportRowStatuses.Created,
+		model.ImportRowStatuses.Created,
+		model.ImportRowStatuses.Failed,
+	} {
+		if results[i].Status != want {
+			t.Errorf("row %d: status = %s (%s), want %s", i+1, results[i].Status, results[i].Error, want)
+		}
+	}
+	if results[4].Error != helpers.ErrParentLocationDoesNotExist.Error() {
+		t.Errorf("orphan row error = %q", results[4].Error)
+	}
+
+	// parents are the created locations, not the IDs in the file
+	parentOf := func(i int) *uuid.UUID {
+		l, err := s.GetLocation(ctx, *results[i].ID)
+		if err != nil {
+			t.Fatalf("GetLocation: %v", err)
+		}
+		return l.ParentID
+	}
+	if p := parentOf(0); p == nil || *p != *results[1].ID {
+		t.Errorf("floor parent = %v, want the created building %s", p, results[1].ID)
+	}
+	if p := parentOf(1); p == nil || *p != *results[2].ID {
+		t.Errorf("building parent = %v, want the created site %s", p, results[2].ID)
+	}
+	if p := parentOf(2); p != nil {
+		t.Errorf("site parent = %v, want none", p)
+	}
+	if p := parentOf(3); p == nil || *p != *existing.ID {
+		t.Errorf("annex parent = %v, want the existing location", p)
+	}
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// cursorTimeLayout is fixed-width so that formatted UTC timestamps sort the
+// same way as the times they represent.
+const cursorTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// cursor is the keyset position after the last row of a page: the value of
+// the sort column and the row ID as a tie-breaker.
+type cursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+func sortKey(q model.ListQuery) string {
+	if q.Desc {
+		return "-" + q.Sort
+	}
+	return q.Sort
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns nil when q has no cursor.
+func decodeCursor(q model.ListQuery) (*cursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, helpers.ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sortKey(q) {
+		return nil, helpers.ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// cursorArg converts a cursor value back into a query argument for the sort
+// column.
+func cursorArg(field, value string) (any, error) {
+	switch field {
+	case model.SortByCreatedAtUTC, model.SortByLastUpdatedAtUTC:
+		t, err := time.Parse(cursorTimeLayout, value)
+		if err != nil {
+			return nil, helpers.ErrInvalidCursor
+		}
+		return t, nil
+	}
+
+	return value, nil
+}
+
+func formatCursorTime(t time.Time) string {
+	return t.UTC().Format(cursorTimeLayout)
+}
+
+// parseStoredTime reads the RFC 3339 timestamps model.Location carries as
+// strings.
+func parseStoredTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, s)
+	return t
+}
+
+func pageLimit(q model.ListQuery) int {
+	if q.Limit <= 0 {
+		return model.DefaultPageLimit
+	}
+	if q.Limit > model.MaxPageLimit {
+		return model.MaxPageLimit
+	}
+	return q.Limit
+}
+
+func normalizeSort(q *model.ListQuery) {
+	if q.Sort == "" {
+		q.Sort = model.SortByCreatedAtUTC
+	}
+}
+
+// assetSortValue returns the cursor value of the asset's sort column.
+func assetSortValue(field string, a model.Asset) string {
+	switch field {
+	case model.SortByName:
+		return a.Name
+	case model.SortByLastUpdatedAtUTC:
+		return formatCursorTime(a.LastUpdatedAtUTC)
+	}
+	return formatCursorTime(a.CreatedAtUTC)
+}
+
+// locationSortValue returns the cursor value of the location's sort column.
+func locationSortValue(field string, l model.Location) string {
+	switch field {
+	case model.SortByName:
+		return l.Name
+	case model.SortByCode:
+		return l.Code
+	case model.SortByLastUpdatedAtUTC:
+		return formatCursorTime(parseStoredTime(l.LastUpdatedAtUTC))
+	}
+	return formatCursorTime(parseStoredTime(l.CreatedAtUTC))
+}
+
+// likePrefix escapes LIKE wildcards in p and appends %.
+func likePrefix(p string) string {
+	out := make([]rune, 0, len(p)+1)
+	for _, r := range p {
+		if r == '\\' || r == '%' || r == '_' {
+			out = append(out, '\\')
+		}
+		out = append(out, r)
+	}
+	return string(append(out, '%'))
+}
+
+// assetPage trims a result fetched with limit+1 rows to limit and returns the
+// cursor of the following page, or "" when there is none.
+func assetPage(q model.ListQuery, assets []model.Asset, limit int) ([]model.Asset, string) {
+	if len(assets) <= limit {
+		return assets, ""
+	}
+
+	assets = assets[:limit]
+	last := assets[limit-1]
+
+	return assets, encodeCursor(cursor{Sort: sortKey(q), Value: assetSortValue(q.Sort, last), ID: *last.ID})
+}
+
+// locationPage is assetPage for locations.
+func locationPage(q model.ListQuery, locations []model.Location, limit int) ([]model.Location, string) {
+	if len(locations) <= limit {
+		return locations, ""
+	}
+
+	locations = locations[:limit]
+	last := locations[limit-1]
+
+	return locations, encodeCursor(cursor{Sort: sortKey(q), Value: locationSortValue(q.Sort, last), ID: *last.ID})
+}
+
+// pageAfter sorts keys by (value, ID) in the direction of q and returns the
+// indexes of the rows following the cursor, at most limit+1 of them. It is
+// the in-memory counterpart of the keyset queries used by PostgresStore.
+func pageAfter(q model.ListQuery, after *cursor, keys []cursor, limit int) []int {
+	idx := make([]int, len(keys))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	less := func(a, b cursor) bool {
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		return bytes.Compare(a.ID[:], b.ID[:]) < 0
+	}
+
+	sort.Slice(idx, func(i, j int) bool {
+		if q.Desc {
+			return less(keys[idx[j]], keys[idx[i]])
+		}
+		return less(keys[idx[i]], keys[idx[j]])
+	})
+
+	out := make([]int, 0, limit+1)
+	for _, i := range idx {
+		if after != nil {
+			if q.Desc && !less(keys[i], *after) || !q.Desc && !less(*after, keys[i]) {
+				continue
+			}
+		}
+		out = append(out, i)
+		if len(out) == limit+1 {
+			break
+		}
+	}
+
+	return out
+}
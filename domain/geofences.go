@@ -0,0 +1,290 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSetGeofenceFailed       = errors.New("failed to set geofence")
+	ErrGetGeofenceFailed       = errors.New("failed to get geofence")
+	ErrDeleteGeofenceFailed    = errors.New("failed to delete geofence")
+	ErrGetGeofenceEventsFailed = errors.New("failed to get geofence events")
+)
+
+func (s *PostgresStore) SetGeofence(ctx context.Context, g *model.Geofence) error {
+	var centerLat, centerLng any
+	if g.Center != nil {
+		centerLat, centerLng = g.Center.Latitude, g.Center.Longitude
+	}
+
+	var polygon any
+	if g.Polygon != nil {
+		data, err := json.Marshal(g.Polygon)
+		if err != nil {
+			return ErrSetGeofenceFailed
+		}
+		polygon = string(data)
+	}
+
+	// the foreign key would accept a deleted location
+	query := `
+		INSERT INTO geofences ("locationID", "shape", "centerLatitude", "centerLongitude", "radiusMeters", "polygon")
+		SELECT "ID", $2, $3, $4, $5, $6::jsonb FROM locations
+		WHERE "ID" = $1 AND "deletedAtUTC" IS NULL
+		ON CONFLICT ("locationID") DO UPDATE
+		SET "shape" = EXCLUDED."shape",
+		    "centerLatitude" = EXCLUDED."centerLatitude",
+		    "centerLongitude" = EXCLUDED."centerLongitude",
+		    "radiusMeters" = EXCLUDED."radiusMeters",
+		    "polygon" = EXCLUDED."polygon",
+		    "lastUpdatedAtUTC" = NOW()
+		RETURNING "createdAtUTC", "lastUpdatedAtUTC";
+	`
+
+	if err := s.db.QueryRowContext(ctx, query,
+		g.LocationID,
+		g.Shape,
+		centerLat,
+		centerLng,
+		g.RadiusMeters,
+		polygon,
+	).Scan(&g.CreatedAtUTC, &g.LastUpdatedAtUTC); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return err
+		}
+
+		return ErrSetGeofenceFailed
+	}
+
+	return nil
+}
+
+// selectGeofences leaves out the geofences of deleted locations, which are
+// kept so that restoring the location restores them too.
+const selectGeofences = `
+	SELECT "locationID", "shape", "centerLatitude", "centerLongitude", "radiusMeters", "polygon", "createdAtUTC", "lastUpdatedAtUTC"
+	FROM geofences
+	WHERE "locationID" IN (SELECT "ID" FROM locations WHERE "deletedAtUTC" IS NULL)
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanGeofence(row rowScanner) (model.Geofence, error) {
+	var (
+		g                    model.Geofence
+		centerLat, centerLng sql.NullFloat64
+		radius               sql.NullFloat64
+		polygon              []byte
+	)
+
+	if err := row.Scan(&g.LocationID, &g.Shape, &centerLat, &centerLng, &radius, &polygon, &g.CreatedAtUTC, &g.LastUpdatedAtUTC); err != nil {
+		return g, err
+	}
+
+	if centerLat.Valid && centerLng.Valid {
+		g.Center = &model.Point{Latitude: centerLat.Float64, Longitude: centerLng.Float64}
+	}
+	if radius.Valid {
+		g.RadiusMeters = &radius.Float64
+	}
+	if polygon != nil {
+		if err := json.Unmarshal(polygon, &g.Polygon); err != nil {
+			return g, err
+		}
+	}
+
+	return g, nil
+}
+
+func (s *PostgresStore) GetGeofence(ctx context.Context, locationID uuid.UUID) (*model.Geofence, error) {
+	g, err := scanGeofence(s.db.QueryRowContext(ctx, selectGeofences+`AND "locationID" = $1;`, locationID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrGeofenceDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetGeofenceFailed
+	}
+
+	return &g, nil
+}
+
+func (s *PostgresStore) DeleteGeofence(ctx context.Context, locationID uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM geofences WHERE "locationID" = $1;`, locationID)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteGeofenceFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return helpers.ErrGeofenceDoesNotExist
+	}
+
+	return nil
+}
+
+func listGeofences(ctx context.Context, q queryer) ([]model.Geofence, error) {
+	rows, err := q.QueryContext(ctx, selectGeofences+`;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fences := []model.Geofence{}
+
+	for rows.Next() {
+		g, err := scanGeofence(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		fences = append(fences, g)
+	}
+
+	return fences, rows.Err()
+}
+
+// geofenceState returns, per location, whether the asset's most recent event
+// left it inside the fence.
+func geofenceState(ctx context.Context, q queryer, assetID uuid.UUID) (map[uuid.UUID]bool, error) {
+	query := `
+		SELECT DISTINCT ON ("locationID") "locationID", "event"
+		FROM geofence_events
+		WHERE "assetID" = $1
+		ORDER BY "locationID", "occurredAtUTC" DESC, "ID" DESC;
+	`
+
+	rows, err := q.QueryContext(ctx, query, assetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inside := make(map[uuid.UUID]bool)
+
+	for rows.Next() {
+		var (
+			locationID uuid.UUID
+			event      model.GeofenceEventType
+		)
+
+		if err := rows.Scan(&locationID, &event); err != nil {
+			return nil, err
+		}
+
+		inside[locationID] = event == model.GeofenceEventTypes.Enter
+	}
+
+	return inside, rows.Err()
+}
+
+func insertGeofenceEvents(ctx context.Context, q queryer, events []model.GeofenceEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	args := make([]any, 0, len(events)*6)
+
+	b.WriteString(`INSERT INTO geofence_events ("assetID", "locationID", "event", "latitude", "longitude", "occurredAtUTC") VALUES `)
+
+	for i, e := range events {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * 6
+		fmt.Fprintf(&b, `($%d, $%d, $%d, $%d, $%d, $%d)`, n+1, n+2, n+3, n+4, n+5, n+6)
+		args = append(args, e.AssetID, e.LocationID, e.Event, e.Latitude, e.Longitude, e.OccurredAtUTC)
+	}
+
+	_, err := q.ExecContext(ctx, b.String(), args...)
+	return err
+}
+
+func (s *PostgresStore) GetGeofenceEventsByAsset(ctx context.Context, assetID uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error) {
+	exists, err := s.assetExists(ctx, assetID)
+	if err != nil {
+		return nil, ErrGetGeofenceEventsFailed
+	}
+	if !exists {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	return s.getGeofenceEvents(ctx, `"assetID"`, assetID, q)
+}
+
+func (s *PostgresStore) GetGeofenceEventsByLocation(ctx context.Context, locationID uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error) {
+	exists, err := s.locationExists(ctx, locationID)
+	if err != nil {
+		return nil, ErrGetGeofenceEventsFailed
+	}
+	if !exists {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	return s.getGeofenceEvents(ctx, `"locationID"`, locationID, q)
+}
+
+// getGeofenceEvents filters on column, which must be a trusted identifier.
+func (s *PostgresStore) getGeofenceEvents(ctx context.Context, column string, id uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error) {
+	query := `
+		SELECT "assetID", "locationID", "event", "latitude", "longitude", "occurredAtUTC"
+		FROM geofence_events
+		WHERE ` + column + ` = $1
+		  AND "occurredAtUTC" >= $2
+		  AND "occurredAtUTC" <= $3
+		ORDER BY "occurredAtUTC", "ID";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetGeofenceEventsFailed
+	}
+	defer rows.Close()
+
+	events := []model.GeofenceEvent{}
+
+	for rows.Next() {
+		var e model.GeofenceEvent
+
+		if err := rows.Scan(&e.AssetID, &e.LocationID, &e.Event, &e.Latitude, &e.Longitude, &e.OccurredAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetGeofenceEventsFailed
+		}
+
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetGeofenceEventsFailed
+	}
+
+	return events, nil
+}
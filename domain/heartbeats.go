@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRecordHeartbeatFailed   = errors.New("failed to record heartbeat")
+	ErrMarkAssetsOfflineFailed = errors.New("failed to mark stale assets offline")
+)
+
+// RecordHeartbeat stamps the asset as seen now and flips it online. Only the
+// flip bumps the version, so steady heartbeats do not invalidate the ETags
+// clients hold for conditional writes; the ETag of an asset read covers
+// lastSeenAtUTC separately.
+func (s *PostgresStore) RecordHeartbeat(ctx context.Context, assetID uuid.UUID) (time.Time, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return time.Time{}, ErrRecordHeartbeatFailed
+	}
+	defer tx.Rollback()
+
+	var oldStatus model.Status
+	if err := tx.QueryRowContext(ctx, `SELECT "status" FROM assets WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR UPDATE`, assetID).Scan(&oldStatus); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return time.Time{}, helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return time.Time{}, ErrRecordHeartbeatFailed
+	}
+
+	query := `
+		UPDATE assets
+		SET "lastSeenAtUTC" = NOW(),
+		    "lastUpdatedAtUTC" = CASE WHEN "status" <> 'online' THEN NOW() ELSE "lastUpdatedAtUTC" END,
+		    "version" = CASE WHEN "status" <> 'online' THEN "version" + 1 ELSE "version" END,
+		    "status" = 'online'
+		WHERE "ID" = $1
+		RETURNING "lastSeenAtUTC";
+	`
+
+	var seenAt time.Time
+	if err := tx.QueryRowContext(ctx, query, assetID).Scan(&seenAt); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return time.Time{}, ErrRecordHeartbeatFailed
+	}
+
+	if oldStatus != model.Statuses.Online {
+		if err := recordStatusChange(ctx, tx, assetID, oldStatus, model.Statuses.Online, model.StatusChangeSources.Heartbeat); err != nil {
+			return time.Time{}, ErrRecordHeartbeatFailed
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return time.Time{}, ErrRecordHeartbeatFailed
+	}
+
+	return seenAt, nil
+}
+
+// MarkStaleAssetsOffline flips every online asset that has not been seen since
+// cutoff to offline and returns their IDs. Assets that never sent a heartbeat
+// are judged by their last manual update instead.
+func (s *PostgresStore) MarkStaleAssetsOffline(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	query := `
+		WITH changed AS (
+			UPDATE assets
+			SET "status" = 'offline', "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+			WHERE "status" = 'online' AND "deletedAtUTC" IS NULL
+			  AND COALESCE("lastSeenAtUTC", "lastUpdatedAtUTC") < $1
+			RETURNING "ID", "locationID", "lastUpdatedAtUTC"
+		), history AS (
+			INSERT INTO asset_status_history ("assetID", "oldStatus", "newStatus", "source")
+			SELECT "ID", 'online', 'offline', 'heartbeat' FROM changed
+		)
+		SELECT "ID", "locationID", "lastUpdatedAtUTC" FROM changed;
+	`
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMarkAssetsOfflineFailed
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMarkAssetsOfflineFailed
+	}
+
+	ids := []uuid.UUID{}
+	// the events are recorded once the rows are read: the transaction's
+	// connection is busy until then
+	var recorded []events.Event
+
+	for rows.Next() {
+		var id, locationID uuid.UUID
+		var changedAt time.Time
+
+		if err := rows.Scan(&id, &locationID, &changedAt); err != nil {
+			rows.Close()
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrMarkAssetsOfflineFailed
+		}
+
+		ids = append(ids, id)
+		recorded = append(recorded, events.New(events.AssetStatusChanged, &id, &locationID, model.StatusChange{
+			AssetID:      id,
+			OldStatus:    model.Statuses.Online,
+			NewStatus:    model.Statuses.Offline,
+			Source:       model.StatusChangeSources.Heartbeat,
+			ChangedAtUTC: changedAt,
+		}))
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMarkAssetsOfflineFailed
+	}
+
+	for _, e := range recorded {
+		if err := recordEvent(ctx, tx, e); err != nil {
+			return nil, ErrMarkAssetsOfflineFailed
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMarkAssetsOfflineFailed
+	}
+
+	return ids, nil
+}
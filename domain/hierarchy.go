@@ -0,0 +1,259 @@
+package domain
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrMoveLocationFailed         = errors.New("failed to move location")
+	ErrGetLocationAncestorsFailed = errors.New("failed to get location ancestors")
+	ErrGetLocationSubtreeFailed   = errors.New("failed to get location subtree")
+)
+
+// locationMoveLockID keys the advisory lock that serializes moves. Row locks
+// are not enough to rule out cycles: two moves can each pass the ancestor
+// check against the other's old parent and together close a loop.
+const locationMoveLockID = 7_340_226_118
+
+// subtreeQuery returns a query selecting the location passed as argument n
+// and all of its descendants. Deleted locations, and whatever hangs below
+// them, are skipped unless includeDeleted is set.
+func subtreeQuery(n int, includeDeleted bool) string {
+	live := `AND l."deletedAtUTC" IS NULL`
+	if includeDeleted {
+		live = ""
+	}
+
+	return fmt.Sprintf(`
+		WITH RECURSIVE subtree AS (
+			SELECT l."ID" FROM locations l WHERE l."ID" = $%d %s
+			UNION ALL
+			SELECT l."ID" FROM locations l JOIN subtree t ON l."parentID" = t."ID" WHERE TRUE %s
+		)
+		SELECT "ID" FROM subtree`, n, live, live)
+}
+
+// MoveLocation re-parents the location in one update; its descendants keep
+// pointing at it and so move along.
+func (s *PostgresStore) MoveLocation(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, version *int) (*model.Location, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMoveLocationFailed
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1);`, locationMoveLockID); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMoveLocationFailed
+	}
+
+	var current int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "version" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR UPDATE`,
+		id,
+	).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMoveLocationFailed
+	}
+
+	if version != nil && *version != current {
+		return nil, helpers.ErrVersionMismatch
+	}
+
+	if parentID != nil {
+		var pid uuid.UUID
+		if err := tx.QueryRowContext(ctx,
+			`SELECT "ID" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR SHARE`,
+			*parentID,
+		).Scan(&pid); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, helpers.ErrParentLocationDoesNotExist
+			}
+
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrMoveLocationFailed
+		}
+
+		var cycle bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT $2::uuid IN (`+subtreeQuery(1, false)+`)`,
+			id, *parentID,
+		).Scan(&cycle); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrMoveLocationFailed
+		}
+
+		if cycle {
+			return nil, helpers.ErrLocationCycle
+		}
+	}
+
+	query := `
+		UPDATE locations
+		SET "parentID" = $1, "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "ID" = $2
+		RETURNING "ID", "parentID", "version";
+	`
+
+	loc := &model.Location{}
+	if err := tx.QueryRowContext(ctx, query, parentID, id).Scan(&loc.ID, &loc.ParentID, &loc.Version); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMoveLocationFailed
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.LocationMoved, nil, &id, locationMove{ParentID: parentID})); err != nil {
+		return nil, ErrMoveLocationFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrMoveLocationFailed
+	}
+
+	return loc, nil
+}
+
+func (s *PostgresStore) GetLocationAncestors(ctx context.Context, id uuid.UUID) ([]model.Location, error) {
+	exists, err := s.locationExists(ctx, id)
+	if err != nil {
+		return nil, ErrGetLocationAncestorsFailed
+	}
+	if !exists {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT "parentID", 1 AS "depth" FROM locations WHERE "ID" = $1
+			UNION ALL
+			SELECT l."parentID", a."depth" + 1
+			FROM locations l JOIN ancestors a ON l."ID" = a."parentID"
+		)
+		SELECT l."ID", l."name", l."code", l."parentID", l."createdAtUTC", l."lastUpdatedAtUTC", l."version"
+		FROM ancestors a
+		JOIN locations l ON l."ID" = a."parentID"
+		ORDER BY a."depth" DESC;
+	`
+
+	locations, err := s.queryLocations(ctx, query, id)
+	if err != nil {
+		return nil, ErrGetLocationAncestorsFailed
+	}
+
+	return locations, nil
+}
+
+func (s *PostgresStore) GetLocationSubtree(ctx context.Context, id uuid.UUID) (*model.LocationNode, error) {
+	query := `
+		SELECT "ID", "name", "code", "parentID", "createdAtUTC", "lastUpdatedAtUTC", "version"
+		FROM locations
+		WHERE "ID" IN (` + subtreeQuery(1, false) + `);
+	`
+
+	locations, err := s.queryLocations(ctx, query, id)
+	if err != nil {
+		return nil, ErrGetLocationSubtreeFailed
+	}
+
+	root := buildLocationTree(id, locations)
+	if root == nil {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	return root, nil
+}
+
+// queryLocations runs a query selecting the columns of a live location and
+// scans every row. Errors are logged and returned as is.
+func (s *PostgresStore) queryLocations(ctx context.Context, query string, args ...any) ([]model.Location, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	locations := []model.Location{}
+
+	for rows.Next() {
+		var loc model.Location
+
+		if err := rows.Scan(&loc.ID, &loc.Name, &loc.Code, &loc.ParentID, &loc.CreatedAtUTC, &loc.LastUpdatedAtUTC, &loc.Version); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, err
+		}
+
+		locations = append(locations, loc)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, err
+	}
+
+	return locations, nil
+}
+
+// buildLocationTree nests locations under the one with rootID, which is
+// expected to be among them along with all of its descendants. It returns
+// nil if the root is missing.
+func buildLocationTree(rootID uuid.UUID, locations []model.Location) *model.LocationNode {
+	slices.SortFunc(locations, func(a, b model.Location) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID.String(), b.ID.String()))
+	})
+
+	var root *model.Location
+	children := map[uuid.UUID][]model.Location{}
+	for _, loc := range locations {
+		if *loc.ID == rootID {
+			root = &loc
+			continue
+		}
+		if loc.ParentID != nil {
+			children[*loc.ParentID] = append(children[*loc.ParentID], loc)
+		}
+	}
+
+	if root == nil {
+		return nil
+	}
+
+	var build func(loc model.Location) model.LocationNode
+	build = func(loc model.Location) model.LocationNode {
+		node := model.LocationNode{Location: loc, Children: []model.LocationNode{}}
+		for _, child := range children[*loc.ID] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	tree := build(*root)
+	return &tree
+}
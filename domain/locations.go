@@ -2,7 +2,7 @@ package domain
 
 import (
 	"context"
-	"crud/db"
+	"crud/events"
 	"crud/helpers"
 	"crud/model"
 	"database/sql"
@@ -15,41 +15,149 @@ import (
 )
 
 var (
-	ErrCreateLocationFailed = errors.New("failed to create location")
-	ErrGetLocationsFailed   = errors.New("failed to get locations")
-	ErrDeleteLocationFailed = errors.New("failed to delete location")
-	ErrUpdateLocationFailed = errors.New("failed to update location")
+	ErrCreateLocationFailed  = errors.New("failed to create location")
+	ErrGetLocationsFailed    = errors.New("failed to get locations")
+	ErrGetLocationFailed     = errors.New("failed to get location")
+	ErrDeleteLocationFailed  = errors.New("failed to delete location")
+	ErrUpdateLocationFailed  = errors.New("failed to update location")
+	ErrRestoreLocationFailed = errors.New("failed to restore location")
 )
 
-func CreateLocation(ctx context.Context, location *model.Location) error {
+func (s *PostgresStore) CreateLocation(ctx context.Context, location *model.Location) error {
 	query := `
 		INSERT INTO locations ("name", "code")
 		VALUES ($1, $2)
 		RETURNING "ID";
 	`
+	args := []any{location.Name, location.Code}
+
+	if location.ParentID != nil {
+		// like assets, children can only be added to a live location,
+		// which the insert locks against a concurrent delete
+		query = `
+			INSERT INTO locations ("name", "code", "parentID")
+			SELECT $1, $2, "ID" FROM locations
+			WHERE "ID" = $3 AND "deletedAtUTC" IS NULL
+			FOR SHARE
+			RETURNING "ID";
+		`
+		args = append(args, *location.ParentID)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateLocationFailed
+	}
+	defer tx.Rollback()
 
-	if err := db.DB.QueryRowContext(ctx, query, location.Name, location.Code).Scan(&location.ID); err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&location.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helpers.ErrParentLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 		if err := helpers.HandlePostgresError(err); err != nil {
 			return err
 		}
 		return ErrCreateLocationFailed
 	}
 
+	if err := recordEvent(ctx, tx, events.New(events.LocationCreated, nil, location.ID, *location)); err != nil {
+		return ErrCreateLocationFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateLocationFailed
+	}
+
 	return nil
 }
 
-func GetLocations(ctx context.Context) ([]model.Location, error) {
-	query := `
-		SELECT "ID", "name", "code", "createdAtUTC", "lastUpdatedAtUTC"
-		FROM locations;
-	`
+// locationSortColumns maps the sort fields accepted by location listings
+// onto columns of the locations table.
+var locationSortColumns = map[string]string{
+	model.SortByName:             `"name"`,
+	model.SortByCode:             `"code"`,
+	model.SortByCreatedAtUTC:     `"createdAtUTC"`,
+	model.SortByLastUpdatedAtUTC: `"lastUpdatedAtUTC"`,
+}
+
+func (s *PostgresStore) GetLocations(ctx context.Context, q model.LocationListQuery) ([]model.Location, string, error) {
+	normalizeSort(&q.ListQuery)
+	column, ok := locationSortColumns[q.Sort]
+	if !ok {
+		return nil, "", helpers.ErrInvalidSort
+	}
+
+	after, err := decodeCursor(q.ListQuery)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if q.ParentID != nil {
+		exists, err := s.locationExists(ctx, *q.ParentID)
+		if err != nil {
+			return nil, "", ErrGetLocationsFailed
+		}
+		if !exists {
+			return nil, "", helpers.ErrLocationDoesNotExist
+		}
+	}
+
+	var b strings.Builder
+	args := []any{}
+	arg := func(v any) int {
+		args = append(args, v)
+		return len(args)
+	}
+
+	b.WriteString(`
+		SELECT "ID", "name", "code", "parentID", "createdAtUTC", "lastUpdatedAtUTC", "deletedAtUTC", "version"
+		FROM locations
+		WHERE TRUE`)
+
+	if !q.IncludeDeleted {
+		b.WriteString(` AND "deletedAtUTC" IS NULL`)
+	}
+
+	if q.ParentID != nil {
+		fmt.Fprintf(&b, ` AND "parentID" = $%d`, arg(*q.ParentID))
+	}
+	if q.NamePrefix != "" {
+		fmt.Fprintf(&b, ` AND "name" ILIKE $%d`, arg(likePrefix(q.NamePrefix)))
+	}
+	if q.UpdatedAfter != nil {
+		fmt.Fprintf(&b, ` AND "lastUpdatedAtUTC" >= $%d`, arg(*q.UpdatedAfter))
+	}
+	if q.UpdatedBefore != nil {
+		fmt.Fprintf(&b, ` AND "lastUpdatedAtUTC" < $%d`, arg(*q.UpdatedBefore))
+	}
+
+	direction, comparison := "ASC", ">"
+	if q.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if after != nil {
+		value, err := cursorArg(q.Sort, after.Value)
+		if err != nil {
+			return nil, "", err
+		}
+		fmt.Fprintf(&b, ` AND (%s, "ID") %s ($%d, $%d)`, column, comparison, arg(value), arg(after.ID))
+	}
+
+	limit := pageLimit(q.ListQuery)
+	fmt.Fprintf(&b, ` ORDER BY %s %s, "ID" %s LIMIT $%d;`, column, direction, direction, arg(limit+1))
 
-	rows, err := db.DB.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, b.String(), args...)
 	if err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-		return nil, ErrGetLocationsFailed
+		return nil, "", ErrGetLocationsFailed
 	}
 	defer rows.Close()
 
@@ -58,33 +166,116 @@ func GetLocations(ctx context.Context) ([]model.Location, error) {
 	for rows.Next() {
 		var loc model.Location
 
-		if err := rows.Scan(&loc.ID, &loc.Name, &loc.Code, &loc.CreatedAtUTC, &loc.LastUpdatedAtUTC); err != nil {
-			slog.Error(`{"error":"` + err.Error() + `"}`)
+		if err := rows.Scan(&loc.ID, &loc.Name, &loc.Code, &loc.ParentID, &loc.CreatedAtUTC, &loc.LastUpdatedAtUTC, &loc.DeletedAtUTC, &loc.Version); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-			return nil, ErrGetLocationsFailed
+			return nil, "", ErrGetLocationsFailed
 		}
 
 		locations = append(locations, loc)
 	}
 
 	if err := rows.Err(); err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
-		return nil, ErrGetLocationsFailed
+		return nil, "", ErrGetLocationsFailed
 	}
 
-	return locations, nil
+	locations, next := locationPage(q.ListQuery, locations, limit)
+
+	return locations, next, nil
 }
 
-func DeleteLocation(ctx context.Context, id uuid.UUID) error {
+func (s *PostgresStore) GetLocation(ctx context.Context, id uuid.UUID) (*model.Location, error) {
 	query := `
-		DELETE FROM locations
-		WHERE "ID" = $1;
+		SELECT "ID", "name", "code", "parentID", "createdAtUTC", "lastUpdatedAtUTC", "version"
+		FROM locations
+		WHERE "ID" = $1 AND "deletedAtUTC" IS NULL;
 	`
 
-	_, err := db.DB.ExecContext(ctx, query, id)
+	var loc model.Location
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&loc.ID, &loc.Name, &loc.Code, &loc.ParentID, &loc.CreatedAtUTC, &loc.LastUpdatedAtUTC, &loc.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
 	if err != nil {
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetLocationFailed
+	}
+
+	return &loc, nil
+}
+
+// DeleteLocation soft-deletes the location. Its assets and child locations
+// have to be deleted first, since a deleted location cannot hold live ones.
+func (s *PostgresStore) DeleteLocation(ctx context.Context, id uuid.UUID, version *int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteLocationFailed
+	}
+	defer tx.Rollback()
+
+	// the row lock holds off assets and child locations being created at or
+	// moved to the location until the delete commits
+	var current int
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "version" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR UPDATE`,
+		id,
+	).Scan(&current); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteLocationFailed
+	}
+
+	if version != nil && *version != current {
+		return helpers.ErrVersionMismatch
+	}
+
+	var hasAssets, hasChildren bool
+	if err := tx.QueryRowContext(ctx,
+		`SELECT
+			EXISTS (SELECT 1 FROM assets WHERE "locationID" = $1 AND "deletedAtUTC" IS NULL),
+			EXISTS (SELECT 1 FROM locations WHERE "parentID" = $1 AND "deletedAtUTC" IS NULL)`,
+		id,
+	).Scan(&hasAssets, &hasChildren); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteLocationFailed
+	}
+
+	if hasAssets {
+		return helpers.ErrLocationHasAssets
+	}
+	if hasChildren {
+		return helpers.ErrLocationHasChildren
+	}
+
+	query := `
+		UPDATE locations
+		SET "deletedAtUTC" = NOW(), "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "ID" = $1;
+	`
+
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteLocationFailed
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.LocationDeleted, nil, &id, nil)); err != nil {
+		return ErrDeleteLocationFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
 		return ErrDeleteLocationFailed
 	}
@@ -92,12 +283,91 @@ func DeleteLocation(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func UpdateLocation(ctx context.Context, p model.LocationPatch) (*model.Location, error) {
+// RestoreLocation undoes a soft delete. It fails if the location's parent
+// has been deleted since, or if another location has taken its name or code.
+func (s *PostgresStore) RestoreLocation(ctx context.Context, id uuid.UUID) (*model.Location, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreLocationFailed
+	}
+	defer tx.Rollback()
+
+	var (
+		deletedAt sql.NullTime
+		parentID  *uuid.UUID
+	)
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "deletedAtUTC", "parentID" FROM locations WHERE "ID" = $1 FOR UPDATE`,
+		id,
+	).Scan(&deletedAt, &parentID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreLocationFailed
+	}
+
+	if !deletedAt.Valid {
+		return nil, helpers.ErrLocationNotDeleted
+	}
+
+	if parentID != nil {
+		var pid uuid.UUID
+		if err := tx.QueryRowContext(ctx,
+			`SELECT "ID" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR SHARE`,
+			*parentID,
+		).Scan(&pid); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, helpers.ErrParentLocationDoesNotExist
+			}
+
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrRestoreLocationFailed
+		}
+	}
+
+	query := `
+		UPDATE locations
+		SET "deletedAtUTC" = NULL, "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "ID" = $1
+		RETURNING "ID", "version";
+	`
+
+	loc := &model.Location{}
+	if err := tx.QueryRowContext(ctx, query, id).Scan(&loc.ID, &loc.Version); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrRestoreLocationFailed
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.LocationRestored, nil, &id, nil)); err != nil {
+		return nil, ErrRestoreLocationFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrRestoreLocationFailed
+	}
+
+	return loc, nil
+}
+
+func (s *PostgresStore) UpdateLocation(ctx context.Context, p model.LocationPatch) (*model.Location, error) {
 	var b strings.Builder
 	args := []any{}
 	argIdx := 1
 
-	b.WriteString(`UPDATE locations SET "lastUpdatedAtUTC" = NOW(), `)
+	b.WriteString(`UPDATE locations SET "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1, `)
 
 	first := true
 
@@ -121,21 +391,35 @@ func UpdateLocation(ctx context.Context, p model.LocationPatch) (*model.Location
 		first = false
 	}
 
-	fmt.Fprintf(&b, ` WHERE "ID" = $%d`, argIdx)
+	fmt.Fprintf(&b, ` WHERE "ID" = $%d AND "deletedAtUTC" IS NULL`, argIdx)
 	args = append(args, p.ID)
+	argIdx++
+
+	if p.Version != nil {
+		fmt.Fprintf(&b, ` AND "version" = $%d`, argIdx)
+		args = append(args, *p.Version)
+	}
 
-	b.WriteString(` RETURNING "ID"`)
+	b.WriteString(` RETURNING "ID", "version"`)
 
 	query := b.String()
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrUpdateLocationFailed
+	}
+	defer tx.Rollback()
+
 	loc := &model.Location{}
 
-	if err := db.DB.QueryRowContext(ctx, query, args...).Scan(&loc.ID); err != nil {
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&loc.ID, &loc.Version); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, helpers.ErrLocationDoesNotExist
+			return nil, s.locationMissing(ctx, p.ID, p.Version, ErrUpdateLocationFailed)
 		}
 
-		slog.Error(`{"error":"` + err.Error() + `"}`)
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
 
 		if err := helpers.HandlePostgresError(err); err != nil {
 			return nil, err
@@ -144,5 +428,46 @@ func UpdateLocation(ctx context.Context, p model.LocationPatch) (*model.Location
 		return nil, ErrUpdateLocationFailed
 	}
 
+	change := locationChange{Name: p.Name, Code: p.Code}
+	if err := recordEvent(ctx, tx, events.New(events.LocationUpdated, nil, &p.ID, change)); err != nil {
+		return nil, ErrUpdateLocationFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrUpdateLocationFailed
+	}
+
 	return loc, nil
 }
+
+// locationMissing explains why a conditional write to a location matched no
+// row: either the location is gone or it is no longer at version.
+func (s *PostgresStore) locationMissing(ctx context.Context, id uuid.UUID, version *int, failed error) error {
+	if version == nil {
+		return helpers.ErrLocationDoesNotExist
+	}
+
+	exists, err := s.locationExists(ctx, id)
+	if err != nil {
+		return failed
+	}
+	if exists {
+		return helpers.ErrVersionMismatch
+	}
+
+	return helpers.ErrLocationDoesNotExist
+}
+
+func (s *PostgresStore) locationExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL)`, id).Scan(&exists); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,671 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is a concurrency-safe, in-memory implementation of Store.
+// It mirrors the constraints enforced by the Postgres schema so it can stand
+// in for the database in tests and local development.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	locations  map[uuid.UUID]*memLocation
+	assets     map[uuid.UUID]*memAsset
+	assetTypes map[uuid.UUID]*model.AssetType
+	telemetry  map[uuid.UUID][]model.TelemetryReading
+	history    map[uuid.UUID][]model.StatusChange
+	positions  map[uuid.UUID][]model.Position // sorted by RecordedAtUTC
+	transfers  map[uuid.UUID][]model.AssetTransfer
+
+	geofences      map[uuid.UUID]model.Geofence
+	geofenceEvents []model.GeofenceEvent
+
+	apiKeys map[uuid.UUID]*memAPIKey
+
+	webhooks   map[uuid.UUID]*model.Webhook
+	deliveries map[uuid.UUID]*model.WebhookDelivery
+
+	alertRules map[uuid.UUID]*model.AlertRule
+	alerts     map[uuid.UUID]*model.Alert
+}
+
+type memLocation struct {
+	id               uuid.UUID
+	name             string
+	code             string
+	parentID         *uuid.UUID
+	createdAtUTC     time.Time
+	lastUpdatedAtUTC time.Time
+	deletedAtUTC     *time.Time
+	version          int
+}
+
+type memAsset struct {
+	id               uuid.UUID
+	name             string
+	status           model.Status
+	locationID       uuid.UUID
+	typeID           *uuid.UUID
+	attributes       map[string]any
+	lastSeenAtUTC    *time.Time
+	createdAtUTC     time.Time
+	lastUpdatedAtUTC time.Time
+	deletedAtUTC     *time.Time
+	version          int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		locations:  make(map[uuid.UUID]*memLocation),
+		assets:     make(map[uuid.UUID]*memAsset),
+		assetTypes: make(map[uuid.UUID]*model.AssetType),
+		telemetry:  make(map[uuid.UUID][]model.TelemetryReading),
+		history:    make(map[uuid.UUID][]model.StatusChange),
+		positions:  make(map[uuid.UUID][]model.Position),
+		transfers:  make(map[uuid.UUID][]model.AssetTransfer),
+		geofences:  make(map[uuid.UUID]model.Geofence),
+		apiKeys:    make(map[uuid.UUID]*memAPIKey),
+
+		webhooks:   make(map[uuid.UUID]*model.Webhook),
+		deliveries: make(map[uuid.UUID]*model.WebhookDelivery),
+
+		alertRules: make(map[uuid.UUID]*model.AlertRule),
+		alerts:     make(map[uuid.UUID]*model.Alert),
+	}
+}
+
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+func (l *memLocation) toModel() model.Location {
+	id := l.id
+	loc := model.Location{
+		ID:               &id,
+		Name:             l.name,
+		Code:             l.code,
+		ParentID:         copyUUID(l.parentID),
+		CreatedAtUTC:     l.createdAtUTC.Format(time.RFC3339Nano),
+		LastUpdatedAtUTC: l.lastUpdatedAtUTC.Format(time.RFC3339Nano),
+		Version:          l.version,
+	}
+	if l.deletedAtUTC != nil {
+		deleted := l.deletedAtUTC.Format(time.RFC3339Nano)
+		loc.DeletedAtUTC = &deleted
+	}
+	return loc
+}
+
+// assetToModel must be called with at least a read lock held.
+func (s *MemoryStore) assetToModel(a *memAsset) model.Asset {
+	id := a.id
+	out := model.Asset{
+		ID:               &id,
+		Name:             a.name,
+		Status:           a.status,
+		TypeID:           copyUUID(a.typeID),
+		Attributes:       a.attributes,
+		LastSeenAtUTC:    a.lastSeenAtUTC,
+		LastPosition:     s.lastPosition(a.id),
+		LastUpdatedAtUTC: a.lastUpdatedAtUTC,
+		CreatedAtUTC:     a.createdAtUTC,
+		DeletedAtUTC:     a.deletedAtUTC,
+		Version:          a.version,
+	}
+	if loc, ok := s.locations[a.locationID]; ok {
+		out.Location = loc.name
+	}
+	return out
+}
+
+// asset returns the asset with id unless it is missing or deleted. It must
+// be called with at least a read lock held.
+func (s *MemoryStore) asset(id uuid.UUID) (*memAsset, bool) {
+	a, ok := s.assets[id]
+	if !ok || a.deletedAtUTC != nil {
+		return nil, false
+	}
+	return a, true
+}
+
+// location is asset's counterpart for locations.
+func (s *MemoryStore) location(id uuid.UUID) (*memLocation, bool) {
+	l, ok := s.locations[id]
+	if !ok || l.deletedAtUTC != nil {
+		return nil, false
+	}
+	return l, true
+}
+
+func (s *MemoryStore) GetAllAssets(ctx context.Context, q model.AssetListQuery) ([]model.Asset, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listAssets(q)
+}
+
+func (s *MemoryStore) GetAssetsByLocation(ctx context.Context, locationID uuid.UUID, q model.AssetListQuery) ([]model.Asset, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	q.LocationID = &locationID
+
+	return s.listAssets(q)
+}
+
+// listAssets must be called with at least a read lock held.
+func (s *MemoryStore) listAssets(q model.AssetListQuery) ([]model.Asset, string, error) {
+	normalizeSort(&q.ListQuery)
+	if !slices.Contains(model.AssetSortFields, q.Sort) {
+		return nil, "", helpers.ErrInvalidSort
+	}
+
+	after, err := decodeCursor(q.ListQuery)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var subtree map[uuid.UUID]bool
+	if q.LocationID != nil && q.Recursive {
+		subtree = s.subtree(*q.LocationID, q.IncludeDeleted)
+	}
+
+	matched := []model.Asset{}
+	keys := []cursor{}
+	for _, a := range s.assets {
+		if a.deletedAtUTC != nil && !q.IncludeDeleted ||
+			subtree != nil && !subtree[a.locationID] ||
+			subtree == nil && q.LocationID != nil && a.locationID != *q.LocationID ||
+			q.Status != nil && a.status != *q.Status ||
+			!hasPrefixFold(a.name, q.NamePrefix) ||
+			q.TypeID != nil && (a.typeID == nil || *a.typeID != *q.TypeID) ||
+			!hasAttributes(a.attributes, q.Attributes) ||
+			!inUpdatedRange(q.ListQuery, a.lastUpdatedAtUTC) {
+			continue
+		}
+
+		asset := s.assetToModel(a)
+		matched = append(matched, asset)
+		keys = append(keys, cursor{Value: assetSortValue(q.Sort, asset), ID: a.id})
+	}
+
+	limit := pageLimit(q.ListQuery)
+	assets := []model.Asset{}
+	for _, i := range pageAfter(q.ListQuery, after, keys, limit) {
+		assets = append(assets, matched[i])
+	}
+
+	assets, next := assetPage(q.ListQuery, assets, limit)
+
+	return assets, next, nil
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(s), strings.ToLower(prefix))
+}
+
+// hasAttributes reports whether attrs holds every value in want, the way
+// a JSONB containment test matches top-level keys.
+func hasAttributes(attrs, want map[string]any) bool {
+	for k, v := range want {
+		got, ok := attrs[k]
+		if !ok || !reflect.DeepEqual(got, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func inUpdatedRange(q model.ListQuery, t time.Time) bool {
+	if q.UpdatedAfter != nil && t.Before(*q.UpdatedAfter) {
+		return false
+	}
+	if q.UpdatedBefore != nil && !t.Before(*q.UpdatedBefore) {
+		return false
+	}
+	return true
+}
+
+func (s *MemoryStore) GetAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.AssetDetail, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.asset(assetID)
+	if !ok || a.locationID != locationID {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	return &model.AssetDetail{
+		Asset:    s.assetToModel(a),
+		Location: s.locations[a.locationID].toModel(),
+	}, nil
+}
+
+func (s *MemoryStore) GetAssetLocationID(ctx context.Context, assetID uuid.UUID) (uuid.UUID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.asset(assetID)
+	if !ok {
+		return uuid.Nil, helpers.ErrAssetDoesNotExist
+	}
+
+	return a.locationID, nil
+}
+
+func (s *MemoryStore) CountAssets(ctx context.Context) ([]model.AssetCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct {
+		locationID uuid.UUID
+		status     model.Status
+	}
+	counts := make(map[key]int)
+	for _, a := range s.assets {
+		if a.deletedAtUTC != nil {
+			continue
+		}
+		counts[key{a.locationID, a.status}]++
+	}
+
+	out := make([]model.AssetCount, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, model.AssetCount{
+			LocationID:   k.locationID,
+			LocationCode: s.locations[k.locationID].code,
+			Status:       k.status,
+			Count:        n,
+		})
+	}
+	slices.SortFunc(out, func(a, b model.AssetCount) int {
+		if c := strings.Compare(a.LocationCode, b.LocationCode); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Status), string(b.Status))
+	})
+
+	return out, nil
+}
+
+func (s *MemoryStore) CreateAsset(ctx context.Context, a *model.CreateAssetRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.location(a.LocationID); !ok {
+		return helpers.ErrLocationDoesNotExist
+	}
+	if s.assetNameTaken(a.Name, uuid.Nil) {
+		return helpers.ErrAssetAlreadyExists
+	}
+	if err := s.checkAttributes(a.TypeID, a.Attributes); err != nil {
+		return err
+	}
+
+	status := a.Status
+	if status == "" {
+		status = model.Statuses.Offline
+	}
+
+	id := uuid.New()
+	t := now()
+	s.assets[id] = &memAsset{
+		id:               id,
+		name:             a.Name,
+		status:           status,
+		locationID:       a.LocationID,
+		typeID:           copyUUID(a.TypeID),
+		attributes:       nonEmpty(a.Attributes),
+		createdAtUTC:     t,
+		lastUpdatedAtUTC: t,
+		version:          1,
+	}
+	a.ID = &id
+
+	locationID := a.LocationID
+	s.recordEvent(ctx, events.New(events.AssetCreated, &id, &locationID, *a))
+
+	return nil
+}
+
+func (s *MemoryStore) UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, patch model.AssetPatch) (*model.Asset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if patch.Name == nil && patch.Status == nil && patch.TypeID == nil && patch.Attributes == nil {
+		return nil, helpers.ErrNoValidFieldsToUpdate
+	}
+
+	a, ok := s.asset(assetID)
+	if !ok || a.locationID != locationID {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	if patch.Version != nil && *patch.Version != a.version {
+		return nil, helpers.ErrVersionMismatch
+	}
+
+	if patch.Name != nil && s.assetNameTaken(*patch.Name, assetID) {
+		return nil, helpers.ErrAssetAlreadyExists
+	}
+
+	typeID, attrs := a.typeID, a.attributes
+	if patch.TypeID != nil {
+		typeID = patch.TypeID
+	}
+	if patch.Attributes != nil {
+		attrs = patch.Attributes
+	}
+	if patch.TypeID != nil || patch.Attributes != nil {
+		if err := s.checkAttributes(typeID, attrs); err != nil {
+			return nil, err
+		}
+	}
+
+	if patch.Name != nil {
+		a.name = *patch.Name
+	}
+	a.typeID, a.attributes = copyUUID(typeID), nonEmpty(attrs)
+	s.recordEvent(ctx, events.New(events.AssetUpdated, &assetID, &locationID, patch))
+	t := now()
+	if patch.Status != nil && *patch.Status != a.status {
+		s.recordStatusChange(ctx, a.id, a.status, *patch.Status, model.StatusChangeSources.Manual, t)
+		a.status = *patch.Status
+	}
+	a.lastUpdatedAtUTC = t
+	a.version++
+
+	id := a.id
+	return &model.Asset{ID: &id, Status: a.status, Version: a.version}, nil
+}
+
+func (s *MemoryStore) UpdateAssetStatus(ctx context.Context, assetID uuid.UUID, status model.Status, source model.StatusChangeSource) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.asset(assetID)
+	if !ok {
+		return helpers.ErrAssetDoesNotExist
+	}
+
+	if a.status == status {
+		return nil
+	}
+
+	t := now()
+	s.recordStatusChange(ctx, a.id, a.status, status, source, t)
+	a.status = status
+	a.lastUpdatedAtUTC = t
+	a.version++
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, version *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.asset(assetID)
+	if !ok || a.locationID != locationID {
+		return helpers.ErrAssetDoesNotExist
+	}
+	if version != nil && *version != a.version {
+		return helpers.ErrVersionMismatch
+	}
+
+	t := now()
+	a.deletedAtUTC = &t
+	a.lastUpdatedAtUTC = t
+	a.version++
+	s.recordEvent(ctx, events.New(events.AssetDeleted, &assetID, &locationID, nil))
+
+	return nil
+}
+
+func (s *MemoryStore) RestoreAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.Asset, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.assets[assetID]
+	if !ok || a.locationID != locationID {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+	if a.deletedAtUTC == nil {
+		return nil, helpers.ErrAssetNotDeleted
+	}
+	if _, ok := s.location(locationID); !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+	if s.assetNameTaken(a.name, a.id) {
+		return nil, helpers.ErrAssetAlreadyExists
+	}
+
+	a.deletedAtUTC = nil
+	a.lastUpdatedAtUTC = now()
+	a.version++
+	s.recordEvent(ctx, events.New(events.AssetRestored, &assetID, &locationID, nil))
+
+	id := a.id
+	return &model.Asset{ID: &id, Version: a.version}, nil
+}
+
+// assetNameTaken must be called with the lock held. Deleted assets do not
+// hold on to their names.
+func (s *MemoryStore) assetNameTaken(name string, except uuid.UUID) bool {
+	for id, a := range s.assets {
+		if id != except && a.deletedAtUTC == nil && a.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) CreateLocation(ctx context.Context, location *model.Location) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if location.ParentID != nil {
+		if _, ok := s.location(*location.ParentID); !ok {
+			return helpers.ErrParentLocationDoesNotExist
+		}
+	}
+
+	if err := s.locationConflict(location.Name, location.Code, uuid.Nil); err != nil {
+		return err
+	}
+
+	id := uuid.New()
+	t := now()
+	s.locations[id] = &memLocation{
+		id:               id,
+		name:             location.Name,
+		code:             location.Code,
+		parentID:         copyUUID(location.ParentID),
+		createdAtUTC:     t,
+		lastUpdatedAtUTC: t,
+		version:          1,
+	}
+	location.ID = &id
+	s.recordEvent(ctx, events.New(events.LocationCreated, nil, &id, *location))
+
+	return nil
+}
+
+func (s *MemoryStore) GetLocations(ctx context.Context, q model.LocationListQuery) ([]model.Location, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalizeSort(&q.ListQuery)
+	if !slices.Contains(model.LocationSortFields, q.Sort) {
+		return nil, "", helpers.ErrInvalidSort
+	}
+
+	after, err := decodeCursor(q.ListQuery)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if q.ParentID != nil {
+		if _, ok := s.location(*q.ParentID); !ok {
+			return nil, "", helpers.ErrLocationDoesNotExist
+		}
+	}
+
+	matched := []model.Location{}
+	keys := []cursor{}
+	for _, l := range s.locations {
+		if l.deletedAtUTC != nil && !q.IncludeDeleted ||
+			q.ParentID != nil && (l.parentID == nil || *l.parentID != *q.ParentID) ||
+			!hasPrefixFold(l.name, q.NamePrefix) || !inUpdatedRange(q.ListQuery, l.lastUpdatedAtUTC) {
+			continue
+		}
+
+		loc := l.toModel()
+		matched = append(matched, loc)
+		keys = append(keys, cursor{Value: locationSortValue(q.Sort, loc), ID: l.id})
+	}
+
+	limit := pageLimit(q.ListQuery)
+	locations := []model.Location{}
+	for _, i := range pageAfter(q.ListQuery, after, keys, limit) {
+		locations = append(locations, matched[i])
+	}
+
+	locations, next := locationPage(q.ListQuery, locations, limit)
+
+	return locations, next, nil
+}
+
+func (s *MemoryStore) GetLocation(ctx context.Context, id uuid.UUID) (*model.Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.location(id)
+	if !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	loc := l.toModel()
+	return &loc, nil
+}
+
+func (s *MemoryStore) DeleteLocation(ctx context.Context, id uuid.UUID, version *int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.location(id)
+	if !ok {
+		return helpers.ErrLocationDoesNotExist
+	}
+	if version != nil && *version != l.version {
+		return helpers.ErrVersionMismatch
+	}
+
+	for _, a := range s.assets {
+		if a.locationID == id && a.deletedAtUTC == nil {
+			return helpers.ErrLocationHasAssets
+		}
+	}
+	for _, c := range s.locations {
+		if c.parentID != nil && *c.parentID == id && c.deletedAtUTC == nil {
+			return helpers.ErrLocationHasChildren
+		}
+	}
+
+	t := now()
+	l.deletedAtUTC = &t
+	l.lastUpdatedAtUTC = t
+	l.version++
+	s.recordEvent(ctx, events.New(events.LocationDeleted, nil, &id, nil))
+
+	return nil
+}
+
+func (s *MemoryStore) RestoreLocation(ctx context.Context, id uuid.UUID) (*model.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.locations[id]
+	if !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+	if l.deletedAtUTC == nil {
+		return nil, helpers.ErrLocationNotDeleted
+	}
+	if l.parentID != nil {
+		if _, ok := s.location(*l.parentID); !ok {
+			return nil, helpers.ErrParentLocationDoesNotExist
+		}
+	}
+	if err := s.locationConflict(l.name, l.code, id); err != nil {
+		return nil, err
+	}
+
+	l.deletedAtUTC = nil
+	l.lastUpdatedAtUTC = now()
+	l.version++
+	s.recordEvent(ctx, events.New(events.LocationRestored, nil, &id, nil))
+
+	return &model.Location{ID: &id, Version: l.version}, nil
+}
+
+func (s *MemoryStore) UpdateLocation(ctx context.Context, p model.LocationPatch) (*model.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.location(p.ID)
+	if !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+	if p.Version != nil && *p.Version != l.version {
+		return nil, helpers.ErrVersionMismatch
+	}
+
+	name, code := l.name, l.code
+	if p.Name != nil {
+		name = *p.Name
+	}
+	if p.Code != nil {
+		code = *p.Code
+	}
+
+	if err := s.locationConflict(name, code, p.ID); err != nil {
+		return nil, err
+	}
+
+	l.name, l.code = name, code
+	l.lastUpdatedAtUTC = now()
+	l.version++
+
+	id := l.id
+	s.recordEvent(ctx, events.New(events.LocationUpdated, nil, &id, locationChange{Name: p.Name, Code: p.Code}))
+	return &model.Location{ID: &id, Version: l.version}, nil
+}
+
+// locationConflict must be called with the lock held. Deleted locations do
+// not hold on to their names and codes.
+func (s *MemoryStore) locationConflict(name, code string, except uuid.UUID) error {
+	for id, l := range s.locations {
+		if id == except || l.deletedAtUTC != nil {
+			continue
+		}
+		if l.name == name {
+			return helpers.ErrLocationAlreadyExists
+		}
+		if l.code == code {
+			return helpers.ErrCodeAlreadyExists
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package domain
+
+import (
+	"context"
+	"sort"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) CreateAlertRule(ctx context.Context, r *model.AlertRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.AssetID != nil {
+		if _, ok := s.assets[*r.AssetID]; !ok {
+			return helpers.ErrAssetDoesNotExist
+		}
+	}
+	if r.LocationID != nil {
+		if _, ok := s.locations[*r.LocationID]; !ok {
+			return helpers.ErrLocationDoesNotExist
+		}
+	}
+
+	id := uuid.New()
+	t := now()
+	r.ID = &id
+	r.CreatedAtUTC = t
+	r.LastUpdatedAtUTC = t
+
+	stored := *r
+	s.alertRules[id] = &stored
+
+	return nil
+}
+
+func (s *MemoryStore) GetAlertRules(ctx context.Context) ([]model.AlertRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rules := []model.AlertRule{}
+	for _, r := range s.alertRules {
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAtUTC.Before(rules[j].CreatedAtUTC)
+	})
+
+	return rules, nil
+}
+
+func (s *MemoryStore) GetAlertRule(ctx context.Context, id uuid.UUID) (*model.AlertRule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.alertRules[id]
+	if !ok {
+		return nil, helpers.ErrAlertRuleDoesNotExist
+	}
+
+	out := *r
+	return &out, nil
+}
+
+func (s *MemoryStore) DeleteAlertRule(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.alertRules[id]; !ok {
+		return helpers.ErrAlertRuleDoesNotExist
+	}
+
+	s.deleteAlertRule(id)
+
+	return nil
+}
+
+// deleteAlertRule removes a rule and, as the foreign key cascade does, its
+// alerts. It must be called with the lock held.
+func (s *MemoryStore) deleteAlertRule(id uuid.UUID) {
+	delete(s.alertRules, id)
+	for aid, a := range s.alerts {
+		if a.RuleID == id {
+			delete(s.alerts, aid)
+		}
+	}
+}
+
+// deleteAssetAlerts mirrors the cascades from assets to alert rules and
+// alerts. It must be called with the lock held.
+func (s *MemoryStore) deleteAssetAlerts(assetID uuid.UUID) {
+	for id, r := range s.alertRules {
+		if r.AssetID != nil && *r.AssetID == assetID {
+			s.deleteAlertRule(id)
+		}
+	}
+	for id, a := range s.alerts {
+		if a.AssetID == assetID {
+			delete(s.alerts, id)
+		}
+	}
+}
+
+// deleteLocationAlertRules mirrors the cascade from locations to alert
+// rules. It must be called with the lock held.
+func (s *MemoryStore) deleteLocationAlertRules(locationID uuid.UUID) {
+	for id, r := range s.alertRules {
+		if r.LocationID != nil && *r.LocationID == locationID {
+			s.deleteAlertRule(id)
+		}
+	}
+}
+
+func (s *MemoryStore) GetAlerts(ctx context.Context, q model.AlertQuery) ([]model.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	alerts := []model.Alert{}
+	for _, a := range s.alerts {
+		if q.Status != "" && a.Status != q.Status {
+			continue
+		}
+		if q.RuleID != nil && a.RuleID != *q.RuleID {
+			continue
+		}
+		if q.AssetID != nil && a.AssetID != *q.AssetID {
+			continue
+		}
+		alerts = append(alerts, *a)
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].OpenedAtUTC.Equal(alerts[j].OpenedAtUTC) {
+			return alerts[i].OpenedAtUTC.After(alerts[j].OpenedAtUTC)
+		}
+		return alerts[i].ID.String() < alerts[j].ID.String()
+	})
+
+	if q.Limit > 0 && len(alerts) > q.Limit {
+		alerts = alerts[:q.Limit]
+	}
+
+	return alerts, nil
+}
+
+func (s *MemoryStore) GetAlert(ctx context.Context, id uuid.UUID) (*model.Alert, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.alerts[id]
+	if !ok {
+		return nil, helpers.ErrAlertDoesNotExist
+	}
+
+	out := *a
+	return &out, nil
+}
+
+func (s *MemoryStore) OpenAlert(ctx context.Context, a *model.Alert) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.alertRules[a.RuleID]; !ok {
+		return false, helpers.ErrAlertRuleDoesNotExist
+	}
+	if _, ok := s.assets[a.AssetID]; !ok {
+		return false, helpers.ErrAssetDoesNotExist
+	}
+
+	for _, open := range s.alerts {
+		if open.RuleID == a.RuleID && open.AssetID == a.AssetID && open.Status == model.AlertStatuses.Open {
+			return false, nil
+		}
+	}
+
+	a.ID = uuid.New()
+	a.Status = model.AlertStatuses.Open
+	a.OpenedAtUTC = now()
+	a.ResolvedAtUTC = nil
+	a.ResolvedBy = ""
+
+	stored := *a
+	s.alerts[a.ID] = &stored
+
+	assetID, locationID := a.AssetID, a.LocationID
+	s.recordEvent(ctx, events.New(events.AlertOpened, &assetID, &locationID, stored))
+
+	return true, nil
+}
+
+func (s *MemoryStore) ResolveAlert(ctx context.Context, id uuid.UUID, resolvedBy string) (*model.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.alerts[id]
+	if !ok {
+		return nil, helpers.ErrAlertDoesNotExist
+	}
+	if a.Status != model.AlertStatuses.Open {
+		return nil, helpers.ErrAlertAlreadyResolved
+	}
+
+	t := now()
+	a.Status = model.AlertStatuses.Resolved
+	a.ResolvedAtUTC = &t
+	a.ResolvedBy = resolvedBy
+
+	out := *a
+	assetID, locationID := a.AssetID, a.LocationID
+	s.recordEvent(ctx, events.New(events.AlertResolved, &assetID, &locationID, out))
+
+	return &out, nil
+}
+
+func (s *MemoryStore) GetOfflineAssets(ctx context.Context, assetID *uuid.UUID) ([]model.OfflineAsset, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	assets := []model.OfflineAsset{}
+	for id, a := range s.assets {
+		if a.status != model.Statuses.Offline || a.deletedAtUTC != nil || (assetID != nil && id != *assetID) {
+			continue
+		}
+
+		since := a.createdAtUTC
+		for _, c := range s.history[id] {
+			if c.NewStatus == model.Statuses.Offline && c.ChangedAtUTC.After(since) {
+				since = c.ChangedAtUTC
+			}
+		}
+
+		assets = append(assets, model.OfflineAsset{AssetID: id, LocationID: a.locationID, SinceUTC: since})
+	}
+
+	return assets, nil
+}
+
+func (s *MemoryStore) GetLatestReadings(ctx context.Context, metric string, assetID *uuid.UUID) ([]model.LatestReading, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	readings := []model.LatestReading{}
+	for id, a := range s.assets {
+		if a.deletedAtUTC != nil || (assetID != nil && id != *assetID) {
+			continue
+		}
+
+		var latest *model.TelemetryReading
+		for i, r := range s.telemetry[id] {
+			// later inserts win ties, like ORDER BY "ID" DESC
+			if r.Metric == metric && (latest == nil || !r.RecordedAtUTC.Before(latest.RecordedAtUTC)) {
+				latest = &s.telemetry[id][i]
+			}
+		}
+		if latest == nil {
+			continue
+		}
+
+		readings = append(readings, model.LatestReading{
+			AssetID:       id,
+			LocationID:    a.locationID,
+			Value:         *latest.Value,
+			RecordedAtUTC: latest.RecordedAtUTC,
+		})
+	}
+
+	return readings, nil
+}
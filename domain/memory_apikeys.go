@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"context"
+	"sort"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+type memAPIKey struct {
+	key  model.APIKey
+	hash string
+}
+
+func (s *MemoryStore) CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.hash == hash {
+			return helpers.ErrAPIKeyAlreadyExists
+		}
+	}
+
+	id := uuid.New()
+	key.ID = &id
+	key.CreatedAtUTC = now()
+
+	s.apiKeys[id] = &memAPIKey{key: *key, hash: hash}
+
+	return nil
+}
+
+func (s *MemoryStore) AuthenticateAPIKey(ctx context.Context, hash string) (*model.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.apiKeys {
+		if k.hash != hash || k.key.RevokedAtUTC != nil {
+			continue
+		}
+
+		out := k.key
+		t := now()
+		if k.key.LastUsedAtUTC == nil || t.Sub(*k.key.LastUsedAtUTC) > lastUsedResolution {
+			k.key.LastUsedAtUTC = &t
+		}
+
+		return &out, nil
+	}
+
+	return nil, helpers.ErrAPIKeyDoesNotExist
+}
+
+func (s *MemoryStore) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := []model.APIKey{}
+	for _, k := range s.apiKeys {
+		keys = append(keys, k.key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAtUTC.Before(keys[j].CreatedAtUTC)
+	})
+
+	return keys, nil
+}
+
+func (s *MemoryStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, ok := s.apiKeys[id]
+	if !ok || k.key.RevokedAtUTC != nil {
+		return helpers.ErrAPIKeyDoesNotExist
+	}
+
+	t := now()
+	k.key.RevokedAtUTC = &t
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) CreateAssetType(ctx context.Context, t *model.AssetType) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.assetTypes {
+		if existing.Name == t.Name {
+			return helpers.ErrAssetTypeAlreadyExists
+		}
+	}
+
+	id := uuid.New()
+	stored := *t
+	stored.ID = &id
+	stored.Schema = slices.Clone(t.Schema)
+	stored.CreatedAtUTC = now()
+	s.assetTypes[id] = &stored
+
+	t.ID = &id
+	t.CreatedAtUTC = stored.CreatedAtUTC
+
+	return nil
+}
+
+func assetTypeToModel(t *model.AssetType) model.AssetType {
+	out := *t
+	out.ID = copyUUID(t.ID)
+	out.Schema = slices.Clone(t.Schema)
+	return out
+}
+
+func (s *MemoryStore) GetAssetTypes(ctx context.Context) ([]model.AssetType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	assetTypes := []model.AssetType{}
+	for _, t := range s.assetTypes {
+		assetTypes = append(assetTypes, assetTypeToModel(t))
+	}
+	sort.Slice(assetTypes, func(i, j int) bool {
+		return assetTypes[i].Name < assetTypes[j].Name
+	})
+
+	return assetTypes, nil
+}
+
+func (s *MemoryStore) GetAssetType(ctx context.Context, id uuid.UUID) (*model.AssetType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.assetTypes[id]
+	if !ok {
+		return nil, helpers.ErrAssetTypeDoesNotExist
+	}
+
+	out := assetTypeToModel(t)
+	return &out, nil
+}
+
+func (s *MemoryStore) DeleteAssetType(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.assetTypes[id]; !ok {
+		return helpers.ErrAssetTypeDoesNotExist
+	}
+
+	// like the foreign key, deleted assets still hold on to their type
+	for _, a := range s.assets {
+		if a.typeID != nil && *a.typeID == id {
+			return helpers.ErrAssetTypeInUse
+		}
+	}
+
+	delete(s.assetTypes, id)
+
+	return nil
+}
+
+// checkAttributes resolves typeID and validates attrs against it. It must
+// be called with at least a read lock held.
+func (s *MemoryStore) checkAttributes(typeID *uuid.UUID, attrs map[string]any) error {
+	var assetType *model.AssetType
+	if typeID != nil {
+		t, ok := s.assetTypes[*typeID]
+		if !ok {
+			return helpers.ErrAssetTypeDoesNotExist
+		}
+		assetType = t
+	}
+
+	return checkAttributes(assetType, attrs)
+}
+
+// nonEmpty stores empty attributes as nil, matching how the Postgres store
+// reads back its '{}' default.
+func nonEmpty(attrs map[string]any) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
@@ -0,0 +1,182 @@
+package domain
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) ImportLocations(ctx context.Context, locations []model.LocationImportRow) ([]model.ImportRowResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]model.ImportRowResult, len(locations))
+	created := make(map[uuid.UUID]uuid.UUID)
+
+	for _, i := range importOrder(locations) {
+		l := &locations[i]
+
+		if l.ParentID != nil {
+			if id, ok := created[*l.ParentID]; ok {
+				l.ParentID = &id
+			} else if _, ok := s.location(*l.ParentID); !ok {
+				results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: helpers.ErrParentLocationDoesNotExist.Error()}
+				continue
+			}
+		}
+
+		if s.locationConflict(l.Name, l.Code, uuid.Nil) != nil {
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Skipped, Error: errLocationTaken.Error()}
+			continue
+		}
+
+		id := uuid.New()
+		t := now()
+		s.locations[id] = &memLocation{
+			id:               id,
+			name:             l.Name,
+			code:             l.Code,
+			parentID:         copyUUID(l.ParentID),
+			createdAtUTC:     t,
+			lastUpdatedAtUTC: t,
+			version:          1,
+		}
+		if l.ID != nil {
+			created[*l.ID] = id
+		}
+		location := model.Location{ID: &id, Name: l.Name, Code: l.Code, ParentID: l.ParentID}
+		s.recordEvent(ctx, events.New(events.LocationCreated, nil, &id, location))
+		results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Created, ID: &id}
+	}
+
+	return results, nil
+}
+
+func (s *MemoryStore) ImportAssets(ctx context.Context, assets []model.AssetImportRow) ([]model.ImportRowResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]model.ImportRowResult, len(assets))
+
+	for i, a := range assets {
+		if _, ok := s.location(a.LocationID); !ok {
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: helpers.ErrLocationDoesNotExist.Error()}
+			continue
+		}
+		if err := s.checkAttributes(a.TypeID, a.Attributes); err != nil {
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Failed, Error: err.Error()}
+			continue
+		}
+		if s.assetNameTaken(a.Name, uuid.Nil) {
+			results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Skipped, Error: helpers.ErrAssetAlreadyExists.Error()}
+			continue
+		}
+
+		id := uuid.New()
+		t := now()
+		s.assets[id] = &memAsset{
+			id:               id,
+			name:             a.Name,
+			status:           model.Status(a.Status),
+			locationID:       a.LocationID,
+			typeID:           copyUUID(a.TypeID),
+			attributes:       nonEmpty(a.Attributes),
+			createdAtUTC:     t,
+			lastUpdatedAtUTC: t,
+			version:          1,
+		}
+		s.recordStatusChange(ctx, id, model.Status(a.Status), model.Status(a.Status), model.StatusChangeSources.Import, t)
+		locationID := a.LocationID
+		s.recordEvent(ctx, events.New(events.AssetCreated, &id, &locationID, model.CreateAssetRequest{
+			ID:         &id,
+			Name:       a.Name,
+			Status:     model.Status(a.Status),
+			LocationID: locationID,
+			TypeID:     a.TypeID,
+			Attributes: a.Attributes,
+		}))
+		results[i] = model.ImportRowResult{Status: model.ImportRowStatuses.Created, ID: &id}
+	}
+
+	return results, nil
+}
+
+// ExportLocations snapshots the locations and calls fn without holding the
+// lock, so a slow reader cannot block writers.
+func (s *MemoryStore) ExportLocations(ctx context.Context, fn func(model.Location) error) error {
+	s.mu.RLock()
+	locs := make([]*memLocation, 0, len(s.locations))
+	for _, l := range s.locations {
+		if l.deletedAtUTC == nil {
+			locs = append(locs, l)
+		}
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		if !locs[i].createdAtUTC.Equal(locs[j].createdAtUTC) {
+			return locs[i].createdAtUTC.Before(locs[j].createdAtUTC)
+		}
+		return bytes.Compare(locs[i].id[:], locs[j].id[:]) < 0
+	})
+	locations := make([]model.Location, 0, len(locs))
+	for _, l := range locs {
+		locations = append(locations, l.toModel())
+	}
+	s.mu.RUnlock()
+
+	for _, l := range locations {
+		if err := fn(l); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportAssets snapshots the assets and calls fn without holding the lock.
+func (s *MemoryStore) ExportAssets(ctx context.Context, fn func(model.AssetExportRow) error) error {
+	s.mu.RLock()
+	assets := make([]model.AssetExportRow, 0, len(s.assets))
+	for _, a := range s.assets {
+		if a.deletedAtUTC != nil {
+			continue
+		}
+
+		row := model.AssetExportRow{
+			ID:               a.id,
+			Name:             a.name,
+			Status:           a.status,
+			LocationID:       a.locationID,
+			TypeID:           copyUUID(a.typeID),
+			Attributes:       a.attributes,
+			CreatedAtUTC:     a.createdAtUTC,
+			LastUpdatedAtUTC: a.lastUpdatedAtUTC,
+		}
+		if a.lastSeenAtUTC != nil {
+			seen := *a.lastSeenAtUTC
+			row.LastSeenAtUTC = &seen
+		}
+		assets = append(assets, row)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(assets, func(i, j int) bool {
+		if !assets[i].CreatedAtUTC.Equal(assets[j].CreatedAtUTC) {
+			return assets[i].CreatedAtUTC.Before(assets[j].CreatedAtUTC)
+		}
+		return bytes.Compare(assets[i].ID[:], assets[j].ID[:]) < 0
+	})
+
+	for _, a := range assets {
+		if err := fn(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"sort"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) SetGeofence(ctx context.Context, g *model.Geofence) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.location(g.LocationID); !ok {
+		return helpers.ErrLocationDoesNotExist
+	}
+
+	t := now()
+	g.CreatedAtUTC = t
+	if existing, ok := s.geofences[g.LocationID]; ok {
+		g.CreatedAtUTC = existing.CreatedAtUTC
+	}
+	g.LastUpdatedAtUTC = t
+
+	stored := *g
+	stored.Polygon = append([]model.Point(nil), g.Polygon...)
+	s.geofences[g.LocationID] = stored
+
+	return nil
+}
+
+func (s *MemoryStore) GetGeofence(ctx context.Context, locationID uuid.UUID) (*model.Geofence, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.geofences[locationID]
+	if _, live := s.location(locationID); !ok || !live {
+		return nil, helpers.ErrGeofenceDoesNotExist
+	}
+
+	g.Polygon = append([]model.Point(nil), g.Polygon...)
+	return &g, nil
+}
+
+func (s *MemoryStore) DeleteGeofence(ctx context.Context, locationID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.geofences[locationID]; !ok {
+		return helpers.ErrGeofenceDoesNotExist
+	}
+
+	delete(s.geofences, locationID)
+
+	return nil
+}
+
+func (s *MemoryStore) GetGeofenceEventsByAsset(ctx context.Context, assetID uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.asset(assetID); !ok {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	return s.filterGeofenceEvents(q, func(e model.GeofenceEvent) bool { return e.AssetID == assetID }), nil
+}
+
+func (s *MemoryStore) GetGeofenceEventsByLocation(ctx context.Context, locationID uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.location(locationID); !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	return s.filterGeofenceEvents(q, func(e model.GeofenceEvent) bool { return e.LocationID == locationID }), nil
+}
+
+// filterGeofenceEvents must be called with at least a read lock held.
+func (s *MemoryStore) filterGeofenceEvents(q model.GeofenceEventQuery, match func(model.GeofenceEvent) bool) []model.GeofenceEvent {
+	events := []model.GeofenceEvent{}
+	for _, e := range s.geofenceEvents {
+		if !match(e) || e.OccurredAtUTC.Before(q.From) || e.OccurredAtUTC.After(q.To) {
+			continue
+		}
+		events = append(events, e)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAtUTC.Before(events[j].OccurredAtUTC)
+	})
+
+	return events
+}
+
+// geofenceList must be called with at least a read lock held. The
+// geofences of deleted locations are kept for a restore but left out.
+func (s *MemoryStore) geofenceList() []model.Geofence {
+	fences := make([]model.Geofence, 0, len(s.geofences))
+	for id, g := range s.geofences {
+		if _, ok := s.location(id); ok {
+			fences = append(fences, g)
+		}
+	}
+	return fences
+}
+
+// geofenceState must be called with at least a read lock held. Events are
+// appended in time order per asset, so the last one per location wins.
+func (s *MemoryStore) geofenceState(assetID uuid.UUID) map[uuid.UUID]bool {
+	inside := make(map[uuid.UUID]bool)
+	for _, e := range s.geofenceEvents {
+		if e.AssetID == assetID {
+			inside[e.LocationID] = e.Event == model.GeofenceEventTypes.Enter
+		}
+	}
+	return inside
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) RecordHeartbeat(ctx context.Context, assetID uuid.UUID) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.asset(assetID)
+	if !ok {
+		return time.Time{}, helpers.ErrAssetDoesNotExist
+	}
+
+	t := now()
+	if a.status != model.Statuses.Online {
+		s.recordStatusChange(ctx, a.id, a.status, model.Statuses.Online, model.StatusChangeSources.Heartbeat, t)
+		a.status = model.Statuses.Online
+		a.lastUpdatedAtUTC = t
+		a.version++
+	}
+	a.lastSeenAtUTC = &t
+
+	return t, nil
+}
+
+func (s *MemoryStore) MarkStaleAssetsOffline(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := []uuid.UUID{}
+	t := now()
+
+	for id, a := range s.assets {
+		if a.status != model.Statuses.Online || a.deletedAtUTC != nil {
+			continue
+		}
+
+		seen := a.lastUpdatedAtUTC
+		if a.lastSeenAtUTC != nil {
+			seen = *a.lastSeenAtUTC
+		}
+		if !seen.Before(cutoff) {
+			continue
+		}
+
+		s.recordStatusChange(ctx, id, a.status, model.Statuses.Offline, model.StatusChangeSources.Heartbeat, t)
+		a.status = model.Statuses.Offline
+		a.lastUpdatedAtUTC = t
+		a.version++
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
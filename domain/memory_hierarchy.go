@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"context"
+	"slices"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) MoveLocation(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, version *int) (*model.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.location(id)
+	if !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+	if version != nil && *version != l.version {
+		return nil, helpers.ErrVersionMismatch
+	}
+
+	if parentID != nil {
+		if _, ok := s.location(*parentID); !ok {
+			return nil, helpers.ErrParentLocationDoesNotExist
+		}
+		if s.subtree(id, false)[*parentID] {
+			return nil, helpers.ErrLocationCycle
+		}
+	}
+
+	l.parentID = copyUUID(parentID)
+	l.lastUpdatedAtUTC = now()
+	l.version++
+	s.recordEvent(ctx, events.New(events.LocationMoved, nil, &id, locationMove{ParentID: copyUUID(parentID)}))
+
+	return &model.Location{ID: &id, ParentID: copyUUID(parentID), Version: l.version}, nil
+}
+
+func (s *MemoryStore) GetLocationAncestors(ctx context.Context, id uuid.UUID) ([]model.Location, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.location(id)
+	if !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	ancestors := []model.Location{}
+	for l.parentID != nil {
+		l = s.locations[*l.parentID]
+		ancestors = append(ancestors, l.toModel())
+	}
+	slices.Reverse(ancestors)
+
+	return ancestors, nil
+}
+
+func (s *MemoryStore) GetLocationSubtree(ctx context.Context, id uuid.UUID) (*model.LocationNode, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	locations := []model.Location{}
+	for lid := range s.subtree(id, false) {
+		locations = append(locations, s.locations[lid].toModel())
+	}
+
+	root := buildLocationTree(id, locations)
+	if root == nil {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	return root, nil
+}
+
+// subtree returns the IDs of the location and all of its descendants,
+// skipping deleted locations unless includeDeleted is set. It must be called
+// with at least a read lock held.
+func (s *MemoryStore) subtree(id uuid.UUID, includeDeleted bool) map[uuid.UUID]bool {
+	ids := map[uuid.UUID]bool{}
+	if l, ok := s.locations[id]; !ok || l.deletedAtUTC != nil && !includeDeleted {
+		return ids
+	}
+
+	queue := []uuid.UUID{id}
+	ids[id] = true
+	for len(queue) > 0 {
+		parent := queue[0]
+		queue = queue[1:]
+		for cid, c := range s.locations {
+			if c.parentID == nil || *c.parentID != parent || ids[cid] ||
+				c.deletedAtUTC != nil && !includeDeleted {
+				continue
+			}
+			ids[cid] = true
+			queue = append(queue, cid)
+		}
+	}
+
+	return ids
+}
+
+func copyUUID(id *uuid.UUID) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	c := *id
+	return &c
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	"crud/geofence"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) CreatePositions(ctx context.Context, assetID uuid.UUID, positions []model.Position) ([]model.GeofenceEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.asset(assetID)
+	if !ok {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	fresh := positions
+	if last := s.lastPosition(assetID); last != nil {
+		fresh = freshPositions(positions, sql.NullTime{Time: last.RecordedAtUTC, Valid: true})
+	}
+
+	for _, p := range positions {
+		s.positions[assetID] = append(s.positions[assetID], copyPosition(p))
+	}
+
+	sort.SliceStable(s.positions[assetID], func(i, j int) bool {
+		return s.positions[assetID][i].RecordedAtUTC.Before(s.positions[assetID][j].RecordedAtUTC)
+	})
+
+	crossings := []model.GeofenceEvent{}
+	if len(fresh) > 0 && len(s.geofences) > 0 {
+		crossings = geofence.Detect(assetID, s.geofenceList(), s.geofenceState(assetID), fresh)
+		s.geofenceEvents = append(s.geofenceEvents, crossings...)
+	}
+
+	for _, e := range positionEvents(assetID, a.locationID, positions, crossings) {
+		s.recordEvent(ctx, e)
+	}
+
+	return crossings, nil
+}
+
+func (s *MemoryStore) GetTrack(ctx context.Context, assetID uuid.UUID, q model.TrackQuery) ([]model.Position, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.asset(assetID); !ok {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	track := []model.Position{}
+	for _, p := range s.positions[assetID] {
+		if p.RecordedAtUTC.Before(q.From) || p.RecordedAtUTC.After(q.To) {
+			continue
+		}
+		track = append(track, copyPosition(p))
+	}
+
+	return track, nil
+}
+
+// lastPosition must be called with at least a read lock held.
+func (s *MemoryStore) lastPosition(assetID uuid.UUID) *model.Position {
+	track := s.positions[assetID]
+	if len(track) == 0 {
+		return nil
+	}
+
+	p := copyPosition(track[len(track)-1])
+	return &p
+}
+
+func copyPosition(p model.Position) model.Position {
+	lat, lng := *p.Latitude, *p.Longitude
+	out := model.Position{
+		Latitude:      &lat,
+		Longitude:     &lng,
+		RecordedAtUTC: p.RecordedAtUTC.UTC(),
+	}
+	if p.Accuracy != nil {
+		acc := *p.Accuracy
+		out.Accuracy = &acc
+	}
+	return out
+}
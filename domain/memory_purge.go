@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// PurgeDeleted mirrors the cascades Postgres applies when the rows are
+// deleted for good.
+func (s *MemoryStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (model.PurgeResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var res model.PurgeResult
+
+	for id, a := range s.assets {
+		if a.deletedAtUTC == nil || !a.deletedAtUTC.Before(cutoff) {
+			continue
+		}
+
+		delete(s.assets, id)
+		delete(s.telemetry, id)
+		delete(s.history, id)
+		delete(s.positions, id)
+		delete(s.transfers, id)
+		s.deleteAssetAlerts(id)
+		s.geofenceEvents = slices.DeleteFunc(s.geofenceEvents, func(e model.GeofenceEvent) bool {
+			return e.AssetID == id
+		})
+		res.Assets++
+	}
+
+	for id, l := range s.locations {
+		if l.deletedAtUTC == nil || !l.deletedAtUTC.Before(cutoff) || s.locationReferenced(id) {
+			continue
+		}
+
+		delete(s.locations, id)
+		delete(s.geofences, id)
+		s.detachTransfers(id)
+		s.deleteLocationAlertRules(id)
+		s.geofenceEvents = slices.DeleteFunc(s.geofenceEvents, func(e model.GeofenceEvent) bool {
+			return e.LocationID == id
+		})
+		res.Locations++
+	}
+
+	return res, nil
+}
+
+// locationReferenced reports whether any asset or child location, deleted or
+// not, still points at the location. It must be called with the lock held.
+func (s *MemoryStore) locationReferenced(id uuid.UUID) bool {
+	for _, a := range s.assets {
+		if a.locationID == id {
+			return true
+		}
+	}
+	for _, l := range s.locations {
+		if l.parentID != nil && *l.parentID == id {
+			return true
+		}
+	}
+	return false
+}
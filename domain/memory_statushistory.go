@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// recordStatusChange records a transition's event as well as its history, as
+// the Postgres store does. It must be called with the lock held.
+func (s *MemoryStore) recordStatusChange(ctx context.Context, assetID uuid.UUID, oldStatus, newStatus model.Status, source model.StatusChangeSource, at time.Time) {
+	change := model.StatusChange{
+		AssetID:      assetID,
+		OldStatus:    oldStatus,
+		NewStatus:    newStatus,
+		Source:       source,
+		ChangedAtUTC: at,
+	}
+	s.history[assetID] = append(s.history[assetID], change)
+
+	if a, ok := s.assets[assetID]; ok && oldStatus != newStatus {
+		locationID := a.locationID
+		s.recordEvent(ctx, events.New(events.AssetStatusChanged, &assetID, &locationID, change))
+	}
+}
+
+func (s *MemoryStore) GetStatusHistory(ctx context.Context, assetID uuid.UUID, q model.StatusHistoryQuery) ([]model.StatusChange, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.asset(assetID); !ok {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	changes := []model.StatusChange{}
+	for _, c := range s.history[assetID] {
+		if c.ChangedAtUTC.Before(q.From) || c.ChangedAtUTC.After(q.To) {
+			continue
+		}
+		changes = append(changes, c)
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"context"
+	"sort"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) CreateTelemetry(ctx context.Context, assetID uuid.UUID, readings []model.TelemetryReading) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.asset(assetID)
+	if !ok {
+		return helpers.ErrAssetDoesNotExist
+	}
+
+	for _, r := range readings {
+		v := *r.Value
+		r.Value = &v
+		r.RecordedAtUTC = r.RecordedAtUTC.UTC()
+		s.telemetry[assetID] = append(s.telemetry[assetID], r)
+	}
+
+	locationID := a.locationID
+	s.recordEvent(ctx, events.New(events.AssetTelemetry, &assetID, &locationID, readings))
+
+	return nil
+}
+
+func (s *MemoryStore) GetTelemetry(ctx context.Context, assetID uuid.UUID, q model.TelemetryQuery) ([]model.TelemetryReading, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.asset(assetID); !ok {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	readings := []model.TelemetryReading{}
+	for _, r := range s.telemetry[assetID] {
+		if r.RecordedAtUTC.Before(q.From) || r.RecordedAtUTC.After(q.To) {
+			continue
+		}
+		if q.Metric != "" && r.Metric != q.Metric {
+			continue
+		}
+		readings = append(readings, r)
+	}
+
+	sort.SliceStable(readings, func(i, j int) bool {
+		return readings[i].RecordedAtUTC.Before(readings[j].RecordedAtUTC)
+	})
+
+	return readings, nil
+}
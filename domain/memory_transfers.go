@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"context"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) TransferAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, toLocationID uuid.UUID, reason string) (*model.AssetTransfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.asset(assetID)
+	if !ok || a.locationID != locationID {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+	if toLocationID == locationID {
+		return nil, helpers.ErrAssetAlreadyAtLocation
+	}
+	if _, ok := s.location(toLocationID); !ok {
+		return nil, helpers.ErrLocationDoesNotExist
+	}
+
+	t := now()
+	a.locationID = toLocationID
+	a.lastUpdatedAtUTC = t
+	a.version++
+
+	from, to := locationID, toLocationID
+	transfer := model.AssetTransfer{
+		AssetID:          assetID,
+		FromLocationID:   &from,
+		ToLocationID:     &to,
+		Reason:           reason,
+		TransferredAtUTC: t,
+	}
+	s.transfers[assetID] = append(s.transfers[assetID], transfer)
+	s.recordEvent(ctx, events.New(events.AssetTransferred, &assetID, &to, transfer))
+
+	return &transfer, nil
+}
+
+func (s *MemoryStore) GetAssetTransfers(ctx context.Context, assetID uuid.UUID) ([]model.AssetTransfer, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.asset(assetID); !ok {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	transfers := []model.AssetTransfer{}
+	for _, t := range s.transfers[assetID] {
+		transfers = append(transfers, copyTransfer(t))
+	}
+
+	return transfers, nil
+}
+
+func copyTransfer(t model.AssetTransfer) model.AssetTransfer {
+	if t.FromLocationID != nil {
+		id := *t.FromLocationID
+		t.FromLocationID = &id
+	}
+	if t.ToLocationID != nil {
+		id := *t.ToLocationID
+		t.ToLocationID = &id
+	}
+	return t
+}
+
+// detachTransfers mirrors ON DELETE SET NULL on asset_transfers. It must be
+// called with the lock held.
+func (s *MemoryStore) detachTransfers(locationID uuid.UUID) {
+	for _, transfers := range s.transfers {
+		for i := range transfers {
+			if transfers[i].FromLocationID != nil && *transfers[i].FromLocationID == locationID {
+				transfers[i].FromLocationID = nil
+			}
+			if transfers[i].ToLocationID != nil && *transfers[i].ToLocationID == locationID {
+				transfers[i].ToLocationID = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+func (s *MemoryStore) CreateWebhook(ctx context.Context, w *model.Webhook) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := uuid.New()
+	t := now()
+	stored := *w
+	stored.ID = &id
+	stored.Events = slices.Clone(w.Events)
+	stored.CreatedAtUTC = t
+	stored.LastUpdatedAtUTC = t
+	s.webhooks[id] = &stored
+
+	w.ID = &id
+	w.CreatedAtUTC = t
+	w.LastUpdatedAtUTC = t
+
+	return nil
+}
+
+// webhookToModel strips the secret, as the Postgres queries do.
+func webhookToModel(w *model.Webhook) model.Webhook {
+	out := *w
+	id := *w.ID
+	out.ID = &id
+	out.Events = slices.Clone(w.Events)
+	out.Secret = ""
+	return out
+}
+
+func (s *MemoryStore) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	webhooks := []model.Webhook{}
+	for _, w := range s.webhooks {
+		webhooks = append(webhooks, webhookToModel(w))
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAtUTC.Before(webhooks[j].CreatedAtUTC)
+	})
+
+	return webhooks, nil
+}
+
+func (s *MemoryStore) GetWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.webhooks[id]
+	if !ok {
+		return nil, helpers.ErrWebhookDoesNotExist
+	}
+
+	out := webhookToModel(w)
+	return &out, nil
+}
+
+func (s *MemoryStore) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return helpers.ErrWebhookDoesNotExist
+	}
+
+	delete(s.webhooks, id)
+	for did, d := range s.deliveries {
+		if d.WebhookID == id {
+			delete(s.deliveries, did)
+		}
+	}
+
+	return nil
+}
+
+// recordEvent queues a delivery of e to every webhook whose filter selects
+// it, as the Postgres store does in the write's transaction. It must be
+// called with the lock held.
+func (s *MemoryStore) recordEvent(ctx context.Context, e events.Event) {
+	var payload []byte
+
+	for id, w := range s.webhooks {
+		if !events.Match(w.Events, e.Type) {
+			continue
+		}
+
+		if payload == nil {
+			var err error
+			if payload, err = json.Marshal(e); err != nil {
+				slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+				return
+			}
+		}
+
+		t := now()
+		d := &model.WebhookDelivery{
+			ID:               uuid.New(),
+			WebhookID:        id,
+			EventID:          e.ID,
+			EventType:        string(e.Type),
+			Payload:          slices.Clone(payload),
+			Status:           model.DeliveryStatuses.Pending,
+			NextAttemptAtUTC: &t,
+			CreatedAtUTC:     t,
+		}
+		s.deliveries[d.ID] = d
+	}
+
+	noteEvent(ctx, e)
+}
+
+func (s *MemoryStore) ClaimWebhookDeliveries(ctx context.Context, at time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := []*model.WebhookDelivery{}
+	for _, d := range s.deliveries {
+		if d.Status == model.DeliveryStatuses.Pending && d.NextAttemptAtUTC != nil && !d.NextAttemptAtUTC.After(at) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttemptAtUTC.Before(*due[j].NextAttemptAtUTC)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	leased := at.Add(lease)
+	claimed := []model.WebhookDelivery{}
+	for _, d := range due {
+		d.NextAttemptAtUTC = &leased
+
+		out := copyDelivery(d)
+		w := s.webhooks[d.WebhookID]
+		out.URL, out.Secret = w.URL, w.Secret
+		claimed = append(claimed, out)
+	}
+
+	return claimed, nil
+}
+
+func (s *MemoryStore) RecordWebhookAttempt(ctx context.Context, deliveryID uuid.UUID, a model.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[deliveryID]
+	if !ok {
+		return nil
+	}
+
+	attemptedAt := a.AttemptedAtUTC.UTC()
+	d.Status = a.Status
+	d.Attempts++
+	d.LastAttemptAtUTC = &attemptedAt
+	d.ResponseStatus = a.ResponseStatus
+	d.LastError = a.Error
+	d.NextAttemptAtUTC = a.NextAttemptAtUTC
+	d.DeliveredAtUTC = nil
+	if a.Status == model.DeliveryStatuses.Delivered {
+		d.DeliveredAtUTC = &attemptedAt
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.webhooks[webhookID]; !ok {
+		return nil, helpers.ErrWebhookDoesNotExist
+	}
+
+	deliveries := []model.WebhookDelivery{}
+	for _, d := range s.deliveries {
+		if d.WebhookID != webhookID || status != "" && d.Status != status {
+			continue
+		}
+		deliveries = append(deliveries, copyDelivery(d))
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAtUTC.After(deliveries[j].CreatedAtUTC)
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (s *MemoryStore) RedeliverWebhookDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[deliveryID]
+	if !ok || d.WebhookID != webhookID {
+		return helpers.ErrDeliveryDoesNotExist
+	}
+
+	t := now()
+	d.Status = model.DeliveryStatuses.Pending
+	d.Attempts = 0
+	d.NextAttemptAtUTC = &t
+	d.DeliveredAtUTC = nil
+
+	return nil
+}
+
+func copyDelivery(d *model.WebhookDelivery) model.WebhookDelivery {
+	out := *d
+	out.Payload = slices.Clone(d.Payload)
+	return out
+}
@@ -0,0 +1,218 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"crud/events"
+	"crud/geofence"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCreatePositionsFailed = errors.New("failed to store positions")
+	ErrGetTrackFailed        = errors.New("failed to get track")
+)
+
+// CreatePositions stores the reports and, in the same transaction, checks
+// them against every geofence. It records an event for the most recent report
+// of the batch and one for each crossing. Reports older than the asset's last
+// known fix are stored but do not generate crossing events.
+func (s *PostgresStore) CreatePositions(ctx context.Context, assetID uuid.UUID, positions []model.Position) ([]model.GeofenceEvent, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCreatePositionsFailed
+	}
+	defer tx.Rollback()
+
+	// serialise reports per asset so crossings are evaluated in order. Like
+	// heartbeats, reports leave the version alone: the asset's ETag covers
+	// its last position separately.
+	var locationID uuid.UUID
+	if err := tx.QueryRowContext(ctx, `SELECT "locationID" FROM assets WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR UPDATE`, assetID).Scan(&locationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCreatePositionsFailed
+	}
+
+	var lastFix sql.NullTime
+	if err := tx.QueryRowContext(ctx, `SELECT MAX("recordedAtUTC") FROM asset_positions WHERE "assetID" = $1`, assetID).Scan(&lastFix); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCreatePositionsFailed
+	}
+
+	var b strings.Builder
+	args := make([]any, 0, len(positions)*5)
+
+	b.WriteString(`INSERT INTO asset_positions ("assetID", "latitude", "longitude", "accuracy", "recordedAtUTC") VALUES `)
+
+	for i, p := range positions {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * 5
+		fmt.Fprintf(&b, `($%d, $%d, $%d, $%d, $%d)`, n+1, n+2, n+3, n+4, n+5)
+		args = append(args, assetID, *p.Latitude, *p.Longitude, p.Accuracy, p.RecordedAtUTC.UTC())
+	}
+
+	if _, err := tx.ExecContext(ctx, b.String(), args...); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrCreatePositionsFailed
+	}
+
+	crossings, err := detectCrossings(ctx, tx, assetID, freshPositions(positions, lastFix))
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCreatePositionsFailed
+	}
+
+	for _, e := range positionEvents(assetID, locationID, positions, crossings) {
+		if err := recordEvent(ctx, tx, e); err != nil {
+			return nil, ErrCreatePositionsFailed
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrCreatePositionsFailed
+	}
+
+	return crossings, nil
+}
+
+// positionEvents returns the events of a batch of reports: the most recent
+// report, then each geofence crossing at the fence's location.
+func positionEvents(assetID, locationID uuid.UUID, positions []model.Position, crossings []model.GeofenceEvent) []events.Event {
+	latest := positions[0]
+	for _, p := range positions[1:] {
+		if p.RecordedAtUTC.After(latest.RecordedAtUTC) {
+			latest = p
+		}
+	}
+
+	out := []events.Event{events.New(events.AssetPosition, &assetID, &locationID, latest)}
+
+	for _, c := range crossings {
+		t := events.GeofenceEnter
+		if c.Event == model.GeofenceEventTypes.Exit {
+			t = events.GeofenceExit
+		}
+		fenceLocation := c.LocationID
+		out = append(out, events.New(t, &assetID, &fenceLocation, c))
+	}
+
+	return out
+}
+
+func detectCrossings(ctx context.Context, q queryer, assetID uuid.UUID, positions []model.Position) ([]model.GeofenceEvent, error) {
+	if len(positions) == 0 {
+		return []model.GeofenceEvent{}, nil
+	}
+
+	fences, err := listGeofences(ctx, q)
+	if err != nil || len(fences) == 0 {
+		return []model.GeofenceEvent{}, err
+	}
+
+	inside, err := geofenceState(ctx, q, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	events := geofence.Detect(assetID, fences, inside, positions)
+
+	if err := insertGeofenceEvents(ctx, q, events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// freshPositions drops reports at or before lastFix.
+func freshPositions(positions []model.Position, lastFix sql.NullTime) []model.Position {
+	if !lastFix.Valid {
+		return positions
+	}
+
+	fresh := make([]model.Position, 0, len(positions))
+	for _, p := range positions {
+		if p.RecordedAtUTC.After(lastFix.Time) {
+			fresh = append(fresh, p)
+		}
+	}
+
+	return fresh
+}
+
+func (s *PostgresStore) GetTrack(ctx context.Context, assetID uuid.UUID, q model.TrackQuery) ([]model.Position, error) {
+	exists, err := s.assetExists(ctx, assetID)
+	if err != nil {
+		return nil, ErrGetTrackFailed
+	}
+	if !exists {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	query := `
+		SELECT "latitude", "longitude", "accuracy", "recordedAtUTC"
+		FROM asset_positions
+		WHERE "assetID" = $1
+		  AND "recordedAtUTC" >= $2
+		  AND "recordedAtUTC" <= $3
+		ORDER BY "recordedAtUTC";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, assetID, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetTrackFailed
+	}
+	defer rows.Close()
+
+	track := []model.Position{}
+
+	for rows.Next() {
+		p := model.Position{
+			Latitude:  new(float64),
+			Longitude: new(float64),
+		}
+
+		if err := rows.Scan(p.Latitude, p.Longitude, &p.Accuracy, &p.RecordedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetTrackFailed
+		}
+
+		track = append(track, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetTrackFailed
+	}
+
+	return track, nil
+}
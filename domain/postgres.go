@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+)
+
+// PostgresStore is the Postgres-backed implementation of Store.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx so helpers can run either
+// standalone or as part of a larger transaction.
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
@@ -0,0 +1,319 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"crud/events"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// Publisher wraps a Store and, after every write that succeeds, publishes on
+// bus the events the write recorded. Both stores build those events where
+// they make the change, so every caller that changes data through the store,
+// whether HTTP, MQTT, bulk import or a background worker, produces the same
+// events. The one exception is PurgeDeleted, which is silent: the rows it
+// removes were announced with a deleted event when they were soft-deleted.
+type Publisher struct {
+	Store
+	bus *events.Bus
+}
+
+func NewPublisher(store Store, bus *events.Bus) *Publisher {
+	return &Publisher{Store: store, bus: bus}
+}
+
+var _ Store = (*Publisher)(nil)
+
+type pendingEventsKey struct{}
+
+// pendingEvents collects the events recorded during one store call so the
+// Publisher can announce them after the call has committed.
+type pendingEvents struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func withPendingEvents(ctx context.Context) (context.Context, *pendingEvents) {
+	p := &pendingEvents{}
+	return context.WithValue(ctx, pendingEventsKey{}, p), p
+}
+
+// noteEvent is called by both stores wherever they record an event, once the
+// event's webhook deliveries are queued in the write's transaction.
+func noteEvent(ctx context.Context, e events.Event) {
+	p, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, e)
+}
+
+// Data of the events whose payload is not a model value.
+type (
+	locationChange struct {
+		Name *string `json:"name,omitempty"`
+		Code *string `json:"code,omitempty"`
+	}
+	locationMove struct {
+		ParentID *uuid.UUID `json:"parentID"`
+	}
+)
+
+func (p *Publisher) publish(ctx context.Context, pending *pendingEvents) {
+	pending.mu.Lock()
+	recorded := slices.Clone(pending.events)
+	pending.mu.Unlock()
+
+	for _, e := range recorded {
+		p.bus.Publish(ctx, e)
+	}
+}
+
+func (p *Publisher) CreateAsset(ctx context.Context, a *model.CreateAssetRequest) error {
+	sctx, pending := withPendingEvents(ctx)
+
+	if err := p.Store.CreateAsset(sctx, a); err != nil {
+		return err
+	}
+
+	p.publish(ctx, pending)
+
+	return nil
+}
+
+func (p *Publisher) UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, patch model.AssetPatch) (*model.Asset, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	asset, err := p.Store.UpdateAsset(sctx, locationID, assetID, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return asset, nil
+}
+
+func (p *Publisher) UpdateAssetStatus(ctx context.Context, assetID uuid.UUID, status model.Status, source model.StatusChangeSource) error {
+	sctx, pending := withPendingEvents(ctx)
+
+	if err := p.Store.UpdateAssetStatus(sctx, assetID, status, source); err != nil {
+		return err
+	}
+
+	p.publish(ctx, pending)
+
+	return nil
+}
+
+func (p *Publisher) DeleteAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, version *int) error {
+	sctx, pending := withPendingEvents(ctx)
+
+	if err := p.Store.DeleteAsset(sctx, locationID, assetID, version); err != nil {
+		return err
+	}
+
+	p.publish(ctx, pending)
+
+	return nil
+}
+
+func (p *Publisher) RestoreAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.Asset, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	asset, err := p.Store.RestoreAsset(sctx, locationID, assetID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return asset, nil
+}
+
+func (p *Publisher) TransferAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, toLocationID uuid.UUID, reason string) (*model.AssetTransfer, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	transfer, err := p.Store.TransferAsset(sctx, locationID, assetID, toLocationID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return transfer, nil
+}
+
+func (p *Publisher) CreateLocation(ctx context.Context, location *model.Location) error {
+	sctx, pending := withPendingEvents(ctx)
+
+	if err := p.Store.CreateLocation(sctx, location); err != nil {
+		return err
+	}
+
+	p.publish(ctx, pending)
+
+	return nil
+}
+
+func (p *Publisher) UpdateLocation(ctx context.Context, patch model.LocationPatch) (*model.Location, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	location, err := p.Store.UpdateLocation(sctx, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return location, nil
+}
+
+func (p *Publisher) DeleteLocation(ctx context.Context, id uuid.UUID, version *int) error {
+	sctx, pending := withPendingEvents(ctx)
+
+	if err := p.Store.DeleteLocation(sctx, id, version); err != nil {
+		return err
+	}
+
+	p.publish(ctx, pending)
+
+	return nil
+}
+
+func (p *Publisher) RestoreLocation(ctx context.Context, id uuid.UUID) (*model.Location, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	location, err := p.Store.RestoreLocation(sctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return location, nil
+}
+
+func (p *Publisher) MoveLocation(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, version *int) (*model.Location, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	location, err := p.Store.MoveLocation(sctx, id, parentID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return location, nil
+}
+
+func (p *Publisher) ImportLocations(ctx context.Context, locations []model.LocationImportRow) ([]model.ImportRowResult, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	results, err := p.Store.ImportLocations(sctx, locations)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return results, nil
+}
+
+func (p *Publisher) ImportAssets(ctx context.Context, assets []model.AssetImportRow) ([]model.ImportRowResult, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	results, err := p.Store.ImportAssets(sctx, assets)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return results, nil
+}
+
+func (p *Publisher) RecordHeartbeat(ctx context.Context, assetID uuid.UUID) (time.Time, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	seenAt, err := p.Store.RecordHeartbeat(sctx, assetID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	p.publish(ctx, pending)
+
+	return seenAt, nil
+}
+
+func (p *Publisher) MarkStaleAssetsOffline(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	ids, err := p.Store.MarkStaleAssetsOffline(sctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return ids, nil
+}
+
+func (p *Publisher) CreatePositions(ctx context.Context, assetID uuid.UUID, positions []model.Position) ([]model.GeofenceEvent, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	crossings, err := p.Store.CreatePositions(sctx, assetID, positions)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return crossings, nil
+}
+
+func (p *Publisher) CreateTelemetry(ctx context.Context, assetID uuid.UUID, readings []model.TelemetryReading) error {
+	sctx, pending := withPendingEvents(ctx)
+
+	if err := p.Store.CreateTelemetry(sctx, assetID, readings); err != nil {
+		return err
+	}
+
+	p.publish(ctx, pending)
+
+	return nil
+}
+
+func (p *Publisher) OpenAlert(ctx context.Context, a *model.Alert) (bool, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	opened, err := p.Store.OpenAlert(sctx, a)
+	if err != nil {
+		return false, err
+	}
+
+	p.publish(ctx, pending)
+
+	return opened, nil
+}
+
+func (p *Publisher) ResolveAlert(ctx context.Context, id uuid.UUID, resolvedBy string) (*model.Alert, error) {
+	sctx, pending := withPendingEvents(ctx)
+
+	a, err := p.Store.ResolveAlert(sctx, id, resolvedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	p.publish(ctx, pending)
+
+	return a, nil
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"crud/model"
+)
+
+var ErrPurgeFailed = errors.New("failed to purge deleted rows")
+
+// PurgeDeleted hard-deletes assets and locations soft-deleted before cutoff,
+// along with everything that cascades from them. A location is kept while
+// any asset or child location, deleted or not, still references it.
+func (s *PostgresStore) PurgeDeleted(ctx context.Context, cutoff time.Time) (model.PurgeResult, error) {
+	var res model.PurgeResult
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return res, ErrPurgeFailed
+	}
+	defer tx.Rollback()
+
+	assets, err := tx.ExecContext(ctx, `DELETE FROM assets WHERE "deletedAtUTC" < $1;`, cutoff.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return res, ErrPurgeFailed
+	}
+
+	query := `
+		DELETE FROM locations l
+		WHERE l."deletedAtUTC" < $1
+		  AND NOT EXISTS (SELECT 1 FROM assets a WHERE a."locationID" = l."ID")
+		  AND NOT EXISTS (SELECT 1 FROM locations c WHERE c."parentID" = l."ID");
+	`
+
+	locations, err := tx.ExecContext(ctx, query, cutoff.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return res, ErrPurgeFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return res, ErrPurgeFailed
+	}
+
+	n, _ := assets.RowsAffected()
+	res.Assets = int(n)
+	n, _ = locations.RowsAffected()
+	res.Locations = int(n)
+
+	return res, nil
+}
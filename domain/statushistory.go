@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrGetStatusHistoryFailed = errors.New("failed to get status history")
+)
+
+// recordStatusChange appends a transition to asset_status_history and records
+// its event. It runs on the caller's transaction so both commit with the
+// status update. An import's initial status is history, not a transition, so
+// it records no event.
+func recordStatusChange(ctx context.Context, q queryer, assetID uuid.UUID, oldStatus, newStatus model.Status, source model.StatusChangeSource) error {
+	query := `
+		INSERT INTO asset_status_history ("assetID", "oldStatus", "newStatus", "source")
+		VALUES ($1, $2, $3, $4)
+		RETURNING "changedAtUTC", (SELECT "locationID" FROM assets WHERE "ID" = $1);
+	`
+
+	change := model.StatusChange{AssetID: assetID, OldStatus: oldStatus, NewStatus: newStatus, Source: source}
+	var locationID uuid.UUID
+
+	if err := q.QueryRowContext(ctx, query, assetID, oldStatus, newStatus, source).Scan(&change.ChangedAtUTC, &locationID); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return err
+	}
+
+	if oldStatus == newStatus {
+		return nil
+	}
+
+	return recordEvent(ctx, q, events.New(events.AssetStatusChanged, &assetID, &locationID, change))
+}
+
+func (s *PostgresStore) GetStatusHistory(ctx context.Context, assetID uuid.UUID, q model.StatusHistoryQuery) ([]model.StatusChange, error) {
+	exists, err := s.assetExists(ctx, assetID)
+	if err != nil {
+		return nil, ErrGetStatusHistoryFailed
+	}
+	if !exists {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	query := `
+		SELECT "assetID", "oldStatus", "newStatus", "source", "changedAtUTC"
+		FROM asset_status_history
+		WHERE "assetID" = $1
+		  AND "changedAtUTC" >= $2
+		  AND "changedAtUTC" <= $3
+		ORDER BY "changedAtUTC", "ID";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, assetID, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetStatusHistoryFailed
+	}
+	defer rows.Close()
+
+	changes := []model.StatusChange{}
+
+	for rows.Next() {
+		var c model.StatusChange
+
+		if err := rows.Scan(&c.AssetID, &c.OldStatus, &c.NewStatus, &c.Source, &c.ChangedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetStatusHistoryFailed
+		}
+
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetStatusHistoryFailed
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// AssetStore persists assets.
+type AssetStore interface {
+	GetAllAssets(ctx context.Context, q model.AssetListQuery) ([]model.Asset, string, error)
+	GetAssetsByLocation(ctx context.Context, locationID uuid.UUID, q model.AssetListQuery) ([]model.Asset, string, error)
+	GetAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.AssetDetail, error)
+	GetAssetLocationID(ctx context.Context, assetID uuid.UUID) (uuid.UUID, error)
+	CreateAsset(ctx context.Context, a *model.CreateAssetRequest) error
+	UpdateAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, patch model.AssetPatch) (*model.Asset, error)
+	UpdateAssetStatus(ctx context.Context, assetID uuid.UUID, status model.Status, source model.StatusChangeSource) error
+	// DeleteAsset deletes the asset, failing with helpers.ErrVersionMismatch
+	// if version is set and the asset is at a different one.
+	DeleteAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, version *int) error
+	RestoreAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID) (*model.Asset, error)
+	CountAssets(ctx context.Context) ([]model.AssetCount, error)
+}
+
+// TransferStore moves assets between locations and keeps a record of each
+// move.
+type TransferStore interface {
+	TransferAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, toLocationID uuid.UUID, reason string) (*model.AssetTransfer, error)
+	GetAssetTransfers(ctx context.Context, assetID uuid.UUID) ([]model.AssetTransfer, error)
+}
+
+// AssetTypeStore persists asset types. Types cannot be changed once
+// created, so assets never have to be revalidated against a new schema.
+type AssetTypeStore interface {
+	CreateAssetType(ctx context.Context, t *model.AssetType) error
+	GetAssetTypes(ctx context.Context) ([]model.AssetType, error)
+	GetAssetType(ctx context.Context, id uuid.UUID) (*model.AssetType, error)
+	// DeleteAssetType fails with helpers.ErrAssetTypeInUse while any asset,
+	// deleted or not, has the type.
+	DeleteAssetType(ctx context.Context, id uuid.UUID) error
+}
+
+// LocationStore persists locations.
+type LocationStore interface {
+	CreateLocation(ctx context.Context, location *model.Location) error
+	GetLocations(ctx context.Context, q model.LocationListQuery) ([]model.Location, string, error)
+	GetLocation(ctx context.Context, id uuid.UUID) (*model.Location, error)
+	UpdateLocation(ctx context.Context, p model.LocationPatch) (*model.Location, error)
+	// DeleteLocation deletes the location, failing with
+	// helpers.ErrVersionMismatch if version is set and the location is at a
+	// different one.
+	DeleteLocation(ctx context.Context, id uuid.UUID, version *int) error
+	RestoreLocation(ctx context.Context, id uuid.UUID) (*model.Location, error)
+	// MoveLocation re-parents the location, taking its descendants along.
+	// A nil parentID makes it a top-level location.
+	MoveLocation(ctx context.Context, id uuid.UUID, parentID *uuid.UUID, version *int) (*model.Location, error)
+	// GetLocationAncestors returns the location's ancestors, root first.
+	GetLocationAncestors(ctx context.Context, id uuid.UUID) ([]model.Location, error)
+	GetLocationSubtree(ctx context.Context, id uuid.UUID) (*model.LocationNode, error)
+}
+
+// PurgeStore permanently removes soft-deleted rows. Purges publish no
+// events.
+type PurgeStore interface {
+	PurgeDeleted(ctx context.Context, cutoff time.Time) (model.PurgeResult, error)
+}
+
+// BulkStore imports and exports locations and assets in bulk.
+type BulkStore interface {
+	ImportLocations(ctx context.Context, locations []model.LocationImportRow) ([]model.ImportRowResult, error)
+	ImportAssets(ctx context.Context, assets []model.AssetImportRow) ([]model.ImportRowResult, error)
+	ExportLocations(ctx context.Context, fn func(model.Location) error) error
+	ExportAssets(ctx context.Context, fn func(model.AssetExportRow) error) error
+}
+
+// TelemetryStore persists time-series readings reported by assets.
+type TelemetryStore interface {
+	CreateTelemetry(ctx context.Context, assetID uuid.UUID, readings []model.TelemetryReading) error
+	GetTelemetry(ctx context.Context, assetID uuid.UUID, q model.TelemetryQuery) ([]model.TelemetryReading, error)
+}
+
+// HeartbeatStore tracks device liveness.
+type HeartbeatStore interface {
+	RecordHeartbeat(ctx context.Context, assetID uuid.UUID) (time.Time, error)
+	MarkStaleAssetsOffline(ctx context.Context, cutoff time.Time) ([]uuid.UUID, error)
+}
+
+// StatusHistoryStore reads the log of asset status transitions. Transitions
+// are written by the stores that change status.
+type StatusHistoryStore interface {
+	GetStatusHistory(ctx context.Context, assetID uuid.UUID, q model.StatusHistoryQuery) ([]model.StatusChange, error)
+}
+
+// PositionStore persists GPS position reports. CreatePositions returns the
+// geofence crossings the new reports caused.
+type PositionStore interface {
+	CreatePositions(ctx context.Context, assetID uuid.UUID, positions []model.Position) ([]model.GeofenceEvent, error)
+	GetTrack(ctx context.Context, assetID uuid.UUID, q model.TrackQuery) ([]model.Position, error)
+}
+
+// GeofenceStore persists location geofences and the crossing events detected
+// against them.
+type GeofenceStore interface {
+	SetGeofence(ctx context.Context, g *model.Geofence) error
+	GetGeofence(ctx context.Context, locationID uuid.UUID) (*model.Geofence, error)
+	DeleteGeofence(ctx context.Context, locationID uuid.UUID) error
+	GetGeofenceEventsByAsset(ctx context.Context, assetID uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error)
+	GetGeofenceEventsByLocation(ctx context.Context, locationID uuid.UUID, q model.GeofenceEventQuery) ([]model.GeofenceEvent, error)
+}
+
+// APIKeyStore persists hashed API keys.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) error
+	AuthenticateAPIKey(ctx context.Context, hash string) (*model.APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+}
+
+// WebhookStore persists webhook subscriptions and their delivery log. There is
+// no method to queue deliveries: every write that records an event queues
+// them in its own transaction.
+type WebhookStore interface {
+	CreateWebhook(ctx context.Context, w *model.Webhook) error
+	GetWebhooks(ctx context.Context) ([]model.Webhook, error)
+	GetWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error)
+	RecordWebhookAttempt(ctx context.Context, deliveryID uuid.UUID, a model.DeliveryAttempt) error
+	GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error)
+	RedeliverWebhookDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID uuid.UUID) error
+}
+
+// AlertStore persists alert rules and the alerts they open, and answers the
+// queries the alert evaluator runs against current asset state.
+type AlertStore interface {
+	CreateAlertRule(ctx context.Context, r *model.AlertRule) error
+	GetAlertRules(ctx context.Context) ([]model.AlertRule, error)
+	GetAlertRule(ctx context.Context, id uuid.UUID) (*model.AlertRule, error)
+	DeleteAlertRule(ctx context.Context, id uuid.UUID) error
+	GetAlerts(ctx context.Context, q model.AlertQuery) ([]model.Alert, error)
+	GetAlert(ctx context.Context, id uuid.UUID) (*model.Alert, error)
+	OpenAlert(ctx context.Context, a *model.Alert) (bool, error)
+	ResolveAlert(ctx context.Context, id uuid.UUID, resolvedBy string) (*model.Alert, error)
+	GetOfflineAssets(ctx context.Context, assetID *uuid.UUID) ([]model.OfflineAsset, error)
+	GetLatestReadings(ctx context.Context, metric string, assetID *uuid.UUID) ([]model.LatestReading, error)
+}
+
+// Store groups every store the API depends on. Both PostgresStore and
+// MemoryStore implement it.
+type Store interface {
+	AssetStore
+	AssetTypeStore
+	TransferStore
+	LocationStore
+	PurgeStore
+	BulkStore
+	TelemetryStore
+	HeartbeatStore
+	StatusHistoryStore
+	PositionStore
+	GeofenceStore
+	APIKeyStore
+	WebhookStore
+	AlertStore
+}
+
+var (
+	_ Store = (*PostgresStore)(nil)
+	_ Store = (*MemoryStore)(nil)
+)
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCreateTelemetryFailed = errors.New("failed to store telemetry")
+	ErrGetTelemetryFailed    = errors.New("failed to get telemetry")
+)
+
+// CreateTelemetry stores the readings and records them as one event.
+func (s *PostgresStore) CreateTelemetry(ctx context.Context, assetID uuid.UUID, readings []model.TelemetryReading) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateTelemetryFailed
+	}
+	defer tx.Rollback()
+
+	// the foreign key alone would accept readings for a deleted asset
+	var locationID uuid.UUID
+	if err := tx.QueryRowContext(ctx, `SELECT "locationID" FROM assets WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR SHARE`, assetID).Scan(&locationID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateTelemetryFailed
+	}
+
+	var b strings.Builder
+	args := make([]any, 0, len(readings)*5)
+
+	b.WriteString(`INSERT INTO telemetry ("assetID", "metric", "value", "unit", "recordedAtUTC") VALUES `)
+
+	for i, r := range readings {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		n := i * 5
+		fmt.Fprintf(&b, `($%d, $%d, $%d, $%d, $%d)`, n+1, n+2, n+3, n+4, n+5)
+		args = append(args, assetID, r.Metric, *r.Value, r.Unit, r.RecordedAtUTC.UTC())
+	}
+
+	if _, err := tx.ExecContext(ctx, b.String(), args...); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return err
+		}
+
+		return ErrCreateTelemetryFailed
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.AssetTelemetry, &assetID, &locationID, readings)); err != nil {
+		return ErrCreateTelemetryFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateTelemetryFailed
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) GetTelemetry(ctx context.Context, assetID uuid.UUID, q model.TelemetryQuery) ([]model.TelemetryReading, error) {
+	exists, err := s.assetExists(ctx, assetID)
+	if err != nil {
+		return nil, ErrGetTelemetryFailed
+	}
+	if !exists {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	query := `
+		SELECT "metric", "value", "unit", "recordedAtUTC"
+		FROM telemetry
+		WHERE "assetID" = $1
+		  AND "recordedAtUTC" >= $2
+		  AND "recordedAtUTC" <= $3
+		  AND ($4 = '' OR "metric" = $4)
+		ORDER BY "recordedAtUTC";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, assetID, q.From.UTC(), q.To.UTC(), q.Metric)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetTelemetryFailed
+	}
+	defer rows.Close()
+
+	readings := []model.TelemetryReading{}
+
+	for rows.Next() {
+		var r model.TelemetryReading
+		r.Value = new(float64)
+
+		if err := rows.Scan(&r.Metric, r.Value, &r.Unit, &r.RecordedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetTelemetryFailed
+		}
+
+		readings = append(readings, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetTelemetryFailed
+	}
+
+	return readings, nil
+}
+
+func (s *PostgresStore) assetExists(ctx context.Context, assetID uuid.UUID) (bool, error) {
+	var exists bool
+
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM assets WHERE "ID" = $1 AND "deletedAtUTC" IS NULL)`, assetID).Scan(&exists); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return false, err
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,158 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrTransferAssetFailed     = errors.New("failed to transfer asset")
+	ErrGetAssetTransfersFailed = errors.New("failed to get asset transfers")
+)
+
+// TransferAsset moves an asset from locationID to toLocationID and records
+// the move in asset_transfers. Both writes, and the event's deliveries, share
+// one transaction.
+func (s *PostgresStore) TransferAsset(ctx context.Context, locationID uuid.UUID, assetID uuid.UUID, toLocationID uuid.UUID, reason string) (*model.AssetTransfer, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrTransferAssetFailed
+	}
+	defer tx.Rollback()
+
+	var id uuid.UUID
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "ID" FROM assets WHERE "ID" = $1 AND "locationID" = $2 AND "deletedAtUTC" IS NULL FOR UPDATE`,
+		assetID, locationID,
+	).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrAssetDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrTransferAssetFailed
+	}
+
+	if toLocationID == locationID {
+		return nil, helpers.ErrAssetAlreadyAtLocation
+	}
+
+	// the foreign key would accept a deleted location
+	if err := tx.QueryRowContext(ctx,
+		`SELECT "ID" FROM locations WHERE "ID" = $1 AND "deletedAtUTC" IS NULL FOR SHARE`,
+		toLocationID,
+	).Scan(&id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, helpers.ErrLocationDoesNotExist
+		}
+
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrTransferAssetFailed
+	}
+
+	query := `
+		UPDATE assets
+		SET "locationID" = $1, "lastUpdatedAtUTC" = NOW(), "version" = "version" + 1
+		WHERE "ID" = $2;
+	`
+
+	if _, err := tx.ExecContext(ctx, query, toLocationID, assetID); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		if err := helpers.HandlePostgresError(err); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrTransferAssetFailed
+	}
+
+	query = `
+		INSERT INTO asset_transfers ("assetID", "fromLocationID", "toLocationID", "reason")
+		VALUES ($1, $2, $3, $4)
+		RETURNING "transferredAtUTC";
+	`
+
+	t := &model.AssetTransfer{
+		AssetID:        assetID,
+		FromLocationID: &locationID,
+		ToLocationID:   &toLocationID,
+		Reason:         reason,
+	}
+
+	if err := tx.QueryRowContext(ctx, query, assetID, locationID, toLocationID, reason).Scan(&t.TransferredAtUTC); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrTransferAssetFailed
+	}
+
+	if err := recordEvent(ctx, tx, events.New(events.AssetTransferred, &assetID, &toLocationID, *t)); err != nil {
+		return nil, ErrTransferAssetFailed
+	}
+
+	if err := tx.Commit(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrTransferAssetFailed
+	}
+
+	return t, nil
+}
+
+func (s *PostgresStore) GetAssetTransfers(ctx context.Context, assetID uuid.UUID) ([]model.AssetTransfer, error) {
+	exists, err := s.assetExists(ctx, assetID)
+	if err != nil {
+		return nil, ErrGetAssetTransfersFailed
+	}
+	if !exists {
+		return nil, helpers.ErrAssetDoesNotExist
+	}
+
+	query := `
+		SELECT "assetID", "fromLocationID", "toLocationID", "reason", "transferredAtUTC"
+		FROM asset_transfers
+		WHERE "assetID" = $1
+		ORDER BY "transferredAtUTC", "ID";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, assetID)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAssetTransfersFailed
+	}
+	defer rows.Close()
+
+	transfers := []model.AssetTransfer{}
+
+	for rows.Next() {
+		var t model.AssetTransfer
+
+		if err := rows.Scan(&t.AssetID, &t.FromLocationID, &t.ToLocationID, &t.Reason, &t.TransferredAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetAssetTransfersFailed
+		}
+
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetAssetTransfersFailed
+	}
+
+	return transfers, nil
+}
@@ -0,0 +1,301 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCreateWebhookFailed   = errors.New("failed to create webhook")
+	ErrGetWebhooksFailed     = errors.New("failed to get webhooks")
+	ErrDeleteWebhookFailed   = errors.New("failed to delete webhook")
+	ErrClaimDeliveriesFailed = errors.New("failed to claim webhook deliveries")
+	ErrRecordAttemptFailed   = errors.New("failed to record webhook delivery attempt")
+	ErrGetDeliveriesFailed   = errors.New("failed to get webhook deliveries")
+	ErrRedeliverFailed       = errors.New("failed to schedule webhook redelivery")
+)
+
+func (s *PostgresStore) CreateWebhook(ctx context.Context, w *model.Webhook) error {
+	query := `
+		INSERT INTO webhooks ("url", "events", "secret")
+		VALUES ($1, $2, $3)
+		RETURNING "ID", "createdAtUTC", "lastUpdatedAtUTC";
+	`
+
+	if err := s.db.QueryRowContext(ctx, query, w.URL, pq.Array(w.Events), w.Secret).Scan(&w.ID, &w.CreatedAtUTC, &w.LastUpdatedAtUTC); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrCreateWebhookFailed
+	}
+
+	return nil
+}
+
+// GetWebhooks returns every subscription without its secret.
+func (s *PostgresStore) GetWebhooks(ctx context.Context) ([]model.Webhook, error) {
+	query := `
+		SELECT "ID", "url", "events", "createdAtUTC", "lastUpdatedAtUTC"
+		FROM webhooks
+		ORDER BY "createdAtUTC", "ID";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetWebhooksFailed
+	}
+	defer rows.Close()
+
+	webhooks := []model.Webhook{}
+
+	for rows.Next() {
+		var w model.Webhook
+
+		if err := rows.Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.CreatedAtUTC, &w.LastUpdatedAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetWebhooksFailed
+		}
+
+		webhooks = append(webhooks, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetWebhooksFailed
+	}
+
+	return webhooks, nil
+}
+
+func (s *PostgresStore) GetWebhook(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	query := `
+		SELECT "ID", "url", "events", "createdAtUTC", "lastUpdatedAtUTC"
+		FROM webhooks
+		WHERE "ID" = $1;
+	`
+
+	var w model.Webhook
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&w.ID, &w.URL, pq.Array(&w.Events), &w.CreatedAtUTC, &w.LastUpdatedAtUTC)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, helpers.ErrWebhookDoesNotExist
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetWebhooksFailed
+	}
+
+	return &w, nil
+}
+
+func (s *PostgresStore) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE "ID" = $1;`, id)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrDeleteWebhookFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return helpers.ErrWebhookDoesNotExist
+	}
+
+	return nil
+}
+
+// recordEvent queues a delivery of e to every webhook whose filter selects
+// it. It runs on the caller's transaction so the deliveries commit, or roll
+// back, with the change the event describes. Webhooks being deleted
+// concurrently are skipped rather than failing the change.
+func recordEvent(ctx context.Context, q queryer, e events.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return err
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries ("webhookID", "eventID", "eventType", "payload")
+		SELECT "ID", $1, $2, $3::jsonb
+		FROM webhooks
+		WHERE cardinality("events") = 0 OR "events" && $4::text[]
+		FOR KEY SHARE;
+	`
+
+	if _, err := q.ExecContext(ctx, query, e.ID, e.Type, string(payload), pq.Array(events.Filters(e.Type))); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return err
+	}
+
+	noteEvent(ctx, e)
+
+	return nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due
+// at now, and pushes their next attempt back by lease so that another
+// dispatcher does not pick them up while they are in flight.
+func (s *PostgresStore) ClaimWebhookDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET "nextAttemptAtUTC" = $2
+		FROM webhooks w
+		WHERE w."ID" = d."webhookID"
+		  AND d."ID" IN (
+			SELECT "ID" FROM webhook_deliveries
+			WHERE "status" = 'pending' AND "nextAttemptAtUTC" <= $1
+			ORDER BY "nextAttemptAtUTC"
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		  )
+		RETURNING d."ID", d."webhookID", d."eventID", d."eventType", d."payload", d."attempts", d."createdAtUTC", w."url", w."secret";
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, now.UTC(), now.UTC().Add(lease), limit)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrClaimDeliveriesFailed
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+
+	for rows.Next() {
+		d := model.WebhookDelivery{Status: model.DeliveryStatuses.Pending}
+		var payload []byte
+
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Attempts, &d.CreatedAtUTC, &d.URL, &d.Secret); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrClaimDeliveriesFailed
+		}
+
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrClaimDeliveriesFailed
+	}
+
+	return deliveries, nil
+}
+
+func (s *PostgresStore) RecordWebhookAttempt(ctx context.Context, deliveryID uuid.UUID, a model.DeliveryAttempt) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET "status" = $2,
+		    "attempts" = "attempts" + 1,
+		    "lastAttemptAtUTC" = $3,
+		    "responseStatus" = $4,
+		    "lastError" = $5,
+		    "nextAttemptAtUTC" = $6,
+		    "deliveredAtUTC" = CASE WHEN $2 = 'delivered' THEN $3 END
+		WHERE "ID" = $1;
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, deliveryID, a.Status, a.AttemptedAtUTC.UTC(), a.ResponseStatus, a.Error, a.NextAttemptAtUTC); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrRecordAttemptFailed
+	}
+
+	return nil
+}
+
+// GetWebhookDeliveries returns the most recent deliveries of a webhook,
+// newest first, optionally restricted to one status.
+func (s *PostgresStore) GetWebhookDeliveries(ctx context.Context, webhookID uuid.UUID, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID); err != nil {
+		if errors.Is(err, helpers.ErrWebhookDoesNotExist) {
+			return nil, err
+		}
+		return nil, ErrGetDeliveriesFailed
+	}
+
+	query := `
+		SELECT "ID", "webhookID", "eventID", "eventType", "payload", "status", "attempts",
+		       "nextAttemptAtUTC", "lastAttemptAtUTC", "responseStatus", "lastError", "createdAtUTC", "deliveredAtUTC"
+		FROM webhook_deliveries
+		WHERE "webhookID" = $1
+		  AND ($2 = '' OR "status"::text = $2)
+		ORDER BY "createdAtUTC" DESC, "ID"
+		LIMIT $3;
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, webhookID, string(status), limit)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetDeliveriesFailed
+	}
+	defer rows.Close()
+
+	deliveries := []model.WebhookDelivery{}
+
+	for rows.Next() {
+		var d model.WebhookDelivery
+		var payload []byte
+
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAtUTC, &d.LastAttemptAtUTC, &d.ResponseStatus, &d.LastError, &d.CreatedAtUTC, &d.DeliveredAtUTC); err != nil {
+			slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+			return nil, ErrGetDeliveriesFailed
+		}
+
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return nil, ErrGetDeliveriesFailed
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverWebhookDelivery puts a delivery back in the queue with a fresh set
+// of attempts, typically to replay a dead letter once the receiver is fixed.
+func (s *PostgresStore) RedeliverWebhookDelivery(ctx context.Context, webhookID uuid.UUID, deliveryID uuid.UUID) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET "status" = 'pending', "attempts" = 0, "nextAttemptAtUTC" = NOW(), "deliveredAtUTC" = NULL
+		WHERE "ID" = $1 AND "webhookID" = $2;
+	`
+
+	res, err := s.db.ExecContext(ctx, query, deliveryID, webhookID)
+	if err != nil {
+		slog.ErrorContext(ctx, `{"error":"`+err.Error()+`"}`)
+
+		return ErrRedeliverFailed
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return helpers.ErrDeliveryDoesNotExist
+	}
+
+	return nil
+}
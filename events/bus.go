@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler consumes published events. Handlers run synchronously on the
+// publisher's goroutine, so they must not block; anything slow should be
+// queued.
+type Handler func(ctx context.Context, e Event)
+
+// Bus fans events out to in-process subscribers.
+type Bus struct {
+	mu       sync.RWMutex
+	next     int
+	handlers map[int]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[int]Handler)}
+}
+
+// Subscribe registers h and returns a function that removes it.
+func (b *Bus) Subscribe(h Handler) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.next
+	b.next++
+	b.handlers[id] = h
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.handlers, id)
+	}
+}
+
+// Publish delivers e to every subscriber. The context passed to handlers is
+// detached from ctx's cancellation so that a client disconnecting after its
+// write committed does not stop the event from being handled.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, h := range b.handlers {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	ctx = context.WithoutCancel(ctx)
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
@@ -0,0 +1,119 @@
+package events
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+// Event types published by domain.Publisher.
+const (
+	AssetCreated       Type = "asset.created"
+	AssetUpdated       Type = "asset.updated"
+	AssetDeleted       Type = "asset.deleted"
+	AssetRestored      Type = "asset.restored"
+	AssetStatusChanged Type = "asset.status_changed"
+	AssetTransferred   Type = "asset.transferred"
+	AssetPosition      Type = "asset.position"
+	AssetTelemetry     Type = "asset.telemetry"
+	LocationCreated    Type = "location.created"
+	LocationUpdated    Type = "location.updated"
+	LocationDeleted    Type = "location.deleted"
+	LocationRestored   Type = "location.restored"
+	LocationMoved      Type = "location.moved"
+	GeofenceEnter      Type = "geofence.enter"
+	GeofenceExit       Type = "geofence.exit"
+	AlertOpened        Type = "alert.opened"
+	AlertResolved      Type = "alert.resolved"
+)
+
+// Types lists every event type, for validating subscription filters.
+var Types = []Type{
+	AssetCreated,
+	AssetUpdated,
+	AssetDeleted,
+	AssetRestored,
+	AssetStatusChanged,
+	AssetTransferred,
+	AssetPosition,
+	AssetTelemetry,
+	LocationCreated,
+	LocationUpdated,
+	LocationDeleted,
+	LocationRestored,
+	LocationMoved,
+	GeofenceEnter,
+	GeofenceExit,
+	AlertOpened,
+	AlertResolved,
+}
+
+// Event is a change that has been committed to the store. AssetID and
+// LocationID identify what the event is about so consumers can filter
+// without decoding Data, which holds a model value specific to Type.
+type Event struct {
+	ID            uuid.UUID  `json:"ID"`
+	Type          Type       `json:"type"`
+	OccurredAtUTC time.Time  `json:"occurredAtUTC"`
+	AssetID       *uuid.UUID `json:"assetID,omitempty"`
+	LocationID    *uuid.UUID `json:"locationID,omitempty"`
+	Data          any        `json:"data,omitempty"`
+}
+
+// New returns an event of type t stamped with a fresh ID and the current
+// time.
+func New(t Type, assetID, locationID *uuid.UUID, data any) Event {
+	return Event{
+		ID:            uuid.New(),
+		Type:          t,
+		OccurredAtUTC: time.Now().UTC().Truncate(time.Millisecond),
+		AssetID:       assetID,
+		LocationID:    locationID,
+		Data:          data,
+	}
+}
+
+// Match reports whether t is selected by filter. A filter entry is an exact
+// type, a family such as "asset.*", or "*". An empty filter matches every
+// type.
+func Match(filter []string, t Type) bool {
+	if len(filter) == 0 {
+		return true
+	}
+
+	for _, f := range Filters(t) {
+		if slices.Contains(filter, f) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Filters returns every filter entry that selects t: "*", each family t
+// belongs to and t itself. A non-empty filter matches t exactly when it
+// shares an entry with them, which lets a store match filters in SQL.
+func Filters(t Type) []string {
+	filters := []string{"*"}
+	for i, c := range string(t) {
+		if c == '.' {
+			filters = append(filters, string(t[:i])+".*")
+		}
+	}
+
+	return append(filters, string(t))
+}
+
+// ValidFilter reports whether f is an entry Match understands and can match
+// at least one known type.
+func ValidFilter(f string) bool {
+	for _, t := range Types {
+		if Match([]string{f}, t) {
+			return true
+		}
+	}
+	return false
+}
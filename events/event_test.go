@@ -0,0 +1,22 @@
+package events
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFilters(t *testing.T) {
+	if got, want := Filters(AssetStatusChanged), []string{"*", "asset.*", "asset.status_changed"}; !slices.Equal(got, want) {
+		t.Errorf("Filters = %v, want %v", got, want)
+	}
+
+	// the store matches filters by overlap with Filters, so every entry
+	// Match accepts must be among them
+	for _, f := range []string{"*", "asset.*", "asset.created", "location.*", "geofence.enter", "nope"} {
+		for _, typ := range Types {
+			if Match([]string{f}, typ) != slices.Contains(Filters(typ), f) {
+				t.Errorf("Match(%q, %s) disagrees with Filters", f, typ)
+			}
+		}
+	}
+}
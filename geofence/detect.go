@@ -0,0 +1,50 @@
+package geofence
+
+import (
+	"sort"
+
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// Detect walks positions in time order and returns an enter or exit event
+// every time the asset crosses one of the fences. inside holds the asset's
+// state per location before the first position and is updated in place.
+func Detect(assetID uuid.UUID, fences []model.Geofence, inside map[uuid.UUID]bool, positions []model.Position) []model.GeofenceEvent {
+	ordered := make([]model.Position, len(positions))
+	copy(ordered, positions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].RecordedAtUTC.Before(ordered[j].RecordedAtUTC)
+	})
+
+	events := []model.GeofenceEvent{}
+
+	for _, p := range ordered {
+		lat, lng := *p.Latitude, *p.Longitude
+
+		for _, f := range fences {
+			in := Contains(f, lat, lng)
+			if in == inside[f.LocationID] {
+				continue
+			}
+
+			event := model.GeofenceEventTypes.Exit
+			if in {
+				event = model.GeofenceEventTypes.Enter
+			}
+
+			events = append(events, model.GeofenceEvent{
+				AssetID:       assetID,
+				LocationID:    f.LocationID,
+				Event:         event,
+				Latitude:      lat,
+				Longitude:     lng,
+				OccurredAtUTC: p.RecordedAtUTC.UTC(),
+			})
+			inside[f.LocationID] = in
+		}
+	}
+
+	return events
+}
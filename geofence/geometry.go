@@ -0,0 +1,61 @@
+package geofence
+
+import (
+	"math"
+
+	"crud/model"
+)
+
+const earthRadiusMeters = 6371008.8
+
+// Contains reports whether the point lies inside the fence. Points exactly on
+// a polygon edge may fall either way.
+func Contains(f model.Geofence, lat, lng float64) bool {
+	switch f.Shape {
+	case model.GeofenceShapes.Circle:
+		if f.Center == nil || f.RadiusMeters == nil {
+			return false
+		}
+		return Distance(f.Center.Latitude, f.Center.Longitude, lat, lng) <= *f.RadiusMeters
+
+	case model.GeofenceShapes.Polygon:
+		return inPolygon(f.Polygon, lat, lng)
+	}
+
+	return false
+}
+
+// Distance returns the great-circle distance in metres (haversine).
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rlat1 := lat1 * math.Pi / 180
+	rlat2 := lat2 * math.Pi / 180
+	dlat := (lat2 - lat1) * math.Pi / 180
+	dlng := (lng2 - lng1) * math.Pi / 180
+
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) + math.Cos(rlat1)*math.Cos(rlat2)*math.Sin(dlng/2)*math.Sin(dlng/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// inPolygon is an even-odd ray cast on the lat/lng plane, which is accurate
+// enough for site-sized fences that do not cross the antimeridian.
+func inPolygon(poly []model.Point, lat, lng float64) bool {
+	if len(poly) < 3 {
+		return false
+	}
+
+	inside := false
+	j := len(poly) - 1
+
+	for i := range poly {
+		yi, xi := poly[i].Latitude, poly[i].Longitude
+		yj, xj := poly[j].Latitude, poly[j].Longitude
+
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+		j = i
+	}
+
+	return inside
+}
@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+)
+
+const (
+	maxImportBytes = 10 << 20
+	maxImportRows  = 10000
+
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+var (
+	errUnsupportedImportType   = errors.New("content type must be text/csv or application/x-ndjson")
+	errUnsupportedExportFormat = errors.New("format must be csv or ndjson")
+	errTooManyImportRows       = fmt.Errorf("imports are limited to %d rows", maxImportRows)
+	errImportTooLarge          = fmt.Errorf("imports are limited to %d bytes", maxImportBytes)
+)
+
+// importRecord is one record of an import upload. err is set when the record
+// could not be decoded; row is its 1-based position, not counting a CSV
+// header.
+type importRecord[T any] struct {
+	row   int
+	value T
+	err   error
+}
+
+// readImport decodes a CSV or NDJSON upload, chosen by the Content-Type
+// header. CSV uploads need a header row containing columns (matched without
+// regard to case); other columns are ignored and fromCSV maps the named
+// cells of each record onto T.
+func readImport[T any](r *http.Request, columns []string, fromCSV func(map[string]string) (T, error)) ([]importRecord[T], error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, errUnsupportedImportType
+	}
+
+	body := &countingReader{r: r.Body}
+
+	var records []importRecord[T]
+	switch mediaType {
+	case contentTypeCSV:
+		records, err = readCSV(body, columns, fromCSV)
+	case contentTypeNDJSON, "application/ndjson":
+		records, err = readNDJSON[T](body)
+	default:
+		return nil, errUnsupportedImportType
+	}
+
+	var tooLarge *importTooLargeError
+	if errors.As(err, &tooLarge) {
+		return nil, errImportTooLarge
+	}
+
+	return records, err
+}
+
+type importTooLargeError struct{}
+
+func (*importTooLargeError) Error() string { return errImportTooLarge.Error() }
+
+// countingReader fails with importTooLargeError once more than
+// maxImportBytes have been read, which stops the CSV and NDJSON readers.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.n > maxImportBytes {
+		return n, &importTooLargeError{}
+	}
+	return n, err
+}
+
+func readCSV[T any](body io.Reader, columns []string, fromCSV func(map[string]string) (T, error)) ([]importRecord[T], error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv header row is missing")
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if i == 0 {
+			h = strings.TrimPrefix(h, "\ufeff")
+		}
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, c := range columns {
+		if _, ok := index[strings.ToLower(c)]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %s", c)
+		}
+	}
+
+	records := []importRecord[T]{}
+	for {
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+		if len(records) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		cells := make(map[string]string, len(index))
+		for name, i := range index {
+			if i < len(fields) {
+				cells[name] = strings.TrimSpace(fields[i])
+			}
+		}
+
+		v, err := fromCSV(cells)
+		records = append(records, importRecord[T]{row: len(records) + 1, value: v, err: err})
+	}
+
+	return records, nil
+}
+
+func csvError(err error) error {
+	var tooLarge *importTooLargeError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return fmt.Errorf("invalid csv on line %d: %v", pe.Line, pe.Err)
+	}
+	return errors.New("invalid csv")
+}
+
+func readNDJSON[T any](body io.Reader) ([]importRecord[T], error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	records := []importRecord[T]{}
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(records) == maxImportRows {
+			return nil, errTooManyImportRows
+		}
+
+		var v T
+		err := json.Unmarshal(line, &v)
+		if err != nil {
+			err = errors.New("invalid JSON")
+		}
+		records = append(records, importRecord[T]{row: len(records) + 1, value: v, err: err})
+	}
+
+	if err := scanner.Err(); err != nil {
+		var tooLarge *importTooLargeError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, errors.New("invalid ndjson: " + err.Error())
+	}
+
+	return records, nil
+}
+
+// writeImportError reports a readImport error. Errors other than the
+// registered ones describe malformed input.
+func writeImportError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, errUnsupportedImportType), errors.Is(err, errImportTooLarge), errors.Is(err, errTooManyImportRows):
+		problem.Write(w, r, err)
+	default:
+		problem.Write(w, r, problem.New(http.StatusBadRequest, codeInvalidImport, err.Error()))
+	}
+}
+
+// writeExport streams the records produced by export as CSV or NDJSON,
+// chosen by the format query parameter or, failing that, the Accept header.
+// Once the first record is written the status is committed, so a failure
+// part-way through can only be logged and the response cut short.
+func writeExport[T any](w http.ResponseWriter, r *http.Request, name string, header []string, toCSV func(T) []string, export func(context.Context, func(T) error) error) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+		if strings.Contains(r.Header.Get("Accept"), contentTypeCSV) {
+			format = "csv"
+		}
+	}
+
+	var write func(T) error
+	var flush func() error
+
+	switch format {
+	case "csv":
+		cw := csv.NewWriter(w)
+		w.Header().Set("Content-Type", contentTypeCSV+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := cw.Write(header); err != nil {
+			return
+		}
+		write = func(v T) error { return cw.Write(toCSV(v)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+
+	case "ndjson":
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", contentTypeNDJSON)
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.ndjson"`)
+		w.WriteHeader(http.StatusOK)
+		write = func(v T) error { return enc.Encode(v) }
+		flush = func() error { return nil }
+
+	default:
+		problem.Write(w, r, problem.Invalid(errUnsupportedExportFormat))
+		return
+	}
+
+	if err := export(r.Context(), write); err != nil {
+		slog.ErrorContext(r.Context(), `{"error":"export `+name+`: `+err.Error()+`"}`)
+	}
+	if err := flush(); err != nil {
+		slog.ErrorContext(r.Context(), `{"error":"export `+name+`: `+err.Error()+`"}`)
+	}
+}
+
+// validateImport validates the decoded records with the same rules as the
+// create endpoints. It returns a result slot per record, with failed rows
+// already filled in, and the valid values along with the index of their slot.
+// prepare normalizes a valid value before it is stored.
+func validateImport[T any](records []importRecord[T], prepare func(*T)) ([]model.ImportRowResult, []T, []int) {
+	rows := make([]model.ImportRowResult, len(records))
+	valid := []T{}
+	slots := []int{}
+
+	for i, rec := range records {
+		rows[i].Row = rec.row
+
+		if rec.err != nil {
+			rows[i].Status = model.ImportRowStatuses.Failed
+			rows[i].Error = rec.err.Error()
+			continue
+		}
+
+		if err := helpers.Validate(&rec.value); err != nil {
+			rows[i].Status = model.ImportRowStatuses.Failed
+			rows[i].Error = "validation failed"
+			rows[i].Fields = helpers.ValidationMessages(err)
+			continue
+		}
+
+		if prepare != nil {
+			prepare(&rec.value)
+		}
+		valid = append(valid, rec.value)
+		slots = append(slots, i)
+	}
+
+	return rows, valid, slots
+}
+
+// writeImportReport merges the store results for the valid rows into rows and
+// writes the report.
+func writeImportReport(w http.ResponseWriter, r *http.Request, rows []model.ImportRowResult, slots []int, results []model.ImportRowResult) {
+	for i, res := range results {
+		res.Row = rows[slots[i]].Row
+		rows[slots[i]] = res
+	}
+
+	report := model.ImportReport{Rows: []model.ImportRowResult{}}
+	for _, row := range rows {
+		report.Add(row)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+)
+
+func (h *AlertHandler) CreateAlertRule(w http.ResponseWriter, r *http.Request) {
+	req := model.CreateAlertRuleRequest{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	rule := &model.AlertRule{
+		Name:            req.Name,
+		Kind:            model.AlertRuleKind(req.Kind),
+		AssetID:         req.AssetID,
+		LocationID:      req.LocationID,
+		Metric:          req.Metric,
+		Operator:        model.Comparison(req.Operator),
+		Threshold:       req.Threshold,
+		DurationSeconds: req.DurationSeconds,
+	}
+
+	if err := h.store.CreateAlertRule(r.Context(), rule); err != nil {
+		problem.Write(w, r, referenceProblem(err, helpers.ErrLocationDoesNotExist, "locationID"))
+		return
+	}
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
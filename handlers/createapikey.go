@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/auth"
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+)
+
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	req := model.CreateAPIKeyRequest{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	raw, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	key := &model.APIKey{
+		Name:   req.Name,
+		Prefix: prefix,
+		Role:   model.Role(req.Role),
+	}
+
+	if err := h.store.CreateAPIKey(r.Context(), key, hash); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// the plaintext key is only ever returned here
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(APIKeyCreatedResponse{
+		ID:           key.ID,
+		Name:         key.Name,
+		Role:         key.Role,
+		Key:          raw,
+		CreatedAtUTC: key.CreatedAtUTC,
+	})
+}
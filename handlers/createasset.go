@@ -1,28 +1,24 @@
 package handlers
 
 import (
-	"crud/domain"
 	"crud/helpers"
 	"crud/model"
+	"crud/problem"
 	"encoding/json"
-	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 )
 
-func CreateAsset(w http.ResponseWriter, r *http.Request) {
+func (h *AssetHandler) CreateAsset(w http.ResponseWriter, r *http.Request) {
 	locationID := r.PathValue("locationID")
 	locationUUID, err := uuid.Parse(locationID)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
 		return
 	}
 
-	req := struct {
-		Name   string `json:"name" validate:"required,min=5,max=50"`
-		Status string `json:"status" validate:"required,oneof=online offline"`
-	}{}
+	req := model.AssetInput{}
 
 	if err := helpers.ValidateRequest(w, r, &req); err != nil {
 		return
@@ -32,27 +28,17 @@ func CreateAsset(w http.ResponseWriter, r *http.Request) {
 		Name:       req.Name,
 		Status:     model.Status(req.Status),
 		LocationID: locationUUID,
+		TypeID:     req.TypeID,
+		Attributes: req.Attributes,
 	}
 
-	if err := domain.CreateAsset(r.Context(), asset); err != nil {
-		if errors.Is(err, helpers.ErrLocationDoesNotExist) {
-			http.Error(w, `{"error":"location does not exist"}`, http.StatusBadRequest)
-			return
-		}
-
-		if errors.Is(err, helpers.ErrAssetAlreadyExists) {
-			http.Error(w, `{"error":"`+helpers.ErrAssetAlreadyExists.Error()+`"}`, http.StatusConflict)
-			return
-		}
-
-		http.Error(w, `{"error":"failed to create asset"}`, http.StatusInternalServerError)
+	if err := h.store.CreateAsset(r.Context(), asset); err != nil {
+		problem.Write(w, r, attributesProblem(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(struct {
-		ID *uuid.UUID `json:"ID"`
-	}{
+	json.NewEncoder(w).Encode(IDResponse{
 		ID: asset.ID,
 	})
 }
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/helpers"
+	"crud/jsonschema"
+	"crud/model"
+	"crud/problem"
+)
+
+func (h *AssetTypeHandler) CreateAssetType(w http.ResponseWriter, r *http.Request) {
+	req := model.AssetTypeInput{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	// compiling up front means a stored schema always compiles later
+	if _, err := jsonschema.Compile(req.Schema); err != nil {
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "one or more fields are invalid")
+		p.Fields = map[string]string{"schema": err.Error()}
+		problem.Write(w, r, p)
+		return
+	}
+
+	assetType := &model.AssetType{
+		Name:   req.Name,
+		Schema: req.Schema,
+	}
+
+	if err := h.store.CreateAssetType(r.Context(), assetType); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	data, err := json.Marshal(assetType)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
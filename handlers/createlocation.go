@@ -2,22 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 	"strings"
 
-	"crud/domain"
 	"crud/helpers"
 	"crud/model"
-
-	"github.com/google/uuid"
+	"crud/problem"
 )
 
-func CreateLocation(w http.ResponseWriter, r *http.Request) {
-	req := struct {
-		Name string `json:"name" validate:"required,min=5,max=50"`
-		Code string `json:"code" validate:"required,len=4"`
-	}{}
+func (h *LocationHandler) CreateLocation(w http.ResponseWriter, r *http.Request) {
+	req := model.CreateLocationRequest{}
 
 	if err := helpers.ValidateRequest(w, r, &req); err != nil {
 		return
@@ -26,28 +20,19 @@ func CreateLocation(w http.ResponseWriter, r *http.Request) {
 	req.Code = strings.ToUpper(req.Code)
 
 	location := &model.Location{
-		Name: req.Name,
-		Code: req.Code,
+		Name:     req.Name,
+		Code:     req.Code,
+		ParentID: req.ParentID,
 	}
 
-	err := domain.CreateLocation(r.Context(), location)
+	err := h.store.CreateLocation(r.Context(), location)
 	if err != nil {
-		if errors.Is(err, helpers.ErrLocationAlreadyExists) {
-			http.Error(w, `{"error":"`+helpers.ErrLocationAlreadyExists.Error()+`"}`, http.StatusConflict)
-			return
-		}
-		if errors.Is(err, helpers.ErrCodeAlreadyExists) {
-			http.Error(w, `{"error":"`+helpers.ErrCodeAlreadyExists.Error()+`"}`, http.StatusConflict)
-			return
-		}
-		http.Error(w, `{"error":"create location failed"}`, http.StatusInternalServerError)
+		problem.Write(w, r, referenceProblem(err, helpers.ErrParentLocationDoesNotExist, "parentID"))
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(struct {
-		ID *uuid.UUID `json:"ID"`
-	}{
+	json.NewEncoder(w).Encode(IDResponse{
 		ID: location.ID,
 	})
 }
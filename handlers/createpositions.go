@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *PositionHandler) CreatePositions(w http.ResponseWriter, r *http.Request) {
+	assetID := r.PathValue("assetID")
+	assetUUID, err := uuid.Parse(assetID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	batch := model.PositionBatch{}
+	if err := helpers.ValidateRequest(w, r, &batch); err != nil {
+		return
+	}
+
+	events, err := h.store.CreatePositions(r.Context(), assetUUID, batch.Positions)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(PositionsAcceptedResponse{
+		Accepted:       len(batch.Positions),
+		GeofenceEvents: events,
+	})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *TelemetryHandler) CreateTelemetry(w http.ResponseWriter, r *http.Request) {
+	assetID := r.PathValue("assetID")
+	assetUUID, err := uuid.Parse(assetID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	batch := model.TelemetryBatch{}
+	if err := helpers.ValidateRequest(w, r, &batch); err != nil {
+		return
+	}
+
+	if err := h.store.CreateTelemetry(r.Context(), assetUUID, batch.Readings); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(TelemetryAcceptedResponse{
+		Accepted: len(batch.Readings),
+	})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/events"
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+	"crud/webhook"
+)
+
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	req := model.CreateWebhookRequest{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	for _, f := range req.Events {
+		if !events.ValidFilter(f) {
+			problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "unknown event type "+f))
+			return
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = webhook.GenerateSecret(); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+	}
+
+	hook := &model.Webhook{
+		URL:    req.URL,
+		Events: req.Events,
+		Secret: secret,
+	}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+
+	if err := h.store.CreateWebhook(r.Context(), hook); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	// the secret is only ever returned here
+	data, err := json.Marshal(hook)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(data)
+}
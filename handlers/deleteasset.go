@@ -1,37 +1,36 @@
 package handlers
 
 import (
-	"errors"
+	"crud/problem"
 	"net/http"
 
-	"crud/domain"
-	"crud/helpers"
-
 	"github.com/google/uuid"
 )
 
-func DeleteAsset(w http.ResponseWriter, r *http.Request) {
+func (h *AssetHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
 	locationID := r.PathValue("locationID")
 	assetID := r.PathValue("assetID")
 
 	locationUUID, err := uuid.Parse(locationID)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
 		return
 	}
 
 	assetUUID, err := uuid.Parse(assetID)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
 		return
 	}
 
-	if err := domain.DeleteAsset(r.Context(), locationUUID, assetUUID); err != nil {
-		if errors.Is(err, helpers.ErrAssetDoesNotExist) {
-			http.Error(w, `{"error":"`+helpers.ErrAssetDoesNotExist.Error()+`"}`, http.StatusNotFound)
-			return
-		}
-		http.Error(w, `{"error":"failed to delete asset"}`, http.StatusInternalServerError)
+	if err := h.store.DeleteAsset(r.Context(), locationUUID, assetUUID, version); err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
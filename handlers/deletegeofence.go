@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"crud/problem"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *GeofenceHandler) DeleteGeofence(w http.ResponseWriter, r *http.Request) {
+	locationID := r.PathValue("locationID")
+	locationUUID, err := uuid.Parse(locationID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	if err := h.store.DeleteGeofence(r.Context(), locationUUID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,23 +1,28 @@
 package handlers
 
 import (
+	"crud/problem"
 	"net/http"
 
-	"crud/domain"
-
 	"github.com/google/uuid"
 )
 
-func DeleteLocation(w http.ResponseWriter, r *http.Request) {
+func (h *LocationHandler) DeleteLocation(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
 		return
 	}
 
-	if err := domain.DeleteLocation(r.Context(), uid); err != nil {
-		http.Error(w, `{"error":"failed to delete location"}`, http.StatusInternalServerError)
+	if err := h.store.DeleteLocation(r.Context(), uid, version); err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
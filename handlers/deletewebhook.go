@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"crud/problem"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	if err := h.store.DeleteWebhook(r.Context(), id); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
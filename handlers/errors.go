@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"crud/domain"
+	"crud/helpers"
+	"crud/jsonschema"
+	"crud/problem"
+)
+
+// codeInvalidImport reports an upload that could not be parsed at all, as
+// opposed to individual rows failing.
+const codeInvalidImport = "invalid_import"
+
+// The status and code each store and handler error is reported with. Store
+// failures that are not the client's fault keep their message, which names
+// the operation, but always map to 500.
+func init() {
+	for _, e := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{helpers.ErrLocationDoesNotExist, http.StatusNotFound, "location_not_found"},
+		{helpers.ErrLocationAlreadyExists, http.StatusConflict, "location_already_exists"},
+		{helpers.ErrCodeAlreadyExists, http.StatusConflict, "location_code_already_exists"},
+		{helpers.ErrAssetDoesNotExist, http.StatusNotFound, "asset_not_found"},
+		{helpers.ErrAssetAlreadyExists, http.StatusConflict, "asset_already_exists"},
+		{helpers.ErrAssetAlreadyAtLocation, http.StatusConflict, "asset_already_at_location"},
+		{helpers.ErrNoValidFieldsToUpdate, http.StatusBadRequest, "no_fields_to_update"},
+		{helpers.ErrGeofenceDoesNotExist, http.StatusNotFound, "geofence_not_found"},
+		{helpers.ErrAPIKeyDoesNotExist, http.StatusNotFound, "api_key_not_found"},
+		{helpers.ErrAPIKeyAlreadyExists, http.StatusConflict, "api_key_already_exists"},
+		{helpers.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+		{helpers.ErrInvalidSort, http.StatusBadRequest, "invalid_sort"},
+		{helpers.ErrWebhookDoesNotExist, http.StatusNotFound, "webhook_not_found"},
+		{helpers.ErrDeliveryDoesNotExist, http.StatusNotFound, "webhook_delivery_not_found"},
+		{helpers.ErrAlertRuleDoesNotExist, http.StatusNotFound, "alert_rule_not_found"},
+		{helpers.ErrAlertDoesNotExist, http.StatusNotFound, "alert_not_found"},
+		{helpers.ErrAlertAlreadyResolved, http.StatusConflict, "alert_already_resolved"},
+		{helpers.ErrVersionMismatch, http.StatusPreconditionFailed, "version_mismatch"},
+		{helpers.ErrLocationHasAssets, http.StatusConflict, "location_has_assets"},
+		{helpers.ErrLocationNotDeleted, http.StatusConflict, "location_not_deleted"},
+		{helpers.ErrAssetNotDeleted, http.StatusConflict, "asset_not_deleted"},
+		{helpers.ErrParentLocationDoesNotExist, http.StatusBadRequest, "parent_location_not_found"},
+		{helpers.ErrLocationHasChildren, http.StatusConflict, "location_has_children"},
+		{helpers.ErrLocationCycle, http.StatusConflict, "location_cycle"},
+		{helpers.ErrAssetTypeDoesNotExist, http.StatusNotFound, "asset_type_not_found"},
+		{helpers.ErrAssetTypeAlreadyExists, http.StatusConflict, "asset_type_already_exists"},
+		{helpers.ErrAssetTypeInUse, http.StatusConflict, "asset_type_in_use"},
+		{helpers.ErrAttributesWithoutType, http.StatusBadRequest, "attributes_without_type"},
+
+		{errUnsupportedImportType, http.StatusUnsupportedMediaType, "unsupported_media_type"},
+		{errImportTooLarge, http.StatusRequestEntityTooLarge, "import_too_large"},
+		{errTooManyImportRows, http.StatusRequestEntityTooLarge, "import_too_large"},
+	} {
+		problem.Register(e.err, e.status, e.code)
+	}
+
+	for _, err := range []error{
+		domain.ErrGetAllAssetsFailed, domain.ErrGetAssetByLocationFailed, domain.ErrGetAssetFailed,
+		domain.ErrCreateAssetFailed, domain.ErrUpdateAssetFailed, domain.ErrDeleteAssetFailed,
+		domain.ErrRestoreAssetFailed,
+		domain.ErrCountAssetsFailed,
+		domain.ErrCreateAssetTypeFailed, domain.ErrGetAssetTypesFailed, domain.ErrDeleteAssetTypeFailed,
+		domain.ErrTransferAssetFailed, domain.ErrGetAssetTransfersFailed,
+		domain.ErrCreateLocationFailed, domain.ErrGetLocationsFailed, domain.ErrGetLocationFailed,
+		domain.ErrUpdateLocationFailed, domain.ErrDeleteLocationFailed, domain.ErrRestoreLocationFailed,
+		domain.ErrMoveLocationFailed, domain.ErrGetLocationAncestorsFailed, domain.ErrGetLocationSubtreeFailed,
+		domain.ErrPurgeFailed,
+		domain.ErrImportFailed, domain.ErrExportFailed,
+		domain.ErrCreateTelemetryFailed, domain.ErrGetTelemetryFailed,
+		domain.ErrRecordHeartbeatFailed, domain.ErrMarkAssetsOfflineFailed,
+		domain.ErrGetStatusHistoryFailed,
+		domain.ErrCreatePositionsFailed, domain.ErrGetTrackFailed,
+		domain.ErrSetGeofenceFailed, domain.ErrGetGeofenceFailed, domain.ErrDeleteGeofenceFailed,
+		domain.ErrGetGeofenceEventsFailed,
+		domain.ErrCreateAPIKeyFailed, domain.ErrAuthenticateAPIKeyFailed, domain.ErrGetAPIKeysFailed,
+		domain.ErrRevokeAPIKeyFailed,
+		domain.ErrCreateWebhookFailed, domain.ErrGetWebhooksFailed, domain.ErrDeleteWebhookFailed,
+		domain.ErrClaimDeliveriesFailed, domain.ErrRecordAttemptFailed,
+		domain.ErrGetDeliveriesFailed, domain.ErrRedeliverFailed,
+		domain.ErrCreateAlertRuleFailed, domain.ErrGetAlertRulesFailed, domain.ErrDeleteAlertRuleFailed,
+		domain.ErrGetAlertsFailed, domain.ErrOpenAlertFailed, domain.ErrResolveAlertFailed,
+		domain.ErrEvaluateAlertsFailed,
+	} {
+		problem.Register(err, http.StatusInternalServerError, problem.CodeInternal)
+	}
+}
+
+// attributesProblem reports attributes that do not satisfy their type's
+// schema as a validation failure with one message per offending value.
+// Other errors are returned unchanged.
+func attributesProblem(err error) error {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "one or more attributes are invalid")
+	p.Fields = ve.Fields
+	return p
+}
+
+// referenceProblem reports target, the error for a location named in the
+// request body that does not exist, as a validation failure on field. A
+// location in the path that does not exist stays a 404. Other errors are
+// returned unchanged.
+func referenceProblem(err, target error, field string) error {
+	if !errors.Is(err, target) {
+		return err
+	}
+
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "one or more fields are invalid")
+	p.Fields = map[string]string{field: target.Error()}
+	return p
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"crud/model"
+)
+
+var errInvalidIfMatch = errors.New("If-Match must be a single ETag or *")
+
+// etag renders a resource version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// assetETag tags an asset read. Besides the asset's version it covers the
+// location embedded in the body and the last heartbeat and position, which
+// change without bumping the version so that device reports do not break
+// conditional writes. ifMatch reads only the leading asset version.
+func assetETag(a *model.AssetDetail) string {
+	return `"` + strconv.Itoa(a.Version) + "-" + strconv.Itoa(a.Location.Version) +
+		"-" + timeTag(a.LastSeenAtUTC) + "-" + timeTag(positionTime(a.LastPosition)) + `"`
+}
+
+func positionTime(p *model.Position) *time.Time {
+	if p == nil {
+		return nil
+	}
+	return &p.RecordedAtUTC
+}
+
+// timeTag renders t compactly for an entity tag, or 0 when it is unset.
+func timeTag(t *time.Time) string {
+	if t == nil {
+		return "0"
+	}
+	return strconv.FormatInt(t.UnixMicro(), 36)
+}
+
+// ifMatch returns the version a conditional write is based on, or nil when
+// the request has no If-Match or uses "*", which any existing resource
+// matches. The parts of an asset tag after its version are ignored, since
+// writes to the asset do not depend on them. A tag that is not one of ours can never match, so it
+// is returned as version 0, which no resource has.
+func ifMatch(r *http.Request) (*int, error) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	if v == "" || v == "*" {
+		return nil, nil
+	}
+	if strings.Contains(v, ",") {
+		return nil, errInvalidIfMatch
+	}
+
+	version := 0
+	if tag, ok := strings.CutPrefix(v, `"`); ok {
+		tag, _, _ = strings.Cut(strings.TrimSuffix(tag, `"`), "-")
+		if n, err := strconv.Atoi(tag); err == nil && n > 0 {
+			version = n
+		}
+	}
+
+	return &version, nil
+}
+
+// notModified sets the ETag header and, if the request's If-None-Match lists
+// it, answers 304 and reports true.
+func notModified(w http.ResponseWriter, r *http.Request, tag string) bool {
+	w.Header().Set("ETag", tag)
+
+	v := r.Header.Get("If-None-Match")
+	if v == "" {
+		return false
+	}
+
+	for _, t := range strings.Split(v, ",") {
+		t = strings.TrimSpace(t)
+		// If-None-Match uses the weak comparison
+		if t == "*" || strings.TrimPrefix(t, "W/") == tag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"crud/model"
+)
+
+var assetExportColumns = []string{"ID", "name", "status", "locationID", "typeID", "attributes", "lastSeenAtUTC", "createdAtUTC", "lastUpdatedAtUTC"}
+
+func (h *BulkHandler) ExportAssets(w http.ResponseWriter, r *http.Request) {
+	writeExport(w, r, "assets", assetExportColumns, func(a model.AssetExportRow) []string {
+		lastSeen := ""
+		if a.LastSeenAtUTC != nil {
+			lastSeen = a.LastSeenAtUTC.Format(time.RFC3339Nano)
+		}
+		typeID, attributes := "", ""
+		if a.TypeID != nil {
+			typeID = a.TypeID.String()
+		}
+		if len(a.Attributes) > 0 {
+			data, _ := json.Marshal(a.Attributes)
+			attributes = string(data)
+		}
+		return []string{
+			a.ID.String(),
+			a.Name,
+			string(a.Status),
+			a.LocationID.String(),
+			typeID,
+			attributes,
+			lastSeen,
+			a.CreatedAtUTC.Format(time.RFC3339Nano),
+			a.LastUpdatedAtUTC.Format(time.RFC3339Nano),
+		}
+	}, h.store.ExportAssets)
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"crud/model"
+)
+
+var locationExportColumns = []string{"ID", "name", "code", "parentID", "createdAtUTC", "lastUpdatedAtUTC"}
+
+func (h *BulkHandler) ExportLocations(w http.ResponseWriter, r *http.Request) {
+	writeExport(w, r, "locations", locationExportColumns, func(l model.Location) []string {
+		parentID := ""
+		if l.ParentID != nil {
+			parentID = l.ParentID.String()
+		}
+		return []string{l.ID.String(), l.Name, l.Code, parentID, l.CreatedAtUTC, l.LastUpdatedAtUTC}
+	}, h.store.ExportLocations)
+}
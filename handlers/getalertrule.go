@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *AlertHandler) GetAlertRule(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	rule, err := h.store.GetAlertRule(r.Context(), id)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	data, err := json.Marshal(rule)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
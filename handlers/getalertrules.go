@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+)
+
+func (h *AlertHandler) GetAlertRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.store.GetAlertRules(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := AlertRuleListResponse{
+		Rules: rules,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+// GetAlerts lists alerts newest first, filtered by ?status=open|resolved,
+// ?ruleID= and ?assetID=.
+func (h *AlertHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	q := model.AlertQuery{
+		Status: model.AlertStatus(r.URL.Query().Get("status")),
+		Limit:  model.DefaultPageLimit,
+	}
+
+	switch q.Status {
+	case "", model.AlertStatuses.Open, model.AlertStatuses.Resolved:
+	default:
+		problem.Write(w, r, problem.Invalid(errInvalidAlertStatus))
+		return
+	}
+
+	if v := r.URL.Query().Get("ruleID"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			problem.Write(w, r, problem.Invalid(errInvalidRuleID))
+			return
+		}
+		q.RuleID = &id
+	}
+
+	if v := r.URL.Query().Get("assetID"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+			return
+		}
+		q.AssetID = &id
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > model.MaxPageLimit {
+			problem.Write(w, r, problem.Invalid(errInvalidLimit))
+			return
+		}
+		q.Limit = limit
+	}
+
+	alerts, err := h.store.GetAlerts(r.Context(), q)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := AlertListResponse{
+		Alerts: alerts,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+)
+
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.store.GetAPIKeys(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := APIKeyListResponse{
+		APIKeys: keys,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *AssetHandler) GetAsset(w http.ResponseWriter, r *http.Request) {
+	locationID, err := uuid.Parse(r.PathValue("locationID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	assetID, err := uuid.Parse(r.PathValue("assetID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	asset, err := h.store.GetAsset(r.Context(), locationID, assetID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if notModified(w, r, assetETag(asset)) {
+		return
+	}
+
+	response := AssetResponse{
+		Asset: asset,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -1,29 +1,33 @@
 package handlers
 
 import (
-	"crud/domain"
-	"crud/model"
+	"crud/problem"
 	"encoding/json"
 	"net/http"
 )
 
-func GetAssets(w http.ResponseWriter, r *http.Request) {
-	assets, err := domain.GetAllAssets(r.Context())
+func (h *AssetHandler) GetAssets(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAssetListQuery(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	assets, next, err := h.store.GetAllAssets(r.Context(), q)
 
 	if err != nil {
-		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
-	response := struct {
-		Assets []model.Asset `json:"assets"`
-	}{
-		Assets: assets,
+	response := AssetListResponse{
+		Assets:     assets,
+		NextCursor: next,
 	}
 
 	data, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
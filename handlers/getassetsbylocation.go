@@ -1,46 +1,43 @@
 package handlers
 
 import (
-	"crud/domain"
-	"crud/helpers"
-	"crud/model"
+	"crud/problem"
 	"encoding/json"
-	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
 )
 
-func GetAssetsByLocation(w http.ResponseWriter, r *http.Request) {
+func (h *AssetHandler) GetAssetsByLocation(w http.ResponseWriter, r *http.Request) {
 	locationID := r.PathValue("locationID")
 
 	uid, err := uuid.Parse(locationID)
 	if err != nil {
-		http.Error(w, `{"error":"invalid id"}`, http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
 		return
 	}
 
-	assets, err := domain.GetAssetsByLocation(r.Context(), uid)
-
+	q, err := parseAssetListQuery(r)
 	if err != nil {
-		if errors.Is(err, helpers.ErrLocationDoesNotExist) {
-			http.Error(w, `{"error":"`+helpers.ErrLocationDoesNotExist.Error()+`"}`, http.StatusNotFound)
-			return
-		}
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
 
-		http.Error(w, `{"error":"db error: `+err.Error()+`"}`, http.StatusInternalServerError)
+	assets, next, err := h.store.GetAssetsByLocation(r.Context(), uid, q)
+
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
-	response := struct {
-		Assets []model.Asset `json:"assets"`
-	}{
-		Assets: assets,
+	response := AssetListResponse{
+		Assets:     assets,
+		NextCursor: next,
 	}
 
 	data, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
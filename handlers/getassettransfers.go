@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *TransferHandler) GetAssetTransfers(w http.ResponseWriter, r *http.Request) {
+	assetID, err := uuid.Parse(r.PathValue("assetID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	transfers, err := h.store.GetAssetTransfers(r.Context(), assetID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := TransferListResponse{
+		Transfers: transfers,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+)
+
+func (h *AssetTypeHandler) GetAssetTypes(w http.ResponseWriter, r *http.Request) {
+	assetTypes, err := h.store.GetAssetTypes(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := AssetTypeListResponse{
+		AssetTypes: assetTypes,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *GeofenceHandler) GetGeofence(w http.ResponseWriter, r *http.Request) {
+	locationID := r.PathValue("locationID")
+	locationUUID, err := uuid.Parse(locationID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	fence, err := h.store.GetGeofence(r.Context(), locationUUID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	data, err := json.Marshal(fence)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+const defaultGeofenceEventWindow = 7 * 24 * time.Hour
+
+func (h *GeofenceHandler) GetAssetGeofenceEvents(w http.ResponseWriter, r *http.Request) {
+	assetID := r.PathValue("assetID")
+	assetUUID, err := uuid.Parse(assetID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultGeofenceEventWindow)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	events, err := h.store.GetGeofenceEventsByAsset(r.Context(), assetUUID, model.GeofenceEventQuery{From: from, To: to})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeGeofenceEvents(w, r, from, to, events)
+}
+
+func (h *GeofenceHandler) GetLocationGeofenceEvents(w http.ResponseWriter, r *http.Request) {
+	locationID := r.PathValue("locationID")
+	locationUUID, err := uuid.Parse(locationID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultGeofenceEventWindow)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	events, err := h.store.GetGeofenceEventsByLocation(r.Context(), locationUUID, model.GeofenceEventQuery{From: from, To: to})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	writeGeofenceEvents(w, r, from, to, events)
+}
+
+func writeGeofenceEvents(w http.ResponseWriter, r *http.Request, from, to time.Time, events []model.GeofenceEvent) {
+	response := GeofenceEventListResponse{
+		From:   from,
+		To:     to,
+		Events: events,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *LocationHandler) GetLocationAncestors(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	ancestors, err := h.store.GetLocationAncestors(r.Context(), uid)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	data, err := json.Marshal(LocationAncestorsResponse{Ancestors: ancestors})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
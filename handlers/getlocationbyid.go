@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *LocationHandler) GetLocationByID(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	location, err := h.store.GetLocation(r.Context(), uid)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	if notModified(w, r, etag(location.Version)) {
+		return
+	}
+
+	response := LocationResponse{
+		Location: location,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *LocationHandler) GetLocationChildren(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	lq, err := parseListQuery(r, model.LocationSortFields)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	locations, next, err := h.store.GetLocations(r.Context(), model.LocationListQuery{ListQuery: lq, ParentID: &uid})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := LocationListResponse{
+		Locations:  locations,
+		NextCursor: next,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -1,29 +1,34 @@
 package handlers
 
 import (
-	"crud/domain"
 	"crud/model"
+	"crud/problem"
 	"encoding/json"
 	"net/http"
 )
 
-func GetLocation(w http.ResponseWriter, r *http.Request) {
-	locations, err := domain.GetLocations(r.Context())
+func (h *LocationHandler) GetLocation(w http.ResponseWriter, r *http.Request) {
+	lq, err := parseListQuery(r, model.LocationSortFields)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	locations, next, err := h.store.GetLocations(r.Context(), model.LocationListQuery{ListQuery: lq})
 
 	if err != nil {
-		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
-	response := struct {
-		Locations []model.Location `json:"locations"`
-	}{
-		Locations: locations,
+	response := LocationListResponse{
+		Locations:  locations,
+		NextCursor: next,
 	}
 
 	data, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, "json marshal error: "+err.Error(), http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+const defaultStatusHistoryWindow = 7 * 24 * time.Hour
+
+func (h *StatusHistoryHandler) GetStatusHistory(w http.ResponseWriter, r *http.Request) {
+	assetID := r.PathValue("assetID")
+	assetUUID, err := uuid.Parse(assetID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultStatusHistoryWindow)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	history, err := h.store.GetStatusHistory(r.Context(), assetUUID, model.StatusHistoryQuery{From: from, To: to})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := StatusHistoryResponse{
+		From:    from,
+		To:      to,
+		History: history,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+const defaultTelemetryWindow = 24 * time.Hour
+
+func (h *TelemetryHandler) GetTelemetry(w http.ResponseWriter, r *http.Request) {
+	assetID := r.PathValue("assetID")
+	assetUUID, err := uuid.Parse(assetID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultTelemetryWindow)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	q := model.TelemetryQuery{
+		From:   from,
+		To:     to,
+		Metric: r.URL.Query().Get("metric"),
+	}
+
+	readings, err := h.store.GetTelemetry(r.Context(), assetUUID, q)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := TelemetryResponse{
+		From:     from,
+		To:       to,
+		Readings: readings,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+const defaultTrackWindow = 24 * time.Hour
+
+func (h *PositionHandler) GetTrack(w http.ResponseWriter, r *http.Request) {
+	assetID := r.PathValue("assetID")
+	assetUUID, err := uuid.Parse(assetID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	from, to, err := parseTimeRange(r, defaultTrackWindow)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	track, err := h.store.GetTrack(r.Context(), assetUUID, model.TrackQuery{From: from, To: to})
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := TrackResponse{
+		From:  from,
+		To:    to,
+		Track: track,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+// GetWebhookDeliveries lists a webhook's delivery log, newest first,
+// optionally filtered by ?status=pending|delivered|dead.
+func (h *WebhookHandler) GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	status := model.DeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", model.DeliveryStatuses.Pending, model.DeliveryStatuses.Delivered, model.DeliveryStatuses.Dead:
+	default:
+		problem.Write(w, r, problem.Invalid(errInvalidDeliveryStatus))
+		return
+	}
+
+	limit := model.DefaultPageLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > model.MaxPageLimit {
+			problem.Write(w, r, problem.Invalid(errInvalidLimit))
+			return
+		}
+	}
+
+	deliveries, err := h.store.GetWebhookDeliveries(r.Context(), id, status, limit)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := DeliveryListResponse{
+		Deliveries: deliveries,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
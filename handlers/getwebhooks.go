@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+)
+
+func (h *WebhookHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.store.GetWebhooks(r.Context())
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	response := WebhookListResponse{
+		Webhooks: hooks,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"crud/domain"
+	"crud/stream"
+)
+
+// AssetHandler serves the asset endpoints.
+type AssetHandler struct {
+	store domain.AssetStore
+}
+
+func NewAssetHandler(store domain.AssetStore) *AssetHandler {
+	return &AssetHandler{store: store}
+}
+
+// AssetTypeHandler serves the asset type endpoints.
+type AssetTypeHandler struct {
+	store domain.AssetTypeStore
+}
+
+func NewAssetTypeHandler(store domain.AssetTypeStore) *AssetTypeHandler {
+	return &AssetTypeHandler{store: store}
+}
+
+// TransferHandler serves the asset transfer endpoints.
+type TransferHandler struct {
+	store domain.TransferStore
+}
+
+func NewTransferHandler(store domain.TransferStore) *TransferHandler {
+	return &TransferHandler{store: store}
+}
+
+// LocationHandler serves the location endpoints.
+type LocationHandler struct {
+	store domain.LocationStore
+}
+
+func NewLocationHandler(store domain.LocationStore) *LocationHandler {
+	return &LocationHandler{store: store}
+}
+
+// BulkHandler serves the import and export endpoints.
+type BulkHandler struct {
+	store domain.BulkStore
+}
+
+func NewBulkHandler(store domain.BulkStore) *BulkHandler {
+	return &BulkHandler{store: store}
+}
+
+// TelemetryHandler serves the telemetry ingestion and query endpoints.
+type TelemetryHandler struct {
+	store domain.TelemetryStore
+}
+
+func NewTelemetryHandler(store domain.TelemetryStore) *TelemetryHandler {
+	return &TelemetryHandler{store: store}
+}
+
+// HeartbeatHandler serves the device heartbeat endpoint.
+type HeartbeatHandler struct {
+	store domain.HeartbeatStore
+}
+
+func NewHeartbeatHandler(store domain.HeartbeatStore) *HeartbeatHandler {
+	return &HeartbeatHandler{store: store}
+}
+
+// StatusHistoryHandler serves the asset status history endpoint.
+type StatusHistoryHandler struct {
+	store domain.StatusHistoryStore
+}
+
+func NewStatusHistoryHandler(store domain.StatusHistoryStore) *StatusHistoryHandler {
+	return &StatusHistoryHandler{store: store}
+}
+
+// PositionHandler serves the GPS position report and track endpoints.
+type PositionHandler struct {
+	store domain.PositionStore
+}
+
+func NewPositionHandler(store domain.PositionStore) *PositionHandler {
+	return &PositionHandler{store: store}
+}
+
+// GeofenceHandler serves the geofence and crossing event endpoints.
+type GeofenceHandler struct {
+	store domain.GeofenceStore
+}
+
+func NewGeofenceHandler(store domain.GeofenceStore) *GeofenceHandler {
+	return &GeofenceHandler{store: store}
+}
+
+// APIKeyHandler serves the API key management endpoints.
+type APIKeyHandler struct {
+	store domain.APIKeyStore
+}
+
+func NewAPIKeyHandler(store domain.APIKeyStore) *APIKeyHandler {
+	return &APIKeyHandler{store: store}
+}
+
+// WebhookHandler serves the webhook subscription and delivery log endpoints.
+type WebhookHandler struct {
+	store domain.WebhookStore
+}
+
+func NewWebhookHandler(store domain.WebhookStore) *WebhookHandler {
+	return &WebhookHandler{store: store}
+}
+
+// StreamHandler serves the Server-Sent Events stream of live changes.
+type StreamHandler struct {
+	hub *stream.Hub
+}
+
+func NewStreamHandler(hub *stream.Hub) *StreamHandler {
+	return &StreamHandler{hub: hub}
+}
+
+// AlertHandler serves the alert rule and alert endpoints.
+type AlertHandler struct {
+	store domain.AlertStore
+}
+
+func NewAlertHandler(store domain.AlertStore) *AlertHandler {
+	return &AlertHandler{store: store}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidImportLocationID = errors.New("locationID must be a UUID")
+	errInvalidImportTypeID     = errors.New("typeID must be a UUID")
+	errInvalidImportAttributes = errors.New("attributes must be a JSON object")
+)
+
+// ImportAssets accepts the optional CSV columns typeID and attributes, the
+// latter holding a JSON object, alongside the required ones.
+
+func (h *BulkHandler) ImportAssets(w http.ResponseWriter, r *http.Request) {
+	records, err := readImport(r, []string{"name", "status", "locationID"}, func(cells map[string]string) (model.AssetImportRow, error) {
+		row := model.AssetImportRow{
+			AssetInput: model.AssetInput{Name: cells["name"], Status: cells["status"]},
+		}
+		if v := cells["locationid"]; v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return row, errInvalidImportLocationID
+			}
+			row.LocationID = id
+		}
+		if v := cells["typeid"]; v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return row, errInvalidImportTypeID
+			}
+			row.TypeID = &id
+		}
+		if v := cells["attributes"]; v != "" {
+			if err := json.Unmarshal([]byte(v), &row.Attributes); err != nil {
+				return row, errInvalidImportAttributes
+			}
+		}
+		return row, nil
+	})
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	rows, valid, slots := validateImport(records, nil)
+
+	var results []model.ImportRowResult
+	if len(valid) > 0 {
+		results, err = h.store.ImportAssets(r.Context(), valid)
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+	}
+
+	writeImportReport(w, r, rows, slots, results)
+}
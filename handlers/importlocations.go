@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidImportID       = errors.New("ID must be a UUID")
+	errInvalidImportParentID = errors.New("parentID must be a UUID")
+)
+
+// ImportLocations accepts the optional CSV columns ID and parentID, as
+// written by the export, alongside the required ones.
+func (h *BulkHandler) ImportLocations(w http.ResponseWriter, r *http.Request) {
+	records, err := readImport(r, []string{"name", "code"}, func(cells map[string]string) (model.LocationImportRow, error) {
+		row := model.LocationImportRow{
+			LocationInput: model.LocationInput{Name: cells["name"], Code: cells["code"]},
+		}
+		if v := cells["id"]; v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return row, errInvalidImportID
+			}
+			row.ID = &id
+		}
+		if v := cells["parentid"]; v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				return row, errInvalidImportParentID
+			}
+			row.ParentID = &id
+		}
+		return row, nil
+	})
+	if err != nil {
+		writeImportError(w, r, err)
+		return
+	}
+
+	rows, valid, slots := validateImport(records, func(l *model.LocationImportRow) {
+		l.Code = strings.ToUpper(l.Code)
+	})
+
+	var results []model.ImportRowResult
+	if len(valid) > 0 {
+		results, err = h.store.ImportLocations(r.Context(), valid)
+		if err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+	}
+
+	writeImportReport(w, r, rows, slots, results)
+}
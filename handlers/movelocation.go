@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *LocationHandler) MoveLocation(w http.ResponseWriter, r *http.Request) {
+	uid, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	req := model.MoveLocationRequest{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	loc, err := h.store.MoveLocation(r.Context(), uid, req.ParentID, version)
+	if err != nil {
+		problem.Write(w, r, referenceProblem(err, helpers.ErrParentLocationDoesNotExist, "parentID"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(loc.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(IDResponse{
+		ID: loc.ID,
+	})
+}
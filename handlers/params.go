@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"crud/auth"
+	"crud/model"
+	"crud/problem"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	errInvalidFrom  = errors.New("from must be an RFC 3339 timestamp")
+	errInvalidTo    = errors.New("to must be an RFC 3339 timestamp")
+	errInvalidRange = errors.New("from must be before to")
+)
+
+// parseTimeRange reads the optional from/to query parameters. A missing to
+// defaults to now and a missing from defaults to window before to.
+func parseTimeRange(r *http.Request, window time.Duration) (time.Time, time.Time, error) {
+	q := r.URL.Query()
+
+	to := time.Now().UTC()
+	if v := q.Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errInvalidTo
+		}
+		to = t.UTC()
+	}
+
+	from := to.Add(-window)
+	if v := q.Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errInvalidFrom
+		}
+		from = t.UTC()
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errInvalidRange
+	}
+
+	return from, to, nil
+}
+
+var (
+	errInvalidLimit          = fmt.Errorf("limit must be an integer between 1 and %d", model.MaxPageLimit)
+	errInvalidUpdatedAfter   = errors.New("updatedAfter must be an RFC 3339 timestamp")
+	errInvalidUpdatedBefore  = errors.New("updatedBefore must be an RFC 3339 timestamp")
+	errInvalidStatus         = errors.New("status must be online or offline")
+	errInvalidLocationID     = errors.New("locationID must be a UUID")
+	errInvalidAssetID        = errors.New("assetID must be a UUID")
+	errInvalidRuleID         = errors.New("ruleID must be a UUID")
+	errInvalidID             = errors.New("id must be a UUID")
+	errInvalidDeliveryID     = errors.New("deliveryID must be a UUID")
+	errInvalidIncludeDeleted = errors.New("include_deleted must be true or false")
+	errInvalidRecursive      = errors.New("recursive must be true or false")
+	errInvalidTypeID         = errors.New("typeID must be a UUID")
+
+	errInvalidAlertStatus    = errors.New("status must be open or resolved")
+	errInvalidDeliveryStatus = errors.New("status must be pending, delivered or dead")
+)
+
+// parseListQuery reads the paging, sorting and filtering parameters shared by
+// list endpoints: limit, cursor, sort (a field name, prefixed with - for
+// descending order), name (a case-insensitive prefix), updatedAfter,
+// updatedBefore and include_deleted, which only admins may set.
+func parseListQuery(r *http.Request, sortFields []string) (model.ListQuery, error) {
+	q := r.URL.Query()
+	lq := model.ListQuery{
+		Cursor:     q.Get("cursor"),
+		NamePrefix: q.Get("name"),
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > model.MaxPageLimit {
+			return lq, errInvalidLimit
+		}
+		lq.Limit = n
+	}
+
+	if v := q.Get("sort"); v != "" {
+		lq.Sort, lq.Desc = strings.CutPrefix(v, "-")
+		if !slices.Contains(sortFields, lq.Sort) {
+			return lq, fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(sortFields, ", "))
+		}
+	}
+
+	if v := q.Get("updatedAfter"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return lq, errInvalidUpdatedAfter
+		}
+		t = t.UTC()
+		lq.UpdatedAfter = &t
+	}
+
+	if v := q.Get("updatedBefore"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return lq, errInvalidUpdatedBefore
+		}
+		t = t.UTC()
+		lq.UpdatedBefore = &t
+	}
+
+	if v := q.Get("include_deleted"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return lq, errInvalidIncludeDeleted
+		}
+		if b {
+			key := auth.APIKeyFromContext(r.Context())
+			if key == nil || !key.Role.Allows(model.Roles.Admin) {
+				return lq, problem.New(http.StatusForbidden, problem.CodeForbidden, "include_deleted requires the admin role")
+			}
+		}
+		lq.IncludeDeleted = b
+	}
+
+	return lq, nil
+}
+
+// parseAssetListQuery extends parseListQuery with the status, locationID and
+// typeID filters of asset listings, recursive, which widens the location
+// filter to the location's descendants, and attr.<key>=<value>, which
+// matches an attribute. Values that read as JSON numbers, booleans or null
+// are compared as such; anything else, or a quoted JSON string, as a string.
+func parseAssetListQuery(r *http.Request) (model.AssetListQuery, error) {
+	lq, err := parseListQuery(r, model.AssetSortFields)
+	if err != nil {
+		return model.AssetListQuery{}, err
+	}
+
+	aq := model.AssetListQuery{ListQuery: lq}
+	q := r.URL.Query()
+
+	if v := q.Get("status"); v != "" {
+		status := model.Status(v)
+		if status != model.Statuses.Online && status != model.Statuses.Offline {
+			return aq, errInvalidStatus
+		}
+		aq.Status = &status
+	}
+
+	if v := q.Get("locationID"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return aq, errInvalidLocationID
+		}
+		aq.LocationID = &id
+	}
+
+	if v := q.Get("recursive"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return aq, errInvalidRecursive
+		}
+		aq.Recursive = b
+	}
+
+	if v := q.Get("typeID"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return aq, errInvalidTypeID
+		}
+		aq.TypeID = &id
+	}
+
+	for name, values := range q {
+		key, ok := strings.CutPrefix(name, "attr.")
+		if !ok {
+			continue
+		}
+		if key == "" {
+			return aq, errors.New("attr. must be followed by an attribute name")
+		}
+		if aq.Attributes == nil {
+			aq.Attributes = make(map[string]any)
+		}
+		aq.Attributes[key] = attributeValue(values[0])
+	}
+
+	return aq, nil
+}
+
+func attributeValue(v string) any {
+	var value any
+	if err := json.Unmarshal([]byte(v), &value); err != nil {
+		return v
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return v
+	}
+	return value
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"crud/problem"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RedeliverWebhook puts a delivery back in the queue with a fresh set of
+// attempts, typically to replay a dead one once the receiver is fixed.
+func (h *WebhookHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	deliveryID, err := uuid.Parse(r.PathValue("deliveryID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidDeliveryID))
+		return
+	}
+
+	if err := h.store.RedeliverWebhookDelivery(r.Context(), id, deliveryID); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
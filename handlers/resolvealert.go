@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/auth"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+// ResolveAlert closes an open alert by hand. If the rule's condition still
+// holds, the evaluator opens a new alert on its next pass.
+func (h *AlertHandler) ResolveAlert(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	resolvedBy := "api"
+	if key := auth.APIKeyFromContext(r.Context()); key != nil {
+		resolvedBy = key.Name
+	}
+
+	alert, err := h.store.ResolveAlert(r.Context(), id, resolvedBy)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
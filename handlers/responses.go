@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"time"
+
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// Response bodies shared by the handlers and the route table, which uses
+// them to describe each endpoint in the OpenAPI document.
+
+// APIKeyCreatedResponse carries the plaintext key, which is only ever
+// returned when the key is created.
+type APIKeyCreatedResponse struct {
+	ID           *uuid.UUID `json:"ID"`
+	Name         string     `json:"name"`
+	Role         model.Role `json:"role"`
+	Key          string     `json:"key"`
+	CreatedAtUTC time.Time  `json:"createdAtUTC"`
+}
+
+// IDResponse is returned by writes that only report the ID of the
+// resource they created or changed.
+type IDResponse struct {
+	ID *uuid.UUID `json:"ID"`
+}
+
+// PositionsAcceptedResponse reports how many positions were stored and the
+// geofence crossings they caused.
+type PositionsAcceptedResponse struct {
+	Accepted       int                   `json:"accepted"`
+	GeofenceEvents []model.GeofenceEvent `json:"geofenceEvents"`
+}
+
+// TelemetryAcceptedResponse reports how many readings were stored.
+type TelemetryAcceptedResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+type AlertRuleListResponse struct {
+	Rules []model.AlertRule `json:"rules"`
+}
+
+type AlertListResponse struct {
+	Alerts []model.Alert `json:"alerts"`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []model.APIKey `json:"apiKeys"`
+}
+
+type AssetResponse struct {
+	Asset *model.AssetDetail `json:"asset"`
+}
+
+// AssetListResponse is a page of assets. NextCursor is empty on the last
+// page.
+type AssetListResponse struct {
+	Assets     []model.Asset `json:"assets"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+type TransferListResponse struct {
+	Transfers []model.AssetTransfer `json:"transfers"`
+}
+
+// GeofenceEventListResponse lists crossings within the queried window.
+type GeofenceEventListResponse struct {
+	From   time.Time             `json:"from"`
+	To     time.Time             `json:"to"`
+	Events []model.GeofenceEvent `json:"events"`
+}
+
+type LocationResponse struct {
+	Location *model.Location `json:"location"`
+}
+
+// LocationAncestorsResponse lists a location's ancestors, root first.
+type LocationAncestorsResponse struct {
+	Ancestors []model.Location `json:"ancestors"`
+}
+
+type LocationSubtreeResponse struct {
+	Location *model.LocationNode `json:"location"`
+}
+
+// LocationListResponse is a page of locations. NextCursor is empty on the
+// last page.
+type LocationListResponse struct {
+	Locations  []model.Location `json:"locations"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// StatusHistoryResponse lists status transitions within the queried window.
+type StatusHistoryResponse struct {
+	From    time.Time            `json:"from"`
+	To      time.Time            `json:"to"`
+	History []model.StatusChange `json:"history"`
+}
+
+// TelemetryResponse lists readings within the queried window.
+type TelemetryResponse struct {
+	From     time.Time                `json:"from"`
+	To       time.Time                `json:"to"`
+	Readings []model.TelemetryReading `json:"readings"`
+}
+
+// TrackResponse lists positions within the queried window.
+type TrackResponse struct {
+	From  time.Time        `json:"from"`
+	To    time.Time        `json:"to"`
+	Track []model.Position `json:"track"`
+}
+
+type DeliveryListResponse struct {
+	Deliveries []model.WebhookDelivery `json:"deliveries"`
+}
+
+type AssetTypeListResponse struct {
+	AssetTypes []model.AssetType `json:"assetTypes"`
+}
+
+type WebhookListResponse struct {
+	Webhooks []model.Webhook `json:"webhooks"`
+}
+
+type HeartbeatResponse struct {
+	ID            uuid.UUID    `json:"ID"`
+	Status        model.Status `json:"status"`
+	LastSeenAtUTC time.Time    `json:"lastSeenAtUTC"`
+}
+
+type TransferResponse struct {
+	Transfer *model.AssetTransfer `json:"transfer"`
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *AssetHandler) RestoreAsset(w http.ResponseWriter, r *http.Request) {
+	locationUUID, err := uuid.Parse(r.PathValue("locationID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	assetUUID, err := uuid.Parse(r.PathValue("assetID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	asset, err := h.store.RestoreAsset(r.Context(), locationUUID, assetUUID)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(asset.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(IDResponse{
+		ID: asset.ID,
+	})
+}
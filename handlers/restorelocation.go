@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *LocationHandler) RestoreLocation(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	loc, err := h.store.RestoreLocation(r.Context(), uid)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(loc.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(IDResponse{
+		ID: loc.ID,
+	})
+}
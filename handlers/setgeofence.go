@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+
+	"github.com/google/uuid"
+)
+
+func (h *GeofenceHandler) SetGeofence(w http.ResponseWriter, r *http.Request) {
+	locationID := r.PathValue("locationID")
+	locationUUID, err := uuid.Parse(locationID)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	req := model.SetGeofenceRequest{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	fence := &model.Geofence{
+		LocationID: locationUUID,
+		Shape:      model.GeofenceShape(req.Shape),
+	}
+
+	// keep only the fields that belong to the chosen shape
+	switch fence.Shape {
+	case model.GeofenceShapes.Circle:
+		fence.Center = req.Center
+		fence.RadiusMeters = req.RadiusMeters
+	case model.GeofenceShapes.Polygon:
+		fence.Polygon = req.Polygon
+	}
+
+	if err := h.store.SetGeofence(r.Context(), fence); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(fence)
+}
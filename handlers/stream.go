@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"crud/events"
+	"crud/problem"
+	"crud/stream"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// streamHeartbeat keeps idle streams from being closed by proxies and
+	// lets clients notice a dead connection.
+	streamHeartbeat = 15 * time.Second
+
+	// streamWriteTimeout bounds a single write so that a client that stops
+	// reading is disconnected.
+	streamWriteTimeout = 10 * time.Second
+)
+
+// Stream pushes events as Server-Sent Events. Query parameters assetID,
+// locationID and types (each repeatable or comma separated) narrow what is
+// sent. A client reconnecting with Last-Event-ID first receives the buffered
+// events it missed; if they are no longer buffered it receives a "reset"
+// event and should reload its state.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStreamFilter(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
+	// HEAD is served by this handler too; there is no stream to hold open
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	// the stream outlives the server's WriteTimeout, so each write gets its
+	// own deadline instead
+	if err := rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("lastEventID")
+	}
+
+	sub, backlog, complete := h.hub.Subscribe(filter, lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", 3000)
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, m := range backlog {
+		rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := writeStreamMessage(w, m); err != nil {
+			return
+		}
+	}
+	rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(streamHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if err := writeStreamMessage(w, m); err != nil {
+				return
+			}
+		case t := <-ticker.C:
+			rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+			if _, err := fmt.Fprintf(w, ": heartbeat %s\n\n", t.UTC().Format(time.RFC3339)); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeStreamMessage(w http.ResponseWriter, m stream.Message) error {
+	data, err := json.Marshal(m.Event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", m.ID, m.Event.Type, data)
+	return err
+}
+
+func parseStreamFilter(r *http.Request) (stream.Filter, error) {
+	q := r.URL.Query()
+	f := stream.Filter{Types: splitParam(q["types"])}
+
+	for _, t := range f.Types {
+		if !events.ValidFilter(t) {
+			return f, fmt.Errorf("unknown event type %s", t)
+		}
+	}
+
+	for _, v := range splitParam(q["assetID"]) {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, errInvalidAssetID
+		}
+		f.AssetIDs = append(f.AssetIDs, id)
+	}
+
+	for _, v := range splitParam(q["locationID"]) {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return f, errInvalidLocationID
+		}
+		f.LocationIDs = append(f.LocationIDs, id)
+	}
+
+	return f, nil
+}
+
+// splitParam flattens repeated and comma separated query values.
+func splitParam(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+func (h *TransferHandler) TransferAsset(w http.ResponseWriter, r *http.Request) {
+	locationID, err := uuid.Parse(r.PathValue("locationID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidLocationID))
+		return
+	}
+
+	assetID, err := uuid.Parse(r.PathValue("assetID"))
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
+		return
+	}
+
+	req := model.TransferAssetRequest{}
+	if err := helpers.ValidateRequest(w, r, &req); err != nil {
+		return
+	}
+
+	transfer, err := h.store.TransferAsset(r.Context(), locationID, assetID, req.ToLocationID, req.Reason)
+	if err != nil {
+		problem.Write(w, r, referenceProblem(err, helpers.ErrLocationDoesNotExist, "toLocationID"))
+		return
+	}
+
+	response := TransferResponse{
+		Transfer: transfer,
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		problem.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
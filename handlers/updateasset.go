@@ -2,62 +2,57 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 
-	"crud/domain"
 	"crud/helpers"
 	"crud/model"
+	"crud/problem"
 
 	"github.com/google/uuid"
 )
 
-func UpdateAsset(w http.ResponseWriter, r *http.Request) {
+func (h *AssetHandler) UpdateAsset(w http.ResponseWriter, r *http.Request) {
 	locationID := r.PathValue("locationID")
 	assetID := r.PathValue("assetID")
 
 	locationUUID, err := uuid.Parse(locationID)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
 		return
 	}
 
 	assetUUID, err := uuid.Parse(assetID)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidAssetID))
 		return
 	}
+	version, err := ifMatch(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
+		return
+	}
+
 	patch := model.AssetPatch{}
 	if err := helpers.ValidateRequest(w, r, &patch); err != nil {
 		return
 	}
+	patch.Version = version
 
-	if patch.Name == nil && patch.Status == nil {
-		http.Error(w, `{"error":"`+helpers.ErrNoValidFieldsToUpdate.Error()+`"}`, http.StatusBadRequest)
+	if patch.Name == nil && patch.Status == nil && patch.TypeID == nil && patch.Attributes == nil {
+		problem.Write(w, r, helpers.ErrNoValidFieldsToUpdate)
 		return
 	}
 
-	asset, err := domain.UpdateAsset(r.Context(), locationUUID, assetUUID, patch)
+	asset, err := h.store.UpdateAsset(r.Context(), locationUUID, assetUUID, patch)
 	if err != nil {
-		if errors.Is(err, helpers.ErrAssetDoesNotExist) {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		if errors.Is(err, helpers.ErrAssetAlreadyExists) {
-			http.Error(w, `{"error":"`+helpers.ErrAssetAlreadyExists.Error()+`"}`, http.StatusConflict)
-			return
-		}
-
-		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		problem.Write(w, r, attributesProblem(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(asset.Version))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(struct {
-		ID *uuid.UUID `json:"ID"`
-	}{
+	json.NewEncoder(w).Encode(IDResponse{
 		ID: asset.ID,
 	})
 }
@@ -2,22 +2,27 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 	"strings"
 
-	"crud/domain"
 	"crud/helpers"
 	"crud/model"
+	"crud/problem"
 
 	"github.com/google/uuid"
 )
 
-func UpdateLocation(w http.ResponseWriter, r *http.Request) {
+func (h *LocationHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	uid, err := uuid.Parse(id)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
+		problem.Write(w, r, problem.Invalid(errInvalidID))
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err))
 		return
 	}
 
@@ -32,42 +37,27 @@ func UpdateLocation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if patchReq.Name == nil && patchReq.Code == nil {
-		http.Error(w, `{"error":"`+helpers.ErrNoValidFieldsToUpdate.Error()+`"}`, http.StatusBadRequest)
+		problem.Write(w, r, helpers.ErrNoValidFieldsToUpdate)
 		return
 	}
 
 	patch := model.LocationPatch{
-		ID:   uid,
-		Name: patchReq.Name,
-		Code: patchReq.Code,
+		ID:      uid,
+		Name:    patchReq.Name,
+		Code:    patchReq.Code,
+		Version: version,
 	}
 
-	loc, err := domain.UpdateLocation(r.Context(), patch)
+	loc, err := h.store.UpdateLocation(r.Context(), patch)
 	if err != nil {
-		if errors.Is(err, helpers.ErrLocationAlreadyExists) {
-			http.Error(w, `{"error":"`+helpers.ErrLocationAlreadyExists.Error()+`"}`, http.StatusConflict)
-			return
-		}
-
-		if errors.Is(err, helpers.ErrCodeAlreadyExists) {
-			http.Error(w, `{"error":"`+helpers.ErrCodeAlreadyExists.Error()+`"}`, http.StatusConflict)
-			return
-		}
-
-		if errors.Is(err, helpers.ErrLocationDoesNotExist) {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		problem.Write(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(loc.Version))
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(struct {
-		ID *uuid.UUID `json:"ID"`
-	}{
+	json.NewEncoder(w).Encode(IDResponse{
 		ID: loc.ID,
 	})
 }
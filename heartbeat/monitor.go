@@ -0,0 +1,70 @@
+package heartbeat
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"crud/domain"
+)
+
+const (
+	DefaultTimeout  = 5 * time.Minute
+	DefaultInterval = 30 * time.Second
+)
+
+// Monitor periodically flips assets to offline when they have not sent a
+// heartbeat within Timeout.
+type Monitor struct {
+	store    domain.HeartbeatStore
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func NewMonitor(store domain.HeartbeatStore, timeout, interval time.Duration) *Monitor {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Monitor{
+		store:    store,
+		timeout:  timeout,
+		interval: interval,
+	}
+}
+
+// Run sweeps for stale assets every interval until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	slog.Info("heartbeat monitor started", "timeout", m.timeout.String(), "interval", m.interval.String())
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("heartbeat monitor stopped")
+			return
+		case <-ticker.C:
+			m.Sweep(ctx)
+		}
+	}
+}
+
+// Sweep runs a single pass and returns how many assets went offline.
+func (m *Monitor) Sweep(ctx context.Context) int {
+	ids, err := m.store.MarkStaleAssetsOffline(ctx, time.Now().UTC().Add(-m.timeout))
+	if err != nil {
+		slog.Error("heartbeat sweep failed", slog.Any("error", err))
+		return 0
+	}
+
+	if len(ids) > 0 {
+		slog.Info("assets marked offline", "count", len(ids))
+	}
+
+	return len(ids)
+}
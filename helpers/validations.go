@@ -5,8 +5,11 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"reflect"
 	"strings"
 
+	"crud/problem"
+
 	"github.com/go-playground/validator/v10"
 	"github.com/lib/pq"
 )
@@ -19,60 +22,118 @@ const (
 )
 
 var (
-	ErrLocationDoesNotExist  = errors.New("location does not exist")
-	ErrLocationAlreadyExists = errors.New("location already exists")
-	ErrCodeAlreadyExists     = errors.New("code already exists")
-	ErrAssetAlreadyExists    = errors.New("asset already exists")
-	ErrAssetDoesNotExist     = errors.New("asset does not exist")
-	ErrNoValidFieldsToUpdate = errors.New("no valid fields to update")
+	ErrLocationDoesNotExist       = errors.New("location does not exist")
+	ErrLocationAlreadyExists      = errors.New("location already exists")
+	ErrCodeAlreadyExists          = errors.New("code already exists")
+	ErrAssetAlreadyExists         = errors.New("asset already exists")
+	ErrAssetDoesNotExist          = errors.New("asset does not exist")
+	ErrNoValidFieldsToUpdate      = errors.New("no valid fields to update")
+	ErrGeofenceDoesNotExist       = errors.New("geofence does not exist")
+	ErrAPIKeyDoesNotExist         = errors.New("api key does not exist")
+	ErrAPIKeyAlreadyExists        = errors.New("api key already exists")
+	ErrInvalidCursor              = errors.New("invalid cursor")
+	ErrInvalidSort                = errors.New("invalid sort field")
+	ErrAssetAlreadyAtLocation     = errors.New("asset is already at this location")
+	ErrWebhookDoesNotExist        = errors.New("webhook does not exist")
+	ErrDeliveryDoesNotExist       = errors.New("webhook delivery does not exist")
+	ErrAlertRuleDoesNotExist      = errors.New("alert rule does not exist")
+	ErrAlertDoesNotExist          = errors.New("alert does not exist")
+	ErrAlertAlreadyResolved       = errors.New("alert is already resolved")
+	ErrVersionMismatch            = errors.New("resource has been modified")
+	ErrLocationHasAssets          = errors.New("location still has assets")
+	ErrLocationNotDeleted         = errors.New("location is not deleted")
+	ErrAssetNotDeleted            = errors.New("asset is not deleted")
+	ErrParentLocationDoesNotExist = errors.New("parent location does not exist")
+	ErrLocationHasChildren        = errors.New("location still has child locations")
+	ErrLocationCycle              = errors.New("location cannot be moved under itself or one of its descendants")
+	ErrAssetTypeDoesNotExist      = errors.New("asset type does not exist")
+	ErrAssetTypeAlreadyExists     = errors.New("asset type already exists")
+	ErrAssetTypeInUse             = errors.New("asset type is still used by assets")
+	ErrAttributesWithoutType      = errors.New("attributes require a typeID")
 )
 
 var (
 	pqErrorMap = map[string]error{
-		"locations_name_key":     ErrLocationAlreadyExists,
-		"locations_code_key":     ErrCodeAlreadyExists,
-		"assets_name_key":        ErrAssetAlreadyExists,
-		"assets_locationID_fkey": ErrLocationDoesNotExist,
+		"locations_name_key":                ErrLocationAlreadyExists,
+		"locations_code_key":                ErrCodeAlreadyExists,
+		"assets_name_key":                   ErrAssetAlreadyExists,
+		"assets_locationID_fkey":            ErrLocationDoesNotExist,
+		"telemetry_assetID_fkey":            ErrAssetDoesNotExist,
+		"asset_positions_assetID_fkey":      ErrAssetDoesNotExist,
+		"geofences_locationID_fkey":         ErrLocationDoesNotExist,
+		"api_keys_keyHash_key":              ErrAPIKeyAlreadyExists,
+		"webhook_deliveries_webhookID_fkey": ErrWebhookDoesNotExist,
+		"alert_rules_assetID_fkey":          ErrAssetDoesNotExist,
+		"alert_rules_locationID_fkey":       ErrLocationDoesNotExist,
+		"alerts_ruleID_fkey":                ErrAlertRuleDoesNotExist,
+		"alerts_assetID_fkey":               ErrAssetDoesNotExist,
+		"asset_types_name_key":              ErrAssetTypeAlreadyExists,
+		"assets_typeID_fkey":                ErrAssetTypeDoesNotExist,
 	}
 )
 
 func ValidateRequest[T any](w http.ResponseWriter, r *http.Request, req *T) error {
 	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-		http.Error(w, `{"error": "invalid JSON"}`, http.StatusBadRequest)
+		problem.Write(w, r, problem.New(http.StatusBadRequest, problem.CodeInvalidJSON, "invalid JSON"))
 		return err
 	}
 
 	if err := validate.Struct(req); err != nil {
-		slog.Error(err.Error())
+		slog.ErrorContext(r.Context(), err.Error())
+
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "invalid request")
 		if ve, ok := err.(validator.ValidationErrors); ok {
-			writeValidationErrors(w, ve)
-			return err
+			p.Detail = "one or more fields are invalid"
+			p.Fields = validationMessages(ve)
 		}
-
-		writeError(w, "validation_error", "Invalid request")
+		problem.Write(w, r, p)
 		return err
 	}
 
 	return nil
 }
 
-func writeValidationErrors(w http.ResponseWriter, errs validator.ValidationErrors) {
+// Validate runs the struct's validate tags without an HTTP round trip, for
+// payloads that arrive through other transports.
+func Validate(v any) error {
+	return validate.Struct(v)
+}
+
+// ValidationMessages returns the per-field messages for an error returned by
+// Validate, or nil if err is not a validation error.
+func ValidationMessages(err error) map[string]string {
+	var ve validator.ValidationErrors
+	if !errors.As(err, &ve) {
+		return nil
+	}
+	return validationMessages(ve)
+}
+
+func validationMessages(errs validator.ValidationErrors) map[string]string {
 	out := make(map[string]string)
 
 	for _, fe := range errs {
 		field := strings.ToLower(fe.Field())
 
 		switch fe.Tag() {
-		case "required":
+		case "required", "required_if":
 			out[field] = field + " is required"
 
 		case "len":
 			out[field] = field + " must be " + fe.Param() + " letters long"
 
 		case "min":
+			if isNumeric(fe.Kind()) {
+				out[field] = field + " must be at least " + fe.Param()
+				break
+			}
 			out[field] = field + " should have minimum " + fe.Param() + " letters"
 
 		case "max":
+			if isNumeric(fe.Kind()) {
+				out[field] = field + " must be at most " + fe.Param()
+				break
+			}
 			out[field] = field + " should have maximum " + fe.Param() + " letters"
 
 		case "uppercase":
@@ -81,28 +142,25 @@ func writeValidationErrors(w http.ResponseWriter, errs validator.ValidationError
 		case "oneof":
 			out[field] = field + " must be one of: " + fe.Param()
 
+		case "excluded_with", "excluded_unless":
+			out[field] = field + " is not allowed here"
+
 		default:
 			out[field] = field + " is invalid"
 		}
 	}
 
-	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
-		"error": out,
-	})
+	return out
 }
 
-func writeError(w http.ResponseWriter, code string, message string) {
-	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
-		"error": map[string]string{
-			code: message,
-		},
-	})
-}
-
-func writeJSON(w http.ResponseWriter, status int, body interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(body)
+func isNumeric(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
 }
 
 func HandlePostgresError(err error) error {
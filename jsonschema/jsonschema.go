@@ -0,0 +1,251 @@
+// Package jsonschema validates JSON values against a subset of JSON Schema
+// (draft 2020-12): type, enum, const, the numeric, string, array and object
+// bounds, pattern, format, properties, required, additionalProperties and
+// items. Annotations such as title and description are accepted and
+// ignored; any other keyword is rejected when the schema is compiled, so a
+// schema never silently validates less than its author expects.
+package jsonschema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Schema is a compiled schema. The zero value accepts everything.
+type Schema struct {
+	never bool // the false schema
+
+	types []string
+	enum  []any
+	konst *any
+
+	minimum, maximum                   *float64
+	exclusiveMinimum, exclusiveMaximum *float64
+	multipleOf                         *float64
+
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+	format               string
+
+	items                *Schema
+	minItems, maxItems   *int
+	uniqueItems          bool
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	minProps, maxProps   *int
+}
+
+var types = []string{"null", "boolean", "object", "array", "number", "integer", "string"}
+
+// annotations carry no validation meaning and are skipped.
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "$comment": true,
+	"title": true, "description": true, "default": true, "examples": true,
+	"deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// Compile parses a schema document. The root must be a JSON object.
+func Compile(data []byte) (*Schema, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.New("schema is not valid JSON")
+	}
+	if _, ok := doc.(map[string]any); !ok {
+		return nil, errors.New("schema must be a JSON object")
+	}
+
+	return compile(doc, "")
+}
+
+func compile(doc any, path string) (*Schema, error) {
+	if b, ok := doc.(bool); ok {
+		return &Schema{never: !b}, nil
+	}
+
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: a schema must be an object or a boolean", pointer(path))
+	}
+
+	s := &Schema{}
+
+	// sorted so that the first error reported does not depend on map order
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := m[k]
+		at := path + "/" + k
+
+		var err error
+		switch k {
+		case "type":
+			s.types, err = typeList(v, at)
+		case "enum":
+			list, ok := v.([]any)
+			if !ok || len(list) == 0 {
+				err = fmt.Errorf("%s: must be a non-empty array", pointer(at))
+			}
+			s.enum = list
+		case "const":
+			s.konst = &v
+		case "minimum":
+			s.minimum, err = number(v, at)
+		case "maximum":
+			s.maximum, err = number(v, at)
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = number(v, at)
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = number(v, at)
+		case "multipleOf":
+			s.multipleOf, err = number(v, at)
+			if err == nil && *s.multipleOf <= 0 {
+				err = fmt.Errorf("%s: must be greater than 0", pointer(at))
+			}
+		case "minLength":
+			s.minLength, err = count(v, at)
+		case "maxLength":
+			s.maxLength, err = count(v, at)
+		case "pattern":
+			p, ok := v.(string)
+			if !ok {
+				err = fmt.Errorf("%s: must be a string", pointer(at))
+				break
+			}
+			if s.pattern, err = regexp.Compile(p); err != nil {
+				err = fmt.Errorf("%s: invalid regular expression", pointer(at))
+			}
+		case "format":
+			f, ok := v.(string)
+			if !ok || formats[f] == nil {
+				err = fmt.Errorf("%s: must be one of %s", pointer(at), strings.Join(formatNames(), ", "))
+			}
+			s.format = f
+		case "items":
+			s.items, err = compile(v, at)
+		case "minItems":
+			s.minItems, err = count(v, at)
+		case "maxItems":
+			s.maxItems, err = count(v, at)
+		case "uniqueItems":
+			b, ok := v.(bool)
+			if !ok {
+				err = fmt.Errorf("%s: must be a boolean", pointer(at))
+			}
+			s.uniqueItems = b
+		case "properties":
+			props, ok := v.(map[string]any)
+			if !ok {
+				err = fmt.Errorf("%s: must be an object", pointer(at))
+				break
+			}
+			s.properties = make(map[string]*Schema, len(props))
+			for name, sub := range props {
+				if s.properties[name], err = compile(sub, at+"/"+escape(name)); err != nil {
+					break
+				}
+			}
+		case "required":
+			s.required, err = stringList(v, at)
+		case "additionalProperties":
+			s.additionalProperties, err = compile(v, at)
+		case "minProperties":
+			s.minProps, err = count(v, at)
+		case "maxProperties":
+			s.maxProps, err = count(v, at)
+		default:
+			if !annotations[k] {
+				err = fmt.Errorf("%s: unsupported keyword", pointer(at))
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func typeList(v any, at string) ([]string, error) {
+	var list []string
+	switch t := v.(type) {
+	case string:
+		list = []string{t}
+	case []any:
+		for _, e := range t {
+			name, ok := e.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: must be a string or an array of strings", pointer(at))
+			}
+			list = append(list, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s: must be a string or an array of strings", pointer(at))
+	}
+
+	for _, name := range list {
+		if !slices.Contains(types, name) {
+			return nil, fmt.Errorf("%s: %q is not a JSON type", pointer(at), name)
+		}
+	}
+
+	return list, nil
+}
+
+func stringList(v any, at string) ([]string, error) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be an array of strings", pointer(at))
+	}
+
+	out := make([]string, 0, len(list))
+	for _, e := range list {
+		s, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: must be an array of strings", pointer(at))
+		}
+		out = append(out, s)
+	}
+
+	return out, nil
+}
+
+func number(v any, at string) (*float64, error) {
+	n, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%s: must be a number", pointer(at))
+	}
+	return &n, nil
+}
+
+func count(v any, at string) (*int, error) {
+	n, ok := v.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return nil, fmt.Errorf("%s: must be a non-negative integer", pointer(at))
+	}
+	c := int(n)
+	return &c, nil
+}
+
+func pointer(path string) string {
+	if path == "" {
+		return "schema"
+	}
+	return "schema" + path
+}
+
+// escape applies JSON Pointer escaping to a property name.
+func escape(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
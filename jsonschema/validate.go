@@ -0,0 +1,245 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// formats maps the supported format names to their checks.
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		a, err := mail.ParseAddress(s)
+		return err == nil && a.Address == s
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"uuid": func(s string) bool {
+		_, err := uuid.Parse(s)
+		return err == nil && len(s) == 36
+	},
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidationError lists every violation found, keyed by the path of the
+// offending value.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	paths := make([]string, 0, len(e.Fields))
+	for path := range e.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	msgs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		msgs = append(msgs, e.Fields[path])
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks v, a value as decoded by encoding/json into an any, and
+// reports violations with paths starting at root, such as root.dims.width
+// or root.tags[2]. Only the first violation per path is kept.
+func (s *Schema) Validate(root string, v any) error {
+	fields := map[string]string{}
+	s.validate(root, v, fields)
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v any, fields map[string]string) {
+	fail := func(format string, args ...any) {
+		if _, ok := fields[path]; !ok {
+			fields[path] = path + " " + fmt.Sprintf(format, args...)
+		}
+	}
+
+	if s.never {
+		fail("is not allowed")
+		return
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return isType(v, t) }) {
+		fail("must be of type %s", strings.Join(s.types, " or "))
+		return
+	}
+
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+		fail("must be one of %s", list(s.enum))
+		return
+	}
+	if s.konst != nil && !reflect.DeepEqual(*s.konst, v) {
+		fail("must be %s", literal(*s.konst))
+		return
+	}
+
+	switch x := v.(type) {
+	case float64:
+		switch {
+		case s.minimum != nil && x < *s.minimum:
+			fail("must be at least %s", num(*s.minimum))
+		case s.maximum != nil && x > *s.maximum:
+			fail("must be at most %s", num(*s.maximum))
+		case s.exclusiveMinimum != nil && x <= *s.exclusiveMinimum:
+			fail("must be greater than %s", num(*s.exclusiveMinimum))
+		case s.exclusiveMaximum != nil && x >= *s.exclusiveMaximum:
+			fail("must be less than %s", num(*s.exclusiveMaximum))
+		case s.multipleOf != nil && !isMultiple(x, *s.multipleOf):
+			fail("must be a multiple of %s", num(*s.multipleOf))
+		}
+
+	case string:
+		n := utf8.RuneCountInString(x)
+		switch {
+		case s.minLength != nil && n < *s.minLength:
+			fail("should have minimum %d letters", *s.minLength)
+		case s.maxLength != nil && n > *s.maxLength:
+			fail("should have maximum %d letters", *s.maxLength)
+		case s.pattern != nil && !s.pattern.MatchString(x):
+			fail("must match %s", s.pattern.String())
+		case s.format != "" && !formats[s.format](x):
+			fail("must be a valid %s", s.format)
+		}
+
+	case []any:
+		switch {
+		case s.minItems != nil && len(x) < *s.minItems:
+			fail("must have at least %d items", *s.minItems)
+		case s.maxItems != nil && len(x) > *s.maxItems:
+			fail("must have at most %d items", *s.maxItems)
+		case s.uniqueItems && !unique(x):
+			fail("must not contain duplicate items")
+		}
+		if s.items != nil {
+			for i, e := range x {
+				s.items.validate(path+"["+strconv.Itoa(i)+"]", e, fields)
+			}
+		}
+
+	case map[string]any:
+		switch {
+		case s.minProps != nil && len(x) < *s.minProps:
+			fail("must have at least %d properties", *s.minProps)
+		case s.maxProps != nil && len(x) > *s.maxProps:
+			fail("must have at most %d properties", *s.maxProps)
+		}
+		for _, name := range s.required {
+			if _, ok := x[name]; !ok {
+				at := join(path, name)
+				if _, ok := fields[at]; !ok {
+					fields[at] = at + " is required"
+				}
+			}
+		}
+		for name, e := range x {
+			if sub, ok := s.properties[name]; ok {
+				sub.validate(join(path, name), e, fields)
+			} else if s.additionalProperties != nil {
+				s.additionalProperties.validate(join(path, name), e, fields)
+			}
+		}
+	}
+}
+
+func isType(v any, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "string":
+		_, ok := v.(string)
+		return ok
+	}
+	return false
+}
+
+func isMultiple(x, of float64) bool {
+	q := x / of
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+func unique(items []any) bool {
+	for i := range items {
+		for j := i + 1; j < len(items); j++ {
+			if reflect.DeepEqual(items[i], items[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func num(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func literal(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func list(values []any) string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, literal(v))
+	}
+	return strings.Join(out, ", ")
+}
@@ -2,26 +2,47 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"crud/alerts"
+	"crud/auth"
 	"crud/db"
-	"crud/middleware"
+	"crud/domain"
+	"crud/events"
+	"crud/heartbeat"
+	"crud/mqttbridge"
+	"crud/purge"
+	"crud/requestid"
 	"crud/routes"
+	"crud/stream"
+	"crud/webhook"
 
 	"github.com/joho/godotenv"
 )
 
-func init() {
+const usage = `usage:
+  %[1]s [serve] [--migrate]   run the API server, optionally migrating first
+  %[1]s migrate up            apply all pending migrations
+  %[1]s migrate down [N]      revert the last N migrations (default 1)
+  %[1]s migrate status        show the applied version and pending migrations
+`
+
+func main() {
 	// Initialize structured logging
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(requestid.NewLogHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
-	}))
+	})))
 	slog.SetDefault(logger)
 
 	// Load environment variables
@@ -29,6 +50,25 @@ func init() {
 		slog.Warn("No .env file found, using system environment variables", "error", err)
 	}
 
+	// serve is the default so existing deployments keep working
+	cmd, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "serve":
+		serve(args)
+	case "migrate":
+		migrate(args)
+	default:
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+}
+
+// connect initializes the database from DATABASE_STRING.
+func connect() {
 	connStr := os.Getenv("DATABASE_STRING")
 	if connStr == "" {
 		slog.Error("Connection string not provided")
@@ -38,22 +78,50 @@ func init() {
 	if err := db.Init(context.Background(), connStr); err != nil {
 		log.Fatalf("failed to initialize database: %v", err)
 	}
+}
+
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	migrateFirst := flags.Bool("migrate", false, "apply pending migrations before starting")
+	flags.Parse(args)
+
+	connect()
 	// db.Close() will be called during shutdown
+
+	if *migrateFirst {
+		if err := db.MigrateUp(context.Background(), db.DB); err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
+	}
 	slog.Info("Application initialized successfully")
-}
 
-func main() {
-	slog.Info("Starting Library Management Server...")
+	// Every change made through the store is published on the bus, whichever
+	// code path made it.
+	bus := events.NewBus()
+	pg := domain.NewPostgresStore(db.DB)
+	store := domain.NewPublisher(pg, bus)
 
-	router := routes.NewRouter()
+	dispatcher := webhook.NewDispatcher(pg, webhook.Config{
+		Interval: durationFromEnv("WEBHOOK_POLL_INTERVAL", webhook.DefaultInterval),
+		Timeout:  durationFromEnv("WEBHOOK_TIMEOUT", webhook.DefaultTimeout),
+	})
+	// the store queues deliveries with each change; events only wake the
+	// dispatcher so they go out without waiting for the next poll
+	bus.Subscribe(dispatcher.Notify)
 
-	router.Use(middleware.RecoverMiddleware)
-	router.Use(middleware.LoggingMiddleware)
+	evaluator := alerts.NewEvaluator(store, durationFromEnv("ALERT_EVALUATION_INTERVAL", alerts.DefaultInterval))
+	bus.Subscribe(evaluator.Handle)
+
+	hub := stream.NewHub(intFromEnv("STREAM_BUFFER_SIZE", stream.DefaultBufferSize))
+	bus.Subscribe(hub.Publish)
+
+	if err := auth.Bootstrap(context.Background(), store, os.Getenv("ADMIN_API_KEY")); err != nil {
+		log.Fatalf("failed to bootstrap admin api key: %v", err)
+	}
 
-	api := router.Group("/api/v1")
+	slog.Info("Starting Library Management Server...")
 
-	apiRoutes := routes.NewRoutes()
-	routes.AttachRoutes(api, apiRoutes)
+	router := routes.SetupRouter(store, hub, db.DB)
 
 	// Server config
 	port := os.Getenv("PORT")
@@ -68,10 +136,60 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// open event streams would otherwise hold Shutdown until it times out
+	srv.RegisterOnShutdown(hub.Close)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	// Background workers get their own context so they are stopped only
+	// after the HTTP server has drained.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	var workers sync.WaitGroup
+
+	monitor := heartbeat.NewMonitor(store,
+		durationFromEnv("HEARTBEAT_TIMEOUT", heartbeat.DefaultTimeout),
+		durationFromEnv("HEARTBEAT_CHECK_INTERVAL", heartbeat.DefaultInterval),
+	)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		monitor.Run(workerCtx)
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		dispatcher.Run(workerCtx)
+	}()
+
+	purger := purge.NewPurger(store,
+		durationFromEnv("DELETED_RETENTION", purge.DefaultRetention),
+		durationFromEnv("PURGE_INTERVAL", purge.DefaultInterval),
+	)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		purger.Run(workerCtx)
+	}()
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		evaluator.Run(workerCtx)
+	}()
+
+	if cfg, ok := mqttbridge.ConfigFromEnv(); ok {
+		bridge := mqttbridge.New(cfg, store)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			bridge.Run(workerCtx)
+		}()
+	}
+
 	go func() {
 		slog.Info("Server running", "addr", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -92,7 +210,44 @@ func main() {
 		slog.Info("server stopped gracefully")
 	}
 
+	stopWorkers()
+	workers.Wait()
+
 	// close DB
 	db.Close()
 	slog.Info("Server shutdown complete")
 }
+
+// durationFromEnv parses a Go duration (e.g. "90s", "5m") from the
+// environment, falling back to def when it is unset or invalid.
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		slog.Warn("invalid duration, using default", "key", key, "value", v, "default", def.String())
+		return def
+	}
+
+	return d
+}
+
+// intFromEnv parses a positive integer from the environment, falling back to
+// def when it is unset or invalid.
+func intFromEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		slog.Warn("invalid integer, using default", "key", key, "value", v, "default", def)
+		return def
+	}
+
+	return n
+}
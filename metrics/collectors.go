@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"runtime"
+
+	"crud/model"
+)
+
+// HTTP request metrics, labelled by route name. They are recorded by
+// middleware.Metrics.
+var (
+	HTTPRequests = NewCounterVec("http_requests_total",
+		"HTTP requests handled, by route, method and status code.",
+		"route", "method", "code")
+	HTTPRequestDuration = NewHistogramVec("http_request_duration_seconds",
+		"Time to serve HTTP requests, by route and method.",
+		DefaultBuckets, "route", "method")
+)
+
+func init() {
+	Default.Register("http_requests", HTTPRequests)
+	Default.Register("http_request_duration", HTTPRequestDuration)
+	Default.Register("runtime", Runtime())
+}
+
+// Runtime reports goroutines and heap usage.
+func Runtime() Collector {
+	return CollectorFunc(func(ctx context.Context, e *Encoder) {
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms)
+
+		e.Family("go_goroutines", "Number of goroutines that currently exist.", TypeGauge)
+		e.Sample("go_goroutines", float64(runtime.NumGoroutine()))
+		e.Family("go_memstats_heap_alloc_bytes", "Bytes of allocated heap objects.", TypeGauge)
+		e.Sample("go_memstats_heap_alloc_bytes", float64(ms.HeapAlloc))
+		e.Family("go_memstats_sys_bytes", "Bytes of memory obtained from the OS.", TypeGauge)
+		e.Sample("go_memstats_sys_bytes", float64(ms.Sys))
+	})
+}
+
+// DBStats reports the connection pool statistics of db.
+func DBStats(db *sql.DB) Collector {
+	return CollectorFunc(func(ctx context.Context, e *Encoder) {
+		s := db.Stats()
+
+		gauge := func(name, help string, v float64) {
+			e.Family(name, help, TypeGauge)
+			e.Sample(name, v)
+		}
+		counter := func(name, help string, v float64) {
+			e.Family(name, help, TypeCounter)
+			e.Sample(name, v)
+		}
+
+		gauge("db_max_open_connections", "Maximum number of open connections to the database.", float64(s.MaxOpenConnections))
+		gauge("db_open_connections", "Established connections, in use and idle.", float64(s.OpenConnections))
+		gauge("db_in_use_connections", "Connections currently in use.", float64(s.InUse))
+		gauge("db_idle_connections", "Idle connections.", float64(s.Idle))
+		counter("db_wait_count_total", "Connections waited for.", float64(s.WaitCount))
+		counter("db_wait_duration_seconds_total", "Time spent waiting for a connection.", s.WaitDuration.Seconds())
+		counter("db_max_idle_closed_total", "Connections closed due to SetMaxIdleConns.", float64(s.MaxIdleClosed))
+		counter("db_max_idle_time_closed_total", "Connections closed due to SetConnMaxIdleTime.", float64(s.MaxIdleTimeClosed))
+		counter("db_max_lifetime_closed_total", "Connections closed due to SetConnMaxLifetime.", float64(s.MaxLifetimeClosed))
+	})
+}
+
+// AssetCounter is the part of the store Assets reads.
+type AssetCounter interface {
+	CountAssets(ctx context.Context) ([]model.AssetCount, error)
+}
+
+// Assets reports the number of assets by location and status. It queries
+// the store on every scrape; if the query fails the family is left out.
+func Assets(store AssetCounter) Collector {
+	return CollectorFunc(func(ctx context.Context, e *Encoder) {
+		counts, err := store.CountAssets(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "metrics: count assets", slog.Any("error", err))
+			return
+		}
+
+		e.Family("iot_assets", "Assets by location and status.", TypeGauge)
+		for _, c := range counts {
+			e.Sample("iot_assets", float64(c.Count),
+				"location_id", c.LocationID.String(),
+				"location_code", c.LocationCode,
+				"status", string(c.Status))
+		}
+	})
+}
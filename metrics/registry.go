@@ -0,0 +1,155 @@
+// Package metrics exposes counters, histograms and gauges in the Prometheus
+// text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector writes one or more metric families when the registry is scraped.
+type Collector interface {
+	Collect(ctx context.Context, e *Encoder)
+}
+
+// CollectorFunc adapts a function to a Collector. It suits gauges that are
+// read at scrape time, such as pool statistics or row counts.
+type CollectorFunc func(ctx context.Context, e *Encoder)
+
+func (f CollectorFunc) Collect(ctx context.Context, e *Encoder) {
+	f(ctx, e)
+}
+
+// Registry holds collectors under a name. Registering a name again replaces
+// the earlier collector, so setup code can run more than once.
+type Registry struct {
+	mu         sync.RWMutex
+	names      []string
+	collectors map[string]Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+// Default is the registry served by Handler. The HTTP metrics are registered
+// on it.
+var Default = NewRegistry()
+
+func (r *Registry) Register(name string, c Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.collectors[name] = c
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.mu.RLock()
+		collectors := make([]Collector, 0, len(r.names))
+		for _, name := range r.names {
+			collectors = append(collectors, r.collectors[name])
+		}
+		r.mu.RUnlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		e := &Encoder{w: bufio.NewWriter(w)}
+		for _, c := range collectors {
+			c.Collect(req.Context(), e)
+		}
+		e.w.Flush()
+	})
+}
+
+// Handler serves the Default registry.
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// Metric types written by Encoder.Family.
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// Encoder writes metric families in the text format. Each family starts with
+// a call to Family followed by its samples.
+type Encoder struct {
+	w *bufio.Writer
+}
+
+func (e *Encoder) Family(name, help, typ string) {
+	e.w.WriteString("# HELP " + name + " " + escapeHelp(help) + "\n")
+	e.w.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+// Sample writes one value. labels alternate names and values.
+func (e *Encoder) Sample(name string, v float64, labels ...string) {
+	e.w.WriteString(name)
+	if len(labels) > 0 {
+		e.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			e.w.WriteString(labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+		}
+		e.w.WriteByte('}')
+	}
+	e.w.WriteByte(' ')
+	e.w.WriteString(formatValue(v))
+	e.w.WriteByte('\n')
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// zip pairs label names with values for Encoder.Sample.
+func zip(names, values []string, extra ...string) []string {
+	out := make([]string, 0, 2*len(names)+len(extra))
+	for i, n := range names {
+		out = append(out, n, values[i])
+	}
+	return append(out, extra...)
+}
+
+// sortedKeys returns the keys of m in order, so scrapes list series in a
+// stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
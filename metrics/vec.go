@@ -0,0 +1,148 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// labelKey joins label values into a map key. The separator cannot appear
+// in valid UTF-8 text.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	values []string
+	value  float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{
+		name:   name,
+		help:   help,
+		labels: labels,
+		series: make(map[string]*counterSeries),
+	}
+}
+
+// Add adds v, which must not be negative, to the series with the given label
+// values.
+func (c *CounterVec) Add(v float64, values ...string) {
+	if len(values) != len(c.labels) {
+		panic("metrics: " + c.name + ": wrong number of label values")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	k := labelKey(values)
+	s, ok := c.series[k]
+	if !ok {
+		s = &counterSeries{values: values}
+		c.series[k] = s
+	}
+	s.value += v
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *CounterVec) Collect(ctx context.Context, e *Encoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.Family(c.name, c.help, TypeCounter)
+	for _, k := range sortedKeys(c.series) {
+		s := c.series[k]
+		e.Sample(c.name, s.value, zip(c.labels, s.values)...)
+	}
+}
+
+// DefaultBuckets suit request latencies in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	values []string
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates a histogram with the given upper bounds, which must
+// be sorted. The +Inf bucket is implied.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+}
+
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	if len(values) != len(h.labels) {
+		panic("metrics: " + h.name + ": wrong number of label values")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := labelKey(values)
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{values: values, counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+
+	for i, ub := range h.buckets {
+		if v <= ub {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) Collect(ctx context.Context, e *Encoder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	e.Family(h.name, h.help, TypeHistogram)
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+
+		var cumulative uint64
+		for i, ub := range h.buckets {
+			cumulative += s.counts[i]
+			e.Sample(h.name+"_bucket", float64(cumulative), zip(h.labels, s.values, "le", formatValue(ub))...)
+		}
+		e.Sample(h.name+"_bucket", float64(s.count), zip(h.labels, s.values, "le", "+Inf")...)
+		e.Sample(h.name+"_sum", s.sum, zip(h.labels, s.values)...)
+		e.Sample(h.name+"_count", float64(s.count), zip(h.labels, s.values)...)
+	}
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"crud/auth"
+	"crud/helpers"
+	"crud/model"
+	"crud/problem"
+)
+
+// APIKeyAuthenticator resolves a key hash to the active key it belongs to.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, hash string) (*model.APIKey, error)
+}
+
+// AuthMiddleware reads an API key from "Authorization: Bearer <key>" or
+// "X-API-Key" and stores the matching key on the request context. Requests
+// without a key continue anonymously so public routes keep working; routes
+// that need a role enforce it with RequireRole.
+func AuthMiddleware(store APIKeyAuthenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := apiKeyFromRequest(r)
+			if raw == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, err := store.AuthenticateAPIKey(r.Context(), auth.HashKey(raw))
+			if err != nil {
+				if errors.Is(err, helpers.ErrAPIKeyDoesNotExist) {
+					unauthorized(w, r, "invalid api key")
+					return
+				}
+
+				problem.Write(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithAPIKey(r.Context(), key)))
+		})
+	}
+}
+
+// RequireRole rejects requests whose API key is missing (401) or below min
+// (403).
+func RequireRole(min model.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := auth.APIKeyFromContext(r.Context())
+			if key == nil {
+				unauthorized(w, r, "api key required")
+				return
+			}
+
+			if !key.Role.Allows(min) {
+				problem.Write(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, "requires "+string(min)+" role"))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if v := r.Header.Get("X-API-Key"); v != "" {
+		return strings.TrimSpace(v)
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+
+	return ""
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CodeUnauthorized, msg))
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"crud/metrics"
+)
+
+// Metrics records the request count and latency of one route under its
+// name.
+func Metrics(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseRecorder{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r)
+
+			metrics.HTTPRequests.Inc(route, r.Method, strconv.Itoa(rw.Status()))
+			metrics.HTTPRequestDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+		})
+	}
+}
@@ -3,6 +3,10 @@ package middleware
 import (
 	"log/slog"
 	"net/http"
+	"time"
+
+	"crud/problem"
+	"crud/requestid"
 )
 
 // RecoverMiddleware catches panics and avoids crashing the server
@@ -10,22 +14,99 @@ func RecoverMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
-				slog.Error("panic recovered", slog.Any("error", rec))
-				http.Error(w, "internal server error", http.StatusInternalServerError)
+				slog.ErrorContext(r.Context(), "panic recovered", slog.Any("error", rec))
+				problem.Write(w, r, problem.New(http.StatusInternalServerError, problem.CodeInternal, "internal server error"))
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
 
-// LoggingMiddleware logs incoming requests
+// RequestIDMiddleware propagates the client's X-Request-ID, or generates
+// one, and stores it on the request context and the response.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithContext(r.Context(), id)))
+	})
+}
+
+// LoggingMiddleware logs each request once its response is complete, with
+// the status, response size and duration. Server errors are logged at error
+// level.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		slog.Info("request",
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(rw, r)
+
+		level := slog.LevelInfo
+		if rw.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.Log(r.Context(), level, "request",
 			"method", r.Method,
 			"path", r.URL.Path,
+			"status", rw.Status(),
+			"bytes", rw.bytes,
+			"durationMs", time.Since(start).Milliseconds(),
 			"remote", r.RemoteAddr)
-
-		next.ServeHTTP(w, r)
 	})
 }
+
+// responseRecorder records the status and size of a response. It unwraps to
+// the underlying writer so http.ResponseController, and with it streaming,
+// keeps working.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *responseRecorder) Flush() {
+	rw.FlushError()
+}
+
+// FlushError is the method http.ResponseController prefers, so flush errors
+// reach streaming handlers.
+func (rw *responseRecorder) FlushError() error {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	return http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Status returns the status sent, 200 if the handler wrote nothing.
+func (rw *responseRecorder) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"crud/db"
+)
+
+// migrate runs the migrate subcommand against DATABASE_STRING.
+func migrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+
+	connect()
+	defer db.Close()
+
+	ctx := context.Background()
+
+	m, err := db.NewMigrator(db.DB)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := db.MigrateUp(ctx, db.DB); err != nil {
+			log.Fatalf("migrate up failed: %v", err)
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				log.Fatalf("invalid number of migrations to revert: %s", args[1])
+			}
+		}
+
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			log.Fatalf("migrate down failed: %v", err)
+		}
+		slog.Info("migrations reverted", "count", len(reverted))
+
+	case "status":
+		st, err := m.Status(ctx)
+		if err != nil {
+			log.Fatalf("migrate status failed: %v", err)
+		}
+
+		fmt.Printf("version: %d\n", st.Version)
+		if st.Dirty {
+			fmt.Println("dirty: true")
+		}
+		fmt.Printf("pending: %d\n", len(st.Pending))
+		for _, mig := range st.Pending {
+			fmt.Printf("  %06d_%s\n", mig.Version, mig.Name)
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, usage, os.Args[0])
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,139 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AlertRuleKind string
+
+// AlertRuleKinds lists the conditions a rule can watch. An offline rule fires
+// when an asset has been offline for at least DurationSeconds; a threshold
+// rule fires when the latest reading of Metric compares true against
+// Threshold.
+var AlertRuleKinds = struct {
+	Offline   AlertRuleKind
+	Threshold AlertRuleKind
+}{
+	Offline:   "offline",
+	Threshold: "threshold",
+}
+
+type Comparison string
+
+var Comparisons = struct {
+	GreaterThan        Comparison
+	GreaterThanOrEqual Comparison
+	LessThan           Comparison
+	LessThanOrEqual    Comparison
+}{
+	GreaterThan:        "gt",
+	GreaterThanOrEqual: "gte",
+	LessThan:           "lt",
+	LessThanOrEqual:    "lte",
+}
+
+// Holds reports whether "v <c> threshold" is true.
+func (c Comparison) Holds(v, threshold float64) bool {
+	switch c {
+	case Comparisons.GreaterThan:
+		return v > threshold
+	case Comparisons.GreaterThanOrEqual:
+		return v >= threshold
+	case Comparisons.LessThan:
+		return v < threshold
+	case Comparisons.LessThanOrEqual:
+		return v <= threshold
+	}
+	return false
+}
+
+// AlertRule opens an alert for every asset in its scope while its condition
+// holds. The scope is a single asset, the assets at a location, or every
+// asset when both AssetID and LocationID are nil.
+type AlertRule struct {
+	ID               *uuid.UUID    `json:"ID"`
+	Name             string        `json:"name"`
+	Kind             AlertRuleKind `json:"kind"`
+	AssetID          *uuid.UUID    `json:"assetID"`
+	LocationID       *uuid.UUID    `json:"locationID"`
+	Metric           string        `json:"metric,omitempty"`
+	Operator         Comparison    `json:"operator,omitempty"`
+	Threshold        *float64      `json:"threshold,omitempty"`
+	DurationSeconds  int           `json:"durationSeconds"`
+	CreatedAtUTC     time.Time     `json:"createdAtUTC"`
+	LastUpdatedAtUTC time.Time     `json:"lastUpdatedAtUTC"`
+}
+
+// InScope reports whether the rule applies to an asset at locationID.
+func (r AlertRule) InScope(assetID, locationID uuid.UUID) bool {
+	if r.AssetID != nil && *r.AssetID != assetID {
+		return false
+	}
+	if r.LocationID != nil && *r.LocationID != locationID {
+		return false
+	}
+	return true
+}
+
+type CreateAlertRuleRequest struct {
+	Name            string     `json:"name" validate:"required,max=100"`
+	Kind            string     `json:"kind" validate:"required,oneof=offline threshold"`
+	AssetID         *uuid.UUID `json:"assetID" validate:"excluded_with=LocationID"`
+	LocationID      *uuid.UUID `json:"locationID"`
+	Metric          string     `json:"metric" validate:"required_if=Kind threshold,excluded_unless=Kind threshold,max=100"`
+	Operator        string     `json:"operator" validate:"required_if=Kind threshold,excluded_unless=Kind threshold,omitempty,oneof=gt gte lt lte"`
+	Threshold       *float64   `json:"threshold" validate:"required_if=Kind threshold,excluded_unless=Kind threshold"`
+	DurationSeconds int        `json:"durationSeconds" validate:"excluded_unless=Kind offline,min=0,max=604800"`
+}
+
+type AlertStatus string
+
+var AlertStatuses = struct {
+	Open     AlertStatus
+	Resolved AlertStatus
+}{
+	Open:     "open",
+	Resolved: "resolved",
+}
+
+// Alert records one period during which a rule's condition held for an
+// asset. At most one alert per rule and asset is open at a time.
+type Alert struct {
+	ID            uuid.UUID   `json:"ID"`
+	RuleID        uuid.UUID   `json:"ruleID"`
+	AssetID       uuid.UUID   `json:"assetID"`
+	LocationID    uuid.UUID   `json:"locationID"`
+	Status        AlertStatus `json:"status"`
+	Message       string      `json:"message"`
+	Value         *float64    `json:"value"`
+	OpenedAtUTC   time.Time   `json:"openedAtUTC"`
+	ResolvedAtUTC *time.Time  `json:"resolvedAtUTC"`
+	// ResolvedBy is "evaluator" when the condition cleared, or the name of
+	// the API key that resolved the alert by hand.
+	ResolvedBy string `json:"resolvedBy,omitempty"`
+}
+
+type AlertQuery struct {
+	Status  AlertStatus
+	RuleID  *uuid.UUID
+	AssetID *uuid.UUID
+	Limit   int
+}
+
+// OfflineAsset is an asset that is currently offline and when it went
+// offline.
+type OfflineAsset struct {
+	AssetID    uuid.UUID
+	LocationID uuid.UUID
+	SinceUTC   time.Time
+}
+
+// LatestReading is the most recent reading of a metric for an asset.
+type LatestReading struct {
+	AssetID       uuid.UUID
+	LocationID    uuid.UUID
+	Value         float64
+	RecordedAtUTC time.Time
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+// Roles is a map of API key roles, from least to most privileged
+var Roles = struct {
+	Viewer   Role
+	Operator Role
+	Admin    Role
+}{
+	Viewer:   "viewer",
+	Operator: "operator",
+	Admin:    "admin",
+}
+
+var roleRank = map[Role]int{
+	Roles.Viewer:   1,
+	Roles.Operator: 2,
+	Roles.Admin:    3,
+}
+
+// Allows reports whether r is at least as privileged as min. The empty role
+// is used by public routes and is allowed for everyone.
+func (r Role) Allows(min Role) bool {
+	if min == "" {
+		return true
+	}
+	return roleRank[r] >= roleRank[min]
+}
+
+// APIKey never carries the key itself; only its SHA-256 hash is stored.
+type APIKey struct {
+	ID            *uuid.UUID `json:"ID"`
+	Name          string     `json:"name"`
+	Prefix        string     `json:"prefix"`
+	Role          Role       `json:"role"`
+	CreatedAtUTC  time.Time  `json:"createdAtUTC"`
+	LastUsedAtUTC *time.Time `json:"lastUsedAtUTC"`
+	RevokedAtUTC  *time.Time `json:"revokedAtUTC"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=100"`
+	Role string `json:"role" validate:"required,oneof=viewer operator admin"`
+}
@@ -19,22 +19,60 @@ var Statuses = struct {
 }
 
 type Asset struct {
-	ID               *uuid.UUID `json:"ID"`
-	Name             string     `json:"name"`
-	Status           Status     `json:"status"`
-	Location         string     `json:"location"`
-	LastUpdatedAtUTC time.Time  `json:"lastUpdatedAtUTC"`
-	CreatedAtUTC     time.Time  `json:"createdAtUTC"`
+	ID       *uuid.UUID `json:"ID"`
+	Name     string     `json:"name"`
+	Status   Status     `json:"status"`
+	Location string     `json:"location"`
+	TypeID   *uuid.UUID `json:"typeID,omitempty"`
+	// Attributes holds the custom fields defined by the asset's type.
+	Attributes       map[string]any `json:"attributes,omitempty"`
+	LastSeenAtUTC    *time.Time     `json:"lastSeenAtUTC"`
+	LastPosition     *Position      `json:"lastPosition"`
+	LastUpdatedAtUTC time.Time      `json:"lastUpdatedAtUTC"`
+	CreatedAtUTC     time.Time      `json:"createdAtUTC"`
+	DeletedAtUTC     *time.Time     `json:"deletedAtUTC,omitempty"`
+	Version          int            `json:"version"`
+}
+
+// PurgeResult counts the rows a purge of soft-deleted data removed.
+type PurgeResult struct {
+	Assets    int
+	Locations int
+}
+
+// AssetCount is the number of assets at a location with a given status.
+type AssetCount struct {
+	LocationID   uuid.UUID `json:"locationID"`
+	LocationCode string    `json:"locationCode"`
+	Status       Status    `json:"status"`
+	Count        int       `json:"count"`
+}
+
+// AssetDetail is an Asset with its full location in place of the location
+// name.
+type AssetDetail struct {
+	Asset
+	Location Location `json:"location"`
 }
 
 type AssetPatch struct {
-	Name   *string
-	Status *Status
+	Name   *string `json:"name,omitempty"`
+	Status *Status `json:"status,omitempty"`
+	// TypeID changes the asset's type; the attributes, new or current,
+	// must satisfy the new type's schema.
+	TypeID *uuid.UUID `json:"typeID,omitempty"`
+	// Attributes, when set, replaces all of the asset's attributes.
+	Attributes map[string]any `json:"attributes,omitempty"`
+	// Version, when set, is the version the update was based on; the
+	// update fails if the asset has changed since.
+	Version *int `json:"-"`
 }
 
 type CreateAssetRequest struct {
-	ID         *uuid.UUID `json:"ID"`
-	Name       string     `json:"name"`
-	Status     Status     `json:"status"`
-	LocationID uuid.UUID  `json:"locationID"`
+	ID         *uuid.UUID     `json:"ID"`
+	Name       string         `json:"name"`
+	Status     Status         `json:"status"`
+	LocationID uuid.UUID      `json:"locationID"`
+	TypeID     *uuid.UUID     `json:"typeID,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
 }
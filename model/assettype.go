@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AssetType describes a kind of asset, such as a GPS tag or a forklift.
+// Schema is the JSON Schema the attributes of assets of the type must
+// satisfy.
+type AssetType struct {
+	ID           *uuid.UUID      `json:"ID"`
+	Name         string          `json:"name"`
+	Schema       json.RawMessage `json:"schema"`
+	CreatedAtUTC time.Time       `json:"createdAtUTC"`
+}
+
+// AssetTypeInput is the body of POST /asset-types.
+type AssetTypeInput struct {
+	Name   string          `json:"name" validate:"required,min=3,max=50"`
+	Schema json.RawMessage `json:"schema" validate:"required"`
+}
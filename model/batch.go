@@ -0,0 +1,33 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// unmarshalOneOrMany decodes a request body that may be a single object, a
+// bare array of objects, or an object wrapping the array under key.
+func unmarshalOneOrMany[T any](data []byte, key string, out *[]T) error {
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		return json.Unmarshal(trimmed, out)
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(trimmed, &probe); err != nil {
+		return err
+	}
+
+	if raw, ok := probe[key]; ok {
+		return json.Unmarshal(raw, out)
+	}
+
+	var item T
+	if err := json.Unmarshal(trimmed, &item); err != nil {
+		return err
+	}
+	*out = []T{item}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LocationInput holds the client-supplied fields of a new location. It is
+// the body of POST /locations and part of a location import row. ParentID,
+// when set, nests the location under an existing one.
+type LocationInput struct {
+	Name     string     `json:"name" validate:"required,min=5,max=50"`
+	Code     string     `json:"code" validate:"required,len=4"`
+	ParentID *uuid.UUID `json:"parentID,omitempty"`
+}
+
+// LocationImportRow is one row of a location import. ID is the location's ID
+// in the file it came from, such as an export. The created location gets a
+// new ID, but other rows may name this one as their parent by it, so a tree
+// can be imported in one file.
+type LocationImportRow struct {
+	LocationInput
+	ID *uuid.UUID `json:"ID,omitempty"`
+}
+
+// AssetInput holds the client-supplied fields of a new asset. It is the body
+// of POST /locations/{locationID}/assets. Attributes are only allowed along
+// with a TypeID and must satisfy that type's schema.
+type AssetInput struct {
+	Name       string         `json:"name" validate:"required,min=5,max=50"`
+	Status     string         `json:"status" validate:"required,oneof=online offline"`
+	TypeID     *uuid.UUID     `json:"typeID,omitempty"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// AssetImportRow is one row of an asset import: an AssetInput plus the
+// location the asset belongs to.
+type AssetImportRow struct {
+	AssetInput
+	LocationID uuid.UUID `json:"locationID" validate:"required"`
+}
+
+// AssetExportRow is the flat representation of an asset used by exports. It
+// carries the locationID so an export can be imported again.
+type AssetExportRow struct {
+	ID               uuid.UUID      `json:"ID"`
+	Name             string         `json:"name"`
+	Status           Status         `json:"status"`
+	LocationID       uuid.UUID      `json:"locationID"`
+	TypeID           *uuid.UUID     `json:"typeID,omitempty"`
+	Attributes       map[string]any `json:"attributes,omitempty"`
+	LastSeenAtUTC    *time.Time     `json:"lastSeenAtUTC"`
+	CreatedAtUTC     time.Time      `json:"createdAtUTC"`
+	LastUpdatedAtUTC time.Time      `json:"lastUpdatedAtUTC"`
+}
+
+type ImportRowStatus string
+
+// ImportRowStatuses lists the outcomes of a single import row.
+var ImportRowStatuses = struct {
+	Created ImportRowStatus
+	Skipped ImportRowStatus
+	Failed  ImportRowStatus
+}{
+	Created: "created",
+	Skipped: "skipped",
+	Failed:  "failed",
+}
+
+// ImportRowResult reports what happened to one row of an import. Row is the
+// 1-based position of the record in the upload, not counting a CSV header.
+type ImportRowResult struct {
+	Row    int               `json:"row"`
+	Status ImportRowStatus   `json:"status"`
+	ID     *uuid.UUID        `json:"ID,omitempty"`
+	Error  string            `json:"error,omitempty"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+type ImportReport struct {
+	Created int               `json:"created"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
+
+// Add appends a row result and updates the totals.
+func (r *ImportReport) Add(row ImportRowResult) {
+	switch row.Status {
+	case ImportRowStatuses.Created:
+		r.Created++
+	case ImportRowStatuses.Skipped:
+		r.Skipped++
+	case ImportRowStatuses.Failed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GeofenceShape string
+
+// GeofenceShapes is a map of supported geofence geometries
+var GeofenceShapes = struct {
+	Circle  GeofenceShape
+	Polygon GeofenceShape
+}{
+	Circle:  "circle",
+	Polygon: "polygon",
+}
+
+type Point struct {
+	Latitude  float64 `json:"latitude" validate:"min=-90,max=90"`
+	Longitude float64 `json:"longitude" validate:"min=-180,max=180"`
+}
+
+// Geofence is the boundary attached to a location. Circles use Center and
+// RadiusMeters; polygons use Polygon, listed in order, implicitly closed.
+type Geofence struct {
+	LocationID       uuid.UUID     `json:"locationID"`
+	Shape            GeofenceShape `json:"shape"`
+	Center           *Point        `json:"center,omitempty"`
+	RadiusMeters     *float64      `json:"radiusMeters,omitempty"`
+	Polygon          []Point       `json:"polygon,omitempty"`
+	CreatedAtUTC     time.Time     `json:"createdAtUTC"`
+	LastUpdatedAtUTC time.Time     `json:"lastUpdatedAtUTC"`
+}
+
+type SetGeofenceRequest struct {
+	Shape        string   `json:"shape" validate:"required,oneof=circle polygon"`
+	Center       *Point   `json:"center" validate:"required_if=Shape circle"`
+	RadiusMeters *float64 `json:"radiusMeters" validate:"required_if=Shape circle,omitempty,gt=0"`
+	Polygon      []Point  `json:"polygon" validate:"required_if=Shape polygon,omitempty,min=3,max=500,dive"`
+}
+
+type GeofenceEventType string
+
+// GeofenceEventTypes is a map of boundary crossing directions
+var GeofenceEventTypes = struct {
+	Enter GeofenceEventType
+	Exit  GeofenceEventType
+}{
+	Enter: "enter",
+	Exit:  "exit",
+}
+
+type GeofenceEvent struct {
+	AssetID       uuid.UUID         `json:"assetID"`
+	LocationID    uuid.UUID         `json:"locationID"`
+	Event         GeofenceEventType `json:"event"`
+	Latitude      float64           `json:"latitude"`
+	Longitude     float64           `json:"longitude"`
+	OccurredAtUTC time.Time         `json:"occurredAtUTC"`
+}
+
+type GeofenceEventQuery struct {
+	From time.Time
+	To   time.Time
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// ListQuery holds the paging, sorting and filtering options shared by list
+// endpoints. Cursor is the opaque token returned as nextCursor by the
+// previous page and is only valid with the same Sort and Desc.
+type ListQuery struct {
+	Sort          string
+	Desc          bool
+	Limit         int
+	Cursor        string
+	NamePrefix    string
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	// IncludeDeleted lists soft-deleted rows along with live ones.
+	IncludeDeleted bool
+}
+
+type AssetListQuery struct {
+	ListQuery
+	Status     *Status
+	LocationID *uuid.UUID
+	// Recursive widens LocationID to the location's descendants.
+	Recursive bool
+	TypeID    *uuid.UUID
+	// Attributes matches assets whose attributes hold each of the given
+	// values.
+	Attributes map[string]any
+}
+
+type LocationListQuery struct {
+	ListQuery
+	// ParentID restricts the listing to the location's direct children.
+	ParentID *uuid.UUID
+}
+
+// Sort fields accepted by the list endpoints
+const (
+	SortByName             = "name"
+	SortByCode             = "code"
+	SortByCreatedAtUTC     = "createdAtUTC"
+	SortByLastUpdatedAtUTC = "lastUpdatedAtUTC"
+)
+
+var (
+	AssetSortFields    = []string{SortByName, SortByCreatedAtUTC, SortByLastUpdatedAtUTC}
+	LocationSortFields = []string{SortByName, SortByCode, SortByCreatedAtUTC, SortByLastUpdatedAtUTC}
+)
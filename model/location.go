@@ -6,8 +6,30 @@ type Location struct {
 	ID               *uuid.UUID `json:"ID"`
 	Name             string     `json:"name"`
 	Code             string     `json:"code"`
+	ParentID         *uuid.UUID `json:"parentID"`
 	CreatedAtUTC     string     `json:"createdAtUTC"`
 	LastUpdatedAtUTC string     `json:"lastUpdatedAtUTC"`
+	DeletedAtUTC     *string    `json:"deletedAtUTC,omitempty"`
+	Version          int        `json:"version"`
+}
+
+// CreateLocationRequest is the body of POST /locations.
+type CreateLocationRequest struct {
+	LocationInput
+}
+
+// MoveLocationRequest is the body of POST /locations/{id}/move. A null or
+// missing ParentID makes the location a top-level one. Descendants move
+// along with it.
+type MoveLocationRequest struct {
+	ParentID *uuid.UUID `json:"parentID"`
+}
+
+// LocationNode is a location together with its descendants, children sorted
+// by name.
+type LocationNode struct {
+	Location
+	Children []LocationNode `json:"children"`
 }
 
 type UpdateLocationRequest struct {
@@ -19,4 +41,7 @@ type LocationPatch struct {
 	ID   uuid.UUID
 	Name *string
 	Code *string
+	// Version, when set, is the version the update was based on; the
+	// update fails if the location has changed since.
+	Version *int
 }
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// Position is a GPS fix. Accuracy is the horizontal error radius in metres.
+type Position struct {
+	Latitude      *float64  `json:"latitude" validate:"required,min=-90,max=90"`
+	Longitude     *float64  `json:"longitude" validate:"required,min=-180,max=180"`
+	Accuracy      *float64  `json:"accuracy,omitempty" validate:"omitempty,min=0"`
+	RecordedAtUTC time.Time `json:"recordedAtUTC" validate:"required"`
+}
+
+// PositionBatch is the body of a position report. It accepts a single fix, a
+// bare array of fixes, or {"positions": [...]}.
+type PositionBatch struct {
+	Positions []Position `json:"positions" validate:"required,min=1,max=1000,dive"`
+}
+
+func (b *PositionBatch) UnmarshalJSON(data []byte) error {
+	return unmarshalOneOrMany(data, "positions", &b.Positions)
+}
+
+type TrackQuery struct {
+	From time.Time
+	To   time.Time
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StatusChangeSource string
+
+// StatusChangeSources lists what can cause an asset status transition. An
+// imported asset starts its history with an Import entry whose old and new
+// status are both the status it was imported with.
+var StatusChangeSources = struct {
+	Manual    StatusChangeSource
+	Heartbeat StatusChangeSource
+	Import    StatusChangeSource
+	// Device is a status the device reported itself, over MQTT.
+	Device StatusChangeSource
+}{
+	Manual:    "manual",
+	Heartbeat: "heartbeat",
+	Import:    "import",
+	Device:    "device",
+}
+
+type StatusChange struct {
+	AssetID      uuid.UUID          `json:"assetID"`
+	OldStatus    Status             `json:"oldStatus"`
+	NewStatus    Status             `json:"newStatus"`
+	Source       StatusChangeSource `json:"source"`
+	ChangedAtUTC time.Time          `json:"changedAtUTC"`
+}
+
+type StatusHistoryQuery struct {
+	From time.Time
+	To   time.Time
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type TelemetryReading struct {
+	Metric        string    `json:"metric" validate:"required,max=100"`
+	Value         *float64  `json:"value" validate:"required"`
+	Unit          string    `json:"unit" validate:"max=20"`
+	RecordedAtUTC time.Time `json:"recordedAtUTC" validate:"required"`
+}
+
+// TelemetryBatch is the body of a telemetry ingestion request. It accepts a
+// single reading, a bare array of readings, or {"readings": [...]}.
+type TelemetryBatch struct {
+	Readings []TelemetryReading `json:"readings" validate:"required,min=1,max=1000,dive"`
+}
+
+func (b *TelemetryBatch) UnmarshalJSON(data []byte) error {
+	return unmarshalOneOrMany(data, "readings", &b.Readings)
+}
+
+type TelemetryQuery struct {
+	From   time.Time
+	To     time.Time
+	Metric string
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AssetTransfer records an asset moving between locations. FromLocationID
+// and ToLocationID are nil once the location has been deleted.
+type AssetTransfer struct {
+	AssetID          uuid.UUID  `json:"assetID"`
+	FromLocationID   *uuid.UUID `json:"fromLocationID"`
+	ToLocationID     *uuid.UUID `json:"toLocationID"`
+	Reason           string     `json:"reason"`
+	TransferredAtUTC time.Time  `json:"transferredAtUTC"`
+}
+
+type TransferAssetRequest struct {
+	ToLocationID uuid.UUID `json:"toLocationID" validate:"required"`
+	Reason       string    `json:"reason" validate:"required,max=500"`
+}
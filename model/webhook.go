@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a subscription that receives events by HTTP POST. Events is a
+// filter of event types, where an entry may also be a family such as
+// "asset.*"; an empty filter receives everything. Secret signs deliveries and
+// is only returned when the webhook is created.
+type Webhook struct {
+	ID               *uuid.UUID `json:"ID"`
+	URL              string     `json:"url"`
+	Events           []string   `json:"events"`
+	Secret           string     `json:"secret,omitempty"`
+	CreatedAtUTC     time.Time  `json:"createdAtUTC"`
+	LastUpdatedAtUTC time.Time  `json:"lastUpdatedAtUTC"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,http_url,max=2048"`
+	Events []string `json:"events" validate:"max=32,dive,required,max=64"`
+	Secret string   `json:"secret" validate:"omitempty,min=16,max=128"`
+}
+
+type DeliveryStatus string
+
+// DeliveryStatuses lists the states of a webhook delivery. A failed attempt
+// leaves the delivery pending with a later nextAttemptAtUTC until it runs out
+// of attempts and becomes dead.
+var DeliveryStatuses = struct {
+	Pending   DeliveryStatus
+	Delivered DeliveryStatus
+	Dead      DeliveryStatus
+}{
+	Pending:   "pending",
+	Delivered: "delivered",
+	Dead:      "dead",
+}
+
+type WebhookDelivery struct {
+	ID               uuid.UUID       `json:"ID"`
+	WebhookID        uuid.UUID       `json:"webhookID"`
+	EventID          uuid.UUID       `json:"eventID"`
+	EventType        string          `json:"eventType"`
+	Payload          json.RawMessage `json:"payload"`
+	Status           DeliveryStatus  `json:"status"`
+	Attempts         int             `json:"attempts"`
+	NextAttemptAtUTC *time.Time      `json:"nextAttemptAtUTC"`
+	LastAttemptAtUTC *time.Time      `json:"lastAttemptAtUTC"`
+	ResponseStatus   *int            `json:"responseStatus"`
+	LastError        string          `json:"lastError,omitempty"`
+	CreatedAtUTC     time.Time       `json:"createdAtUTC"`
+	DeliveredAtUTC   *time.Time      `json:"deliveredAtUTC"`
+
+	// URL and Secret are filled in for the dispatcher when a delivery is
+	// claimed and never serialised.
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// DeliveryAttempt is the outcome of one attempt to send a delivery. Status is
+// Delivered, Dead, or Pending with NextAttemptAtUTC set for the retry.
+type DeliveryAttempt struct {
+	Status           DeliveryStatus
+	AttemptedAtUTC   time.Time
+	ResponseStatus   *int
+	Error            string
+	NextAttemptAtUTC *time.Time
+}
@@ -0,0 +1,139 @@
+package mqttbridge
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"crud/domain"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	connectRetryInterval = 5 * time.Second
+	disconnectQuiesce    = 250 // milliseconds
+	messageTimeout       = 10 * time.Second
+)
+
+var (
+	ErrUnknownTopic   = errors.New("unknown topic")
+	ErrInvalidPayload = errors.New("invalid payload")
+)
+
+// Store is the subset of domain.Store the bridge writes to. It is the same
+// set of functions the HTTP handlers call.
+type Store interface {
+	domain.AssetStore
+	domain.TelemetryStore
+	domain.HeartbeatStore
+	domain.PositionStore
+}
+
+// Bridge subscribes to device topics and maps each message onto the domain
+// stores:
+//
+//	assets/{assetID}/status     {"status":"online"} or a bare status
+//	assets/{assetID}/telemetry  same body as POST /assets/{assetID}/telemetry
+//	assets/{assetID}/position   same body as POST /assets/{assetID}/positions
+//	assets/{assetID}/heartbeat  payload ignored
+type Bridge struct {
+	cfg   Config
+	store Store
+}
+
+func New(cfg Config, store Store) *Bridge {
+	return &Bridge{cfg: cfg, store: store}
+}
+
+// Topics returns the subscription filters, including the configured prefix.
+func (b *Bridge) Topics() []string {
+	topics := make([]string, 0, len(topicHandlers))
+	for kind := range topicHandlers {
+		topics = append(topics, b.cfg.TopicPrefix+"assets/+/"+kind)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Run connects to the broker and processes messages until ctx is cancelled,
+// then unsubscribes and disconnects.
+func (b *Bridge) Run(ctx context.Context) {
+	opts := paho.NewClientOptions().
+		AddBroker(b.cfg.BrokerURL).
+		SetClientID(b.cfg.ClientID).
+		SetUsername(b.cfg.Username).
+		SetPassword(b.cfg.Password).
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(connectRetryInterval).
+		SetOnConnectHandler(b.subscribe).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			slog.Warn("mqtt connection lost", slog.Any("error", err))
+		})
+
+	client := paho.NewClient(opts)
+
+	slog.Info("mqtt bridge connecting", "broker", b.cfg.BrokerURL, "clientID", b.cfg.ClientID)
+
+	token := client.Connect()
+	select {
+	case <-token.Done():
+		if err := token.Error(); err != nil {
+			slog.Error("mqtt connect failed", slog.Any("error", err))
+		}
+	case <-ctx.Done():
+	}
+
+	<-ctx.Done()
+
+	if client.IsConnected() {
+		client.Unsubscribe(b.Topics()...).WaitTimeout(time.Second)
+	}
+	client.Disconnect(disconnectQuiesce)
+
+	slog.Info("mqtt bridge stopped")
+}
+
+func (b *Bridge) subscribe(client paho.Client) {
+	filters := make(map[string]byte)
+	for _, t := range b.Topics() {
+		filters[t] = b.cfg.QoS
+	}
+
+	token := client.SubscribeMultiple(filters, func(_ paho.Client, m paho.Message) {
+		ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+		defer cancel()
+
+		if err := b.HandleMessage(ctx, m.Topic(), m.Payload()); err != nil {
+			slog.Warn("mqtt message rejected", "topic", m.Topic(), slog.Any("error", err))
+		}
+	})
+
+	if token.Wait() && token.Error() != nil {
+		slog.Error("mqtt subscribe failed", slog.Any("error", token.Error()))
+		return
+	}
+
+	slog.Info("mqtt bridge subscribed", "topics", b.Topics())
+}
+
+// HandleMessage routes a single message by topic. It is independent of the
+// broker connection so tests can drive it directly.
+func (b *Bridge) HandleMessage(ctx context.Context, topic string, payload []byte) error {
+	parts := strings.Split(strings.TrimPrefix(topic, b.cfg.TopicPrefix), "/")
+	if len(parts) != 3 || parts[0] != "assets" {
+		return ErrUnknownTopic
+	}
+
+	handle, ok := topicHandlers[parts[2]]
+	if !ok {
+		return ErrUnknownTopic
+	}
+
+	return handle(ctx, b.store, parts[1], payload)
+}
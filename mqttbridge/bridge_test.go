@@ -0,0 +1,284 @@
+package mqttbridge
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"crud/domain"
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+const testPrefix = "site1/"
+
+// direct hands messages to the bridge the way the subscription callback in
+// subscribe does, skipping the broker so each topic's handling can be checked
+// synchronously. broker_test.go covers the connection itself.
+type direct struct {
+	bridge *Bridge
+}
+
+func (b direct) publish(t *testing.T, topic, payload string) error {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), messageTimeout)
+	defer cancel()
+
+	return b.bridge.HandleMessage(ctx, testPrefix+topic, []byte(payload))
+}
+
+func newTestBridge(t *testing.T) (direct, *domain.MemoryStore, uuid.UUID) {
+	t.Helper()
+
+	store := domain.NewMemoryStore()
+	ctx := context.Background()
+
+	loc := &model.Location{Name: "Warehouse", Code: "WHSE"}
+	if err := store.CreateLocation(ctx, loc); err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+
+	asset := &model.CreateAssetRequest{Name: "tracker1", Status: model.Statuses.Offline, LocationID: *loc.ID}
+	if err := store.CreateAsset(ctx, asset); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+
+	bridge := New(Config{TopicPrefix: testPrefix}, store)
+	return direct{bridge: bridge}, store, *asset.ID
+}
+
+func getAsset(t *testing.T, store *domain.MemoryStore, id uuid.UUID) model.Asset {
+	t.Helper()
+
+	locationID, err := store.GetAssetLocationID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetAssetLocationID: %v", err)
+	}
+	detail, err := store.GetAsset(context.Background(), locationID, id)
+	if err != nil {
+		t.Fatalf("GetAsset: %v", err)
+	}
+	return detail.Asset
+}
+
+func history(t *testing.T, store *domain.MemoryStore, id uuid.UUID) []model.StatusChange {
+	t.Helper()
+
+	changes, err := store.GetStatusHistory(context.Background(), id, model.StatusHistoryQuery{
+		From: time.Now().Add(-time.Hour),
+		To:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("GetStatusHistory: %v", err)
+	}
+	return changes
+}
+
+func TestTopics(t *testing.T) {
+	b := New(Config{TopicPrefix: testPrefix}, nil)
+
+	want := []string{
+		"site1/assets/+/heartbeat",
+		"site1/assets/+/position",
+		"site1/assets/+/status",
+		"site1/assets/+/telemetry",
+	}
+	got := b.Topics()
+	if len(got) != len(want) {
+		t.Fatalf("Topics() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Topics() = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	broker, store, id := newTestBridge(t)
+
+	if err := broker.publish(t, "assets/"+id.String()+"/heartbeat", ""); err != nil {
+		t.Fatalf("heartbeat: %v", err)
+	}
+
+	a := getAsset(t, store, id)
+	if a.Status != model.Statuses.Online {
+		t.Errorf("status = %s, want online", a.Status)
+	}
+	if a.LastSeenAtUTC == nil {
+		t.Error("lastSeenAtUTC not set")
+	}
+
+	// a heartbeat from an asset that is already online changes nothing the
+	// version guards
+	if err := broker.publish(t, "assets/"+id.String()+"/heartbeat", ""); err != nil {
+		t.Fatalf("second heartbeat: %v", err)
+	}
+	if again := getAsset(t, store, id); again.Version != a.Version {
+		t.Errorf("version = %d after a repeat heartbeat, want %d", again.Version, a.Version)
+	}
+
+	changes := history(t, store, id)
+	if len(changes) != 1 || changes[0].Source != model.StatusChangeSources.Heartbeat {
+		t.Errorf("history = %+v, want one heartbeat transition", changes)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	broker, store, id := newTestBridge(t)
+	topic := "assets/" + id.String() + "/status"
+
+	if err := broker.publish(t, topic, `{"status":"online"}`); err != nil {
+		t.Fatalf("online: %v", err)
+	}
+	a := getAsset(t, store, id)
+	if a.Status != model.Statuses.Online || a.LastSeenAtUTC == nil {
+		t.Errorf("after online: status = %s, lastSeenAtUTC = %v", a.Status, a.LastSeenAtUTC)
+	}
+
+	// a bare status is accepted too
+	if err := broker.publish(t, topic, `"offline"`); err != nil {
+		t.Fatalf("offline: %v", err)
+	}
+	if a := getAsset(t, store, id); a.Status != model.Statuses.Offline {
+		t.Errorf("after offline: status = %s", a.Status)
+	}
+
+	changes := history(t, store, id)
+	if len(changes) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(changes))
+	}
+	for _, c := range changes {
+		if c.Source != model.StatusChangeSources.Device {
+			t.Errorf("transition %s -> %s has source %s, want device", c.OldStatus, c.NewStatus, c.Source)
+		}
+	}
+}
+
+func TestTelemetry(t *testing.T) {
+	broker, store, id := newTestBridge(t)
+	at := time.Now().UTC().Add(-time.Minute).Truncate(time.Millisecond)
+
+	payload := `[{"metric":"temperature","value":21.5,"unit":"C","recordedAtUTC":"` + at.Format(time.RFC3339Nano) + `"},` +
+		`{"metric":"battery","value":87,"recordedAtUTC":"` + at.Format(time.RFC3339Nano) + `"}]`
+	if err := broker.publish(t, "assets/"+id.String()+"/telemetry", payload); err != nil {
+		t.Fatalf("telemetry: %v", err)
+	}
+
+	readings, err := store.GetTelemetry(context.Background(), id, model.TelemetryQuery{
+		From:   at.Add(-time.Minute),
+		To:     at.Add(time.Minute),
+		Metric: "temperature",
+	})
+	if err != nil {
+		t.Fatalf("GetTelemetry: %v", err)
+	}
+	if len(readings) != 1 || *readings[0].Value != 21.5 || readings[0].Unit != "C" {
+		t.Errorf("readings = %+v, want one temperature reading of 21.5 C", readings)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	broker, store, id := newTestBridge(t)
+	at := time.Now().UTC().Add(-time.Minute).Truncate(time.Millisecond)
+	version := getAsset(t, store, id).Version
+
+	payload := `{"latitude":52.52,"longitude":13.405,"accuracy":5,"recordedAtUTC":"` + at.Format(time.RFC3339Nano) + `"}`
+	if err := broker.publish(t, "assets/"+id.String()+"/position", payload); err != nil {
+		t.Fatalf("position: %v", err)
+	}
+
+	track, err := store.GetTrack(context.Background(), id, model.TrackQuery{
+		From: at.Add(-time.Minute),
+		To:   at.Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("GetTrack: %v", err)
+	}
+	if len(track) != 1 || *track[0].Latitude != 52.52 || *track[0].Longitude != 13.405 {
+		t.Errorf("track = %+v, want the reported fix", track)
+	}
+
+	a := getAsset(t, store, id)
+	if a.LastPosition == nil {
+		t.Error("lastPosition not set")
+	}
+	if a.Version != version {
+		t.Errorf("version = %d after a position report, want %d", a.Version, version)
+	}
+}
+
+func TestMalformedPayloads(t *testing.T) {
+	broker, store, id := newTestBridge(t)
+	asset := "assets/" + id.String()
+
+	for _, tc := range []struct {
+		name    string
+		topic   string
+		payload string
+	}{
+		{"status not JSON", asset + "/status", `{"status":`},
+		{"unknown status", asset + "/status", `{"status":"asleep"}`},
+		{"empty status", asset + "/status", ``},
+		{"telemetry not JSON", asset + "/telemetry", `temperature=21`},
+		{"telemetry missing value", asset + "/telemetry", `{"metric":"temperature","recordedAtUTC":"2026-01-01T00:00:00Z"}`},
+		{"empty telemetry batch", asset + "/telemetry", `[]`},
+		{"position not JSON", asset + "/position", `52.52,13.405`},
+		{"latitude out of range", asset + "/position", `{"latitude":91,"longitude":0,"recordedAtUTC":"2026-01-01T00:00:00Z"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := broker.publish(t, tc.topic, tc.payload); err == nil {
+				t.Error("message accepted, want an error")
+			}
+		})
+	}
+
+	// nothing was written
+	if a := getAsset(t, store, id); a.Status != model.Statuses.Offline || a.LastPosition != nil {
+		t.Errorf("asset changed by rejected messages: %+v", a)
+	}
+	if changes := history(t, store, id); len(changes) != 0 {
+		t.Errorf("history = %+v, want none", changes)
+	}
+}
+
+func TestUnknownTopics(t *testing.T) {
+	broker, _, id := newTestBridge(t)
+
+	for _, topic := range []string{
+		"assets/" + id.String() + "/battery",
+		"assets/" + id.String(),
+		"devices/" + id.String() + "/heartbeat",
+		"assets/not-a-uuid/heartbeat",
+	} {
+		if err := broker.publish(t, topic, ""); !errors.Is(err, ErrUnknownTopic) {
+			t.Errorf("%s: err = %v, want ErrUnknownTopic", topic, err)
+		}
+	}
+
+	// topics outside the prefix are not routed either
+	if err := broker.bridge.HandleMessage(context.Background(), "other/assets/"+id.String()+"/heartbeat", nil); !errors.Is(err, ErrUnknownTopic) {
+		t.Errorf("unprefixed topic: err = %v, want ErrUnknownTopic", err)
+	}
+}
+
+func TestUnknownAsset(t *testing.T) {
+	broker, _, _ := newTestBridge(t)
+	asset := "assets/" + uuid.NewString()
+	at := time.Now().UTC().Format(time.RFC3339Nano)
+
+	for topic, payload := range map[string]string{
+		asset + "/heartbeat": ``,
+		asset + "/status":    `{"status":"offline"}`,
+		asset + "/telemetry": `{"metric":"temperature","value":20,"recordedAtUTC":"` + at + `"}`,
+		asset + "/position":  `{"latitude":1,"longitude":2,"recordedAtUTC":"` + at + `"}`,
+	} {
+		if err := broker.publish(t, topic, payload); !errors.Is(err, helpers.ErrAssetDoesNotExist) {
+			t.Errorf("%s: err = %v, want ErrAssetDoesNotExist", topic, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package mqttbridge
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"crud/model"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+)
+
+const (
+	bridgeClientID = "bridge-test"
+	waitTimeout    = 5 * time.Second
+)
+
+// recorder reports the bridge client's subscriptions, unsubscriptions and
+// disconnects as the broker sees them.
+type recorder struct {
+	mqtt.HookBase
+	subscribed   chan packets.Subscriptions
+	unsubscribed chan packets.Subscriptions
+	disconnected chan struct{}
+	disconnect   sync.Once
+}
+
+func (h *recorder) ID() string { return "recorder" }
+
+func (h *recorder) Provides(b byte) bool {
+	return b == mqtt.OnSubscribed || b == mqtt.OnUnsubscribed || b == mqtt.OnDisconnect
+}
+
+func (h *recorder) OnSubscribed(cl *mqtt.Client, pk packets.Packet, reasonCodes []byte) {
+	if cl.ID == bridgeClientID {
+		h.subscribed <- pk.Filters
+	}
+}
+
+func (h *recorder) OnUnsubscribed(cl *mqtt.Client, pk packets.Packet) {
+	if cl.ID == bridgeClientID {
+		h.unsubscribed <- pk.Filters
+	}
+}
+
+func (h *recorder) OnDisconnect(cl *mqtt.Client, err error, expire bool) {
+	if cl.ID == bridgeClientID {
+		h.disconnect.Do(func() { close(h.disconnected) })
+	}
+}
+
+// startBroker runs an in-process broker on a free local port and returns its
+// address.
+func startBroker(t *testing.T) (string, *recorder) {
+	t.Helper()
+
+	server := mqtt.New(&mqtt.Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+	if err := server.AddHook(new(auth.AllowHook), nil); err != nil {
+		t.Fatalf("AddHook: %v", err)
+	}
+
+	rec := &recorder{
+		subscribed:   make(chan packets.Subscriptions, 1),
+		unsubscribed: make(chan packets.Subscriptions, 1),
+		disconnected: make(chan struct{}),
+	}
+	if err := server.AddHook(rec, nil); err != nil {
+		t.Fatalf("AddHook: %v", err)
+	}
+
+	tcp := listeners.NewTCP(listeners.Config{ID: "tcp", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatalf("AddListener: %v", err)
+	}
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	return tcp.Address(), rec
+}
+
+func receive[T any](t *testing.T, c <-chan T, what string) T {
+	t.Helper()
+
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(waitTimeout):
+		t.Fatalf("timed out waiting for %s", what)
+		panic("unreachable")
+	}
+}
+
+func filters(subs packets.Subscriptions) ([]string, []byte) {
+	var topics []string
+	var qos []byte
+	for _, s := range subs {
+		topics = append(topics, s.Filter)
+		qos = append(qos, s.Qos)
+	}
+	slices.Sort(topics)
+	return topics, qos
+}
+
+func TestRunAgainstBroker(t *testing.T) {
+	addr, rec := startBroker(t)
+	_, store, id := newTestBridge(t)
+
+	bridge := New(Config{
+		BrokerURL:   "tcp://" + addr,
+		ClientID:    bridgeClientID,
+		TopicPrefix: testPrefix,
+		QoS:         1,
+	}, store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		bridge.Run(ctx)
+		close(stopped)
+	}()
+
+	// the on-connect handler subscribes to every topic at the configured QoS
+	topics, qos := filters(receive(t, rec.subscribed, "the bridge to subscribe"))
+	if !slices.Equal(topics, bridge.Topics()) {
+		t.Errorf("subscribed to %v, want %v", topics, bridge.Topics())
+	}
+	for _, q := range qos {
+		if q != 1 {
+			t.Errorf("subscribed with QoS %v, want 1", qos)
+			break
+		}
+	}
+
+	device := paho.NewClient(paho.NewClientOptions().AddBroker("tcp://" + addr).SetClientID("device"))
+	if token := device.Connect(); !token.WaitTimeout(waitTimeout) || token.Error() != nil {
+		t.Fatalf("device connect: %v", token.Error())
+	}
+	defer device.Disconnect(0)
+
+	publish := func(kind, payload string) {
+		t.Helper()
+
+		token := device.Publish(testPrefix+"assets/"+id.String()+"/"+kind, 1, false, payload)
+		if !token.WaitTimeout(waitTimeout) || token.Error() != nil {
+			t.Fatalf("publish %s: %v", kind, token.Error())
+		}
+	}
+	waitFor := func(what string, ok func(model.Asset) bool) {
+		t.Helper()
+
+		deadline := time.Now().Add(waitTimeout)
+		for !ok(getAsset(t, store, id)) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %s", what)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	publish("heartbeat", "")
+	waitFor("the heartbeat to be recorded", func(a model.Asset) bool {
+		return a.Status == model.Statuses.Online && a.LastSeenAtUTC != nil
+	})
+
+	publish("status", `{"status":"offline"}`)
+	waitFor("the status to be recorded", func(a model.Asset) bool {
+		return a.Status == model.Statuses.Offline
+	})
+
+	// cancelling the context, as SIGTERM does in main, unsubscribes,
+	// disconnects and returns
+	cancel()
+	receive(t, stopped, "Run to return")
+
+	if topics, _ := filters(receive(t, rec.unsubscribed, "the bridge to unsubscribe")); !slices.Equal(topics, bridge.Topics()) {
+		t.Errorf("unsubscribed from %v, want %v", topics, bridge.Topics())
+	}
+	receive(t, rec.disconnected, "the bridge to disconnect")
+}
+
+func TestRunStopsWithoutBroker(t *testing.T) {
+	// nothing listens on the port, so the bridge keeps retrying the connect
+	bridge := New(Config{BrokerURL: "tcp://127.0.0.1:1", ClientID: bridgeClientID}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		bridge.Run(ctx)
+		close(stopped)
+	}()
+
+	cancel()
+	receive(t, stopped, "Run to return")
+}
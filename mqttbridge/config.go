@@ -0,0 +1,48 @@
+package mqttbridge
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// Config controls the MQTT subscriber. The bridge is enabled only when
+// MQTT_BROKER_URL is set.
+type Config struct {
+	BrokerURL   string
+	ClientID    string
+	Username    string
+	Password    string
+	TopicPrefix string
+	QoS         byte
+}
+
+// ConfigFromEnv reads the MQTT_* environment variables. The second return
+// value is false when no broker is configured.
+func ConfigFromEnv() (Config, bool) {
+	cfg := Config{
+		BrokerURL:   os.Getenv("MQTT_BROKER_URL"),
+		ClientID:    os.Getenv("MQTT_CLIENT_ID"),
+		Username:    os.Getenv("MQTT_USERNAME"),
+		Password:    os.Getenv("MQTT_PASSWORD"),
+		TopicPrefix: os.Getenv("MQTT_TOPIC_PREFIX"),
+		QoS:         1,
+	}
+
+	if cfg.BrokerURL == "" {
+		return cfg, false
+	}
+
+	if cfg.ClientID == "" {
+		cfg.ClientID = "iot-asset-tracking-" + uuid.NewString()[:8]
+	}
+
+	if v := os.Getenv("MQTT_QOS"); v != "" {
+		if q, err := strconv.Atoi(v); err == nil && q >= 0 && q <= 2 {
+			cfg.QoS = byte(q)
+		}
+	}
+
+	return cfg, true
+}
@@ -0,0 +1,117 @@
+package mqttbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"crud/helpers"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+type topicHandler func(ctx context.Context, store Store, assetID string, payload []byte) error
+
+var topicHandlers = map[string]topicHandler{
+	"status":    handleStatus,
+	"telemetry": handleTelemetry,
+	"heartbeat": handleHeartbeat,
+	"position":  handlePosition,
+}
+
+func handleStatus(ctx context.Context, store Store, assetID string, payload []byte) error {
+	id, err := uuid.Parse(assetID)
+	if err != nil {
+		return ErrUnknownTopic
+	}
+
+	req := struct {
+		Status string `json:"status" validate:"required,oneof=online offline"`
+	}{}
+
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &req); err != nil {
+			return ErrInvalidPayload
+		}
+	} else {
+		req.Status = string(bytes.Trim(trimmed, `"`))
+	}
+
+	if err := helpers.Validate(&req); err != nil {
+		return err
+	}
+
+	status := model.Status(req.Status)
+
+	// the transition is recorded as reported by the device, not as one the
+	// heartbeat below or the stale sweeper made
+	if err := store.UpdateAssetStatus(ctx, id, status, model.StatusChangeSources.Device); err != nil {
+		return err
+	}
+
+	// a device announcing itself online has also been seen
+	if status == model.Statuses.Online {
+		_, err := store.RecordHeartbeat(ctx, id)
+		return err
+	}
+
+	return nil
+}
+
+func handleTelemetry(ctx context.Context, store Store, assetID string, payload []byte) error {
+	id, err := uuid.Parse(assetID)
+	if err != nil {
+		return ErrUnknownTopic
+	}
+
+	batch := model.TelemetryBatch{}
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		return ErrInvalidPayload
+	}
+
+	if err := helpers.Validate(&batch); err != nil {
+		return err
+	}
+
+	return store.CreateTelemetry(ctx, id, batch.Readings)
+}
+
+func handlePosition(ctx context.Context, store Store, assetID string, payload []byte) error {
+	id, err := uuid.Parse(assetID)
+	if err != nil {
+		return ErrUnknownTopic
+	}
+
+	batch := model.PositionBatch{}
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		return ErrInvalidPayload
+	}
+
+	if err := helpers.Validate(&batch); err != nil {
+		return err
+	}
+
+	events, err := store.CreatePositions(ctx, id, batch.Positions)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		slog.Info("geofence crossing", "assetID", e.AssetID, "locationID", e.LocationID, "event", e.Event)
+	}
+
+	return nil
+}
+
+func handleHeartbeat(ctx context.Context, store Store, assetID string, payload []byte) error {
+	id, err := uuid.Parse(assetID)
+	if err != nil {
+		return ErrUnknownTopic
+	}
+
+	_, err = store.RecordHeartbeat(ctx, id)
+	return err
+}
@@ -0,0 +1,188 @@
+// Package openapi builds an OpenAPI 3.0 document from route metadata,
+// deriving schemas from Go types and their json and validate tags.
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"crud/problem"
+)
+
+// NoBody marks an operation that takes or returns no body, such as a
+// DELETE answered with 204.
+type NoBody struct{}
+
+// Content describes a body that is not a single JSON value, such as CSV or
+// an event stream. Schema, if set, describes one record.
+type Content struct {
+	Types  []string
+	Schema any
+}
+
+// Operation describes one endpoint. Request and Response hold a value of the
+// body type, NoBody or a Content; Status is the success status and defaults
+// to 200.
+type Operation struct {
+	ID       string
+	Method   string
+	Path     string
+	MinRole  string
+	Request  any
+	Response any
+	Status   int
+}
+
+// Check reports every operation missing metadata: all of them need a
+// Response, and POST, PUT and PATCH operations need a Request.
+func Check(ops []Operation) error {
+	var errs []error
+	seen := make(map[string]bool, len(ops))
+
+	for _, op := range ops {
+		if op.ID == "" {
+			errs = append(errs, fmt.Errorf("%s %s has no name", op.Method, op.Path))
+		} else if seen[op.ID] {
+			errs = append(errs, fmt.Errorf("%s: duplicate name", op.ID))
+		}
+		seen[op.ID] = true
+
+		if op.Response == nil {
+			errs = append(errs, fmt.Errorf("%s: no response type", op.ID))
+		}
+
+		switch op.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+			if op.Request == nil {
+				errs = append(errs, fmt.Errorf("%s: no request type", op.ID))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build returns the document for ops, served under basePath.
+func Build(title, version, basePath string, ops []Operation) Schema {
+	s := newSchemas()
+	paths := Schema{}
+
+	for _, op := range ops {
+		path := strings.ReplaceAll(op.Path, "{$}", "")
+		path = strings.ReplaceAll(path, "...}", "}")
+
+		item, ok := paths[path].(Schema)
+		if !ok {
+			item = Schema{}
+			paths[path] = item
+		}
+
+		item[strings.ToLower(op.Method)] = operation(s, op, path)
+	}
+
+	return Schema{
+		"openapi": "3.0.3",
+		"info": Schema{
+			"title":   title,
+			"version": version,
+		},
+		"servers": []Schema{{"url": basePath}},
+		"paths":   paths,
+		"components": Schema{
+			"schemas": s.components,
+			"responses": Schema{
+				"Error": Schema{
+					"description": "Error",
+					"content": Schema{
+						problem.ContentType: Schema{"schema": s.ref(reflect.TypeOf(problem.Problem{}))},
+					},
+				},
+			},
+			"securitySchemes": Schema{
+				"apiKey": Schema{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": Schema{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []Schema{{"apiKey": []string{}}, {"bearer": []string{}}},
+	}
+}
+
+func operation(s *schemas, op Operation, path string) Schema {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := Schema{"description": http.StatusText(status)}
+	if content := body(s, op.Response); content != nil {
+		success["content"] = content
+	}
+
+	out := Schema{
+		"operationId": op.ID,
+		"tags":        []string{tag(path)},
+		"responses": Schema{
+			strconv.Itoa(status): success,
+			"default":            Schema{"$ref": "#/components/responses/Error"},
+		},
+	}
+
+	if op.MinRole == "" {
+		// public; overrides the document-wide requirement
+		out["security"] = []Schema{}
+	} else {
+		out["description"] = "Requires the " + op.MinRole + " role."
+	}
+
+	var params []Schema
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		schema := Schema{"type": "string"}
+		if name := m[1]; name == "id" || strings.HasSuffix(name, "ID") {
+			schema["format"] = "uuid"
+		}
+		params = append(params, Schema{"name": m[1], "in": "path", "required": true, "schema": schema})
+	}
+	if len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if content := body(s, op.Request); content != nil {
+		out["requestBody"] = Schema{"required": true, "content": content}
+	}
+
+	return out
+}
+
+// body returns the content map for v, or nil if there is no body.
+func body(s *schemas, v any) Schema {
+	switch b := v.(type) {
+	case nil, NoBody:
+		return nil
+	case Content:
+		schema := Schema{}
+		if b.Schema != nil {
+			schema = s.of(reflect.TypeOf(b.Schema))
+		}
+
+		content := Schema{}
+		for _, t := range b.Types {
+			content[t] = Schema{"schema": schema}
+		}
+		return content
+	}
+
+	return Schema{"application/json": Schema{"schema": s.of(reflect.TypeOf(v))}}
+}
+
+// tag groups operations by the first segment of their path.
+func tag(path string) string {
+	first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return first
+}
@@ -0,0 +1,273 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is a JSON Schema object as used by OpenAPI 3.0.
+type Schema map[string]any
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	uuidType       = reflect.TypeFor[uuid.UUID]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemas converts Go types to schemas, collecting named struct types as
+// reusable components.
+type schemas struct {
+	components map[string]Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: make(map[string]Schema),
+		names:      make(map[reflect.Type]string),
+	}
+}
+
+// of returns the schema for t. Named structs are emitted once under
+// components/schemas and referenced.
+func (s *schemas) of(t reflect.Type) Schema {
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case uuidType:
+		return Schema{"type": "string", "format": "uuid"}
+	case rawMessageType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.of(t.Elem()))
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Schema{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return Schema{"type": "integer", "format": "int64"}
+	case reflect.Float32:
+		return Schema{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return Schema{"type": "number", "format": "double"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	}
+
+	// interfaces and anything else accept any JSON value
+	return Schema{}
+}
+
+func (s *schemas) ref(t reflect.Type) Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			name = pkgName(t) + name
+		}
+		s.names[t] = name
+
+		// reserve the name first so that recursive types terminate
+		s.components[name] = Schema{}
+		s.components[name] = s.object(t)
+	}
+
+	return Schema{"$ref": "#/components/schemas/" + name}
+}
+
+func pkgName(t reflect.Type) string {
+	p := t.PkgPath()
+	p = p[strings.LastIndex(p, "/")+1:]
+	if p == "" {
+		return ""
+	}
+	return strings.ToUpper(p[:1]) + p[1:]
+}
+
+// object builds an object schema from the exported fields of t, following
+// encoding/json's naming and flattening of embedded structs.
+func (s *schemas) object(t reflect.Type) Schema {
+	properties := Schema{}
+	required := []string{}
+	s.fields(t, properties, &required)
+
+	out := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+func (s *schemas) fields(t reflect.Type, properties Schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				s.fields(ft, properties, required)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := s.of(f.Type)
+		if applyValidation(schema, f.Type, f.Tag.Get("validate")) {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+}
+
+// applyValidation adds the constraints of a validate tag to schema and
+// reports whether the tag makes the field required. Rules after "dive"
+// apply to the elements of a slice.
+func applyValidation(schema Schema, t reflect.Type, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	own, elem, dive := strings.Cut(tag, ",dive")
+	elem = strings.TrimPrefix(elem, ",")
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	required := false
+	for _, rule := range strings.Split(own, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "min", "max", "len", "gt", "gte", "lt", "lte":
+			bound(schema, t, key, param)
+		case "oneof":
+			schema["enum"] = enumValues(t, strings.Fields(param))
+		case "http_url", "url":
+			schema["format"] = "uri"
+		case "email":
+			schema["format"] = "email"
+		case "uppercase":
+			schema["pattern"] = "^[^a-z]*$"
+		}
+	}
+
+	if dive && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		if items, ok := schema["items"].(Schema); ok {
+			applyValidation(items, t.Elem(), elem)
+		}
+	}
+
+	return required
+}
+
+func bound(schema Schema, t reflect.Type, key, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		switch key {
+		case "min":
+			schema["minLength"] = n
+		case "max":
+			schema["maxLength"] = n
+		case "len":
+			schema["minLength"], schema["maxLength"] = n, n
+		}
+	case reflect.Slice, reflect.Array:
+		switch key {
+		case "min":
+			schema["minItems"] = n
+		case "max":
+			schema["maxItems"] = n
+		case "len":
+			schema["minItems"], schema["maxItems"] = n, n
+		}
+	case reflect.Map:
+		switch key {
+		case "min":
+			schema["minProperties"] = n
+		case "max":
+			schema["maxProperties"] = n
+		}
+	default:
+		switch key {
+		case "min", "gte":
+			schema["minimum"] = n
+		case "max", "lte":
+			schema["maximum"] = n
+		case "gt":
+			schema["minimum"], schema["exclusiveMinimum"] = n, true
+		case "lt":
+			schema["maximum"], schema["exclusiveMaximum"] = n, true
+		case "len":
+			schema["minimum"], schema["maximum"] = n, n
+		}
+	}
+}
+
+func enumValues(t reflect.Type, values []string) []any {
+	out := make([]any, 0, len(values))
+	for _, v := range values {
+		if t.Kind() != reflect.String {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				out = append(out, n)
+				continue
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// nullable marks a schema as accepting null. OpenAPI 3.0 ignores siblings
+// of $ref, so references are wrapped in allOf.
+func nullable(schema Schema) Schema {
+	if _, ok := schema["$ref"]; ok {
+		return Schema{"allOf": []Schema{schema}, "nullable": true}
+	}
+	if len(schema) == 0 {
+		return schema
+	}
+
+	schema["nullable"] = true
+	return schema
+}
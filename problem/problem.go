@@ -0,0 +1,138 @@
+// Package problem renders API errors as RFC 7807 problem details. Sentinel
+// errors are registered with the status and code they are reported with, so
+// handlers can pass store errors straight to Write.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"crud/requestid"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Codes used by more than one package. Registered errors carry their own.
+const (
+	CodeInternal         = "internal_error"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeInvalidJSON      = "invalid_json"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeUnavailable      = "unavailable"
+)
+
+// Problem is the body of every error response. Code is stable and meant for
+// programs; Detail is for people and may change.
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Code      string            `json:"code"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"requestID,omitempty"`
+}
+
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// Invalid reports a bad query parameter, path parameter or header, using the
+// error's text as the detail. An err that already is a Problem is returned
+// unchanged.
+func Invalid(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	return New(http.StatusBadRequest, CodeInvalidParameter, err.Error())
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+type entry struct {
+	err    error
+	status int
+	code   string
+}
+
+var (
+	mu       sync.RWMutex
+	registry []entry
+)
+
+// Register reports errors matching err, with errors.Is, with status and
+// code. The error's text becomes the detail, so only register errors whose
+// text is safe to show.
+func Register(err error, status int, code string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	registry = append(registry, entry{err: err, status: status, code: code})
+}
+
+// From returns the problem for err: err itself if it is a Problem, the
+// registered status and code if it matches a registered error, and a 500
+// that hides the error's text otherwise.
+func From(err error) *Problem {
+	p, _ := lookup(err)
+	return p
+}
+
+func lookup(err error) (*Problem, bool) {
+	var p *Problem
+	if errors.As(err, &p) {
+		cp := *p
+		return &cp, true
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, e := range registry {
+		if errors.Is(err, e.err) {
+			return New(e.status, e.code, err.Error()), true
+		}
+	}
+
+	return New(http.StatusInternalServerError, CodeInternal, "internal server error"), false
+}
+
+// Write sends err as a problem response carrying the request's ID. Errors
+// that are neither problems nor registered are logged, since their text is
+// not sent.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p, known := lookup(err)
+	p.RequestID = requestid.FromContext(r.Context())
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+
+	if !known {
+		slog.ErrorContext(r.Context(), "unhandled error", slog.Any("error", err))
+	}
+
+	data, _ := json.Marshal(p)
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(data)
+}
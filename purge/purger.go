@@ -0,0 +1,71 @@
+package purge
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"crud/domain"
+	"crud/model"
+)
+
+const (
+	DefaultRetention = 30 * 24 * time.Hour
+	DefaultInterval  = time.Hour
+)
+
+// Purger periodically hard-deletes assets and locations that have been
+// soft-deleted for longer than Retention.
+type Purger struct {
+	store     domain.PurgeStore
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurger(store domain.PurgeStore, retention, interval time.Duration) *Purger {
+	if retention <= 0 {
+		retention = DefaultRetention
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Purger{
+		store:     store,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges every interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	slog.Info("purger started", "retention", p.retention.String(), "interval", p.interval.String())
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("purger stopped")
+			return
+		case <-ticker.C:
+			p.Sweep(ctx)
+		}
+	}
+}
+
+// Sweep runs a single pass and returns what it removed.
+func (p *Purger) Sweep(ctx context.Context) model.PurgeResult {
+	res, err := p.store.PurgeDeleted(ctx, time.Now().UTC().Add(-p.retention))
+	if err != nil {
+		slog.Error("purge failed", slog.Any("error", err))
+		return res
+	}
+
+	if res.Assets > 0 || res.Locations > 0 {
+		slog.Info("purged deleted rows", "assets", res.Assets, "locations", res.Locations)
+	}
+
+	return res
+}
@@ -0,0 +1,73 @@
+// Package requestid carries the ID of the HTTP request being served on its
+// context and adds it to every log record written with that context.
+package requestid
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Header is the request and response header holding the ID.
+const Header = "X-Request-ID"
+
+// maxLen bounds IDs accepted from clients so they cannot bloat the logs.
+const maxLen = 128
+
+type ctxKey struct{}
+
+// New returns a fresh request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether an ID sent by a client can be propagated: at most
+// 128 printable ASCII characters.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the request ID stored on ctx, or "" if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// LogHandler adds a requestID attribute to records logged with a context
+// that carries one, so the *Context slog functions correlate a request's
+// logs without every caller adding the ID.
+type LogHandler struct {
+	slog.Handler
+}
+
+func NewLogHandler(h slog.Handler) *LogHandler {
+	return &LogHandler{Handler: h}
+}
+
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("requestID", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *LogHandler) WithGroup(name string) slog.Handler {
+	return &LogHandler{Handler: h.Handler.WithGroup(name)}
+}
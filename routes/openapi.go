@@ -0,0 +1,49 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"crud/openapi"
+)
+
+// APIPrefix is the path every API route is mounted under.
+const APIPrefix = "/api/v1"
+
+// Operations describes the routes for the OpenAPI document.
+func (rs Routes) Operations() []openapi.Operation {
+	ops := make([]openapi.Operation, 0, len(rs))
+	for _, r := range rs {
+		ops = append(ops, openapi.Operation{
+			ID:       r.Name,
+			Method:   r.Method,
+			Path:     r.Pattern,
+			MinRole:  string(r.MinRole),
+			Request:  r.Request,
+			Response: r.Response,
+			Status:   r.Status,
+		})
+	}
+	return ops
+}
+
+// serveOpenAPI builds the document once and serves the same bytes on every
+// request.
+func serveOpenAPI(rs Routes) http.HandlerFunc {
+	doc, err := json.Marshal(openapi.Build("IoT Asset Tracking API", "1.0.0", APIPrefix, rs.Operations()))
+	if err != nil {
+		panic("routes: openapi: " + err.Error())
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(doc)
+	}
+}
+
+// bulkContent describes the bodies of the import and export endpoints,
+// which are CSV or NDJSON records of row's type.
+func bulkContent(row any) openapi.Content {
+	return openapi.Content{Types: []string{"text/csv", "application/x-ndjson"}, Schema: row}
+}
@@ -2,7 +2,10 @@ package routes
 
 import (
 	"net/http"
+	"slices"
 	"strings"
+
+	"crud/problem"
 )
 
 type Middleware func(http.Handler) http.Handler
@@ -54,33 +57,82 @@ func (r *Router) fullPattern(pattern string) string {
 	return r.prefix + pattern
 }
 
+// Handle registers handler for method on pattern. A path answers HEAD with
+// its GET handler and OPTIONS with its Allow header unless handlers are
+// registered for them; any other unregistered method gets a 405.
 func (r *Router) Handle(method, pattern string, handler http.Handler) {
 	p := r.fullPattern(pattern)
 
 	// initialize if path not registered
 	if r.routes[p] == nil {
 		r.routes[p] = make(routeMethods)
-
-		r.mux.Handle(p, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			m := r.routes[p][req.Method]
-			if m == nil {
-				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-				return
-			}
-			m.ServeHTTP(w, req)
-		}))
+		r.mux.Handle(p, r.dispatch(r.routes[p]))
 	}
 
-	// apply middlewares
-	h := handler
+	// register method-specific handler inside the map
+	r.routes[p][method] = r.wrap(handler)
+}
+
+// wrap applies the router's middlewares to h.
+func (r *Router) wrap(h http.Handler) http.Handler {
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
 		h = r.middlewares[i](h)
 	}
+	return h
+}
 
-	// register method-specific handler inside the map
-	r.routes[p][method] = h
+// dispatch picks the handler registered for the request method. Requests
+// that fall through to the OPTIONS and 405 responses still pass through the
+// middlewares, so CORS preflights and logging see them.
+func (r *Router) dispatch(methods routeMethods) http.Handler {
+	fallback := r.wrap(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Allow", methods.allow())
+		if req.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		problem.Write(w, req, problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, req.Method+" is not allowed here"))
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h := methods[req.Method]
+		if h == nil && req.Method == http.MethodHead {
+			h = methods[http.MethodGet]
+		}
+		if h == nil {
+			h = fallback
+		}
+		h.ServeHTTP(w, req)
+	})
 }
 
+// allow lists the methods for an Allow header, including the HEAD and
+// OPTIONS the router answers itself.
+func (m routeMethods) allow() string {
+	methods := make([]string, 0, len(m)+2)
+	for method := range m {
+		methods = append(methods, method)
+	}
+	if m[http.MethodGet] != nil && m[http.MethodHead] == nil {
+		methods = append(methods, http.MethodHead)
+	}
+	if m[http.MethodOptions] == nil {
+		methods = append(methods, http.MethodOptions)
+	}
+	slices.Sort(methods)
+	return strings.Join(methods, ", ")
+}
+
+// ServeHTTP answers paths that match no route with a 404 problem, passing
+// it through the router's middlewares like any other response.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.mux.Handler(req); pattern == "" {
+		r.wrap(http.HandlerFunc(notFound)).ServeHTTP(w, req)
+		return
+	}
 	r.mux.ServeHTTP(w, req)
 }
+
+func notFound(w http.ResponseWriter, req *http.Request) {
+	problem.Write(w, req, problem.New(http.StatusNotFound, problem.CodeNotFound, "no route matches "+req.URL.Path))
+}
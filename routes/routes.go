@@ -1,26 +1,78 @@
 package routes
 
 import (
+	"crud/domain"
+	"crud/events"
 	"crud/handlers"
+	"crud/middleware"
+	"crud/model"
+	"crud/openapi"
+	"crud/stream"
 	"net/http"
 )
 
 type Route struct {
-	Name        string
-	Method      string
-	Pattern     string
+	Name    string
+	Method  string
+	Pattern string
+	// MinRole is the least privileged API key role allowed to call the
+	// route. Leave it empty for public routes.
+	MinRole model.Role
+	// Request and Response hold a value of the body types, an
+	// openapi.NoBody or an openapi.Content for non-JSON bodies. They
+	// describe the route in the OpenAPI document; NewRoutes panics if a
+	// route is missing them. Status is the success status, 200 if unset.
+	Request     any
+	Response    any
+	Status      int
 	HandlerFunc http.HandlerFunc
 }
 
 type Routes []Route
 
-func NewRoutes() Routes {
+// NewRoutes returns the API routes followed by GET /openapi.json, which
+// serves the document built from their metadata. It panics if a route is
+// missing that metadata, the same way ServeMux panics on a bad pattern.
+func NewRoutes(store domain.Store, hub *stream.Hub) Routes {
+	rs := apiRoutes(store, hub)
+	rs = append(rs, Route{
+		Name:     "GetOpenAPI",
+		Method:   http.MethodGet,
+		Pattern:  "/openapi.json",
+		Response: openapi.Content{Types: []string{"application/json"}},
+	})
+
+	if err := openapi.Check(rs.Operations()); err != nil {
+		panic("routes: " + err.Error())
+	}
+	rs[len(rs)-1].HandlerFunc = serveOpenAPI(rs)
+
+	return rs
+}
+
+func apiRoutes(store domain.Store, hub *stream.Hub) Routes {
+	locations := handlers.NewLocationHandler(store)
+	assets := handlers.NewAssetHandler(store)
+	assetTypes := handlers.NewAssetTypeHandler(store)
+	transfers := handlers.NewTransferHandler(store)
+	bulk := handlers.NewBulkHandler(store)
+	telemetry := handlers.NewTelemetryHandler(store)
+	heartbeats := handlers.NewHeartbeatHandler(store)
+	history := handlers.NewStatusHistoryHandler(store)
+	positions := handlers.NewPositionHandler(store)
+	geofences := handlers.NewGeofenceHandler(store)
+	apiKeys := handlers.NewAPIKeyHandler(store)
+	webhooks := handlers.NewWebhookHandler(store)
+	streams := handlers.NewStreamHandler(hub)
+	alerts := handlers.NewAlertHandler(store)
+
 	return Routes{
 		// Health Check
 		{
-			Name:    "HealthCheck",
-			Method:  http.MethodGet,
-			Pattern: "/health",
+			Name:     "HealthCheck",
+			Method:   http.MethodGet,
+			Pattern:  "/health",
+			Response: openapi.Content{Types: []string{"text/plain"}},
 			HandlerFunc: func(w http.ResponseWriter, r *http.Request) {
 				w.WriteHeader(http.StatusOK)
 				w.Write([]byte("ok"))
@@ -31,62 +83,498 @@ func NewRoutes() Routes {
 			Name:        "CreateLocation",
 			Method:      http.MethodPost,
 			Pattern:     "/locations",
-			HandlerFunc: handlers.CreateLocation,
+			MinRole:     model.Roles.Operator,
+			Request:     model.CreateLocationRequest{},
+			Response:    handlers.IDResponse{},
+			Status:      http.StatusCreated,
+			HandlerFunc: locations.CreateLocation,
 		},
 		{
 			Name:        "GetLocation",
 			Method:      http.MethodGet,
 			Pattern:     "/locations",
-			HandlerFunc: handlers.GetLocation,
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.LocationListResponse{},
+			HandlerFunc: locations.GetLocation,
+		},
+		{
+			Name:        "ImportLocations",
+			Method:      http.MethodPost,
+			Pattern:     "/locations/import",
+			MinRole:     model.Roles.Operator,
+			Request:     bulkContent(model.LocationImportRow{}),
+			Response:    model.ImportReport{},
+			HandlerFunc: bulk.ImportLocations,
+		},
+		{
+			Name:        "ExportLocations",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/export",
+			MinRole:     model.Roles.Viewer,
+			Response:    bulkContent(model.Location{}),
+			HandlerFunc: bulk.ExportLocations,
+		},
+		{
+			Name:        "GetLocationByID",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{id}",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.LocationResponse{},
+			HandlerFunc: locations.GetLocationByID,
 		},
 		{
 			Name:        "UpdateLocation",
 			Method:      http.MethodPatch,
 			Pattern:     "/locations/{id}",
-			HandlerFunc: handlers.UpdateLocation,
+			MinRole:     model.Roles.Operator,
+			Request:     model.UpdateLocationRequest{},
+			Response:    handlers.IDResponse{},
+			HandlerFunc: locations.UpdateLocation,
 		},
 		{
 			Name:        "DeleteLocation",
 			Method:      http.MethodDelete,
 			Pattern:     "/locations/{id}",
-			HandlerFunc: handlers.DeleteLocation,
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: locations.DeleteLocation,
+		},
+		{
+			Name:        "RestoreLocation",
+			Method:      http.MethodPost,
+			Pattern:     "/locations/{id}/restore",
+			MinRole:     model.Roles.Admin,
+			Request:     openapi.NoBody{},
+			Response:    handlers.IDResponse{},
+			HandlerFunc: locations.RestoreLocation,
+		},
+		{
+			Name:        "MoveLocation",
+			Method:      http.MethodPost,
+			Pattern:     "/locations/{id}/move",
+			MinRole:     model.Roles.Operator,
+			Request:     model.MoveLocationRequest{},
+			Response:    handlers.IDResponse{},
+			HandlerFunc: locations.MoveLocation,
+		},
+		{
+			Name:        "GetLocationChildren",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{id}/children",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.LocationListResponse{},
+			HandlerFunc: locations.GetLocationChildren,
+		},
+		{
+			Name:        "GetLocationAncestors",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{id}/ancestors",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.LocationAncestorsResponse{},
+			HandlerFunc: locations.GetLocationAncestors,
+		},
+		{
+			Name:        "GetLocationSubtree",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{id}/subtree",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.LocationSubtreeResponse{},
+			HandlerFunc: locations.GetLocationSubtree,
 		},
 		// Assets
 		{
 			Name:        "CreateAsset",
 			Method:      http.MethodPost,
 			Pattern:     "/locations/{locationID}/assets",
-			HandlerFunc: handlers.CreateAsset,
+			MinRole:     model.Roles.Operator,
+			Request:     model.AssetInput{},
+			Response:    handlers.IDResponse{},
+			Status:      http.StatusCreated,
+			HandlerFunc: assets.CreateAsset,
 		},
 		{
 			Name:        "GetAssetsByLocation",
 			Method:      http.MethodGet,
 			Pattern:     "/locations/{locationID}/assets",
-			HandlerFunc: handlers.GetAssetsByLocation,
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.AssetListResponse{},
+			HandlerFunc: assets.GetAssetsByLocation,
 		},
 		{
 			Name:        "GetAssets",
 			Method:      http.MethodGet,
 			Pattern:     "/assets",
-			HandlerFunc: handlers.GetAssets,
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.AssetListResponse{},
+			HandlerFunc: assets.GetAssets,
+		},
+		{
+			Name:        "ImportAssets",
+			Method:      http.MethodPost,
+			Pattern:     "/assets/import",
+			MinRole:     model.Roles.Operator,
+			Request:     bulkContent(model.AssetImportRow{}),
+			Response:    model.ImportReport{},
+			HandlerFunc: bulk.ImportAssets,
+		},
+		{
+			Name:        "ExportAssets",
+			Method:      http.MethodGet,
+			Pattern:     "/assets/export",
+			MinRole:     model.Roles.Viewer,
+			Response:    bulkContent(model.AssetExportRow{}),
+			HandlerFunc: bulk.ExportAssets,
+		},
+		{
+			Name:        "GetAsset",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{locationID}/assets/{assetID}",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.AssetResponse{},
+			HandlerFunc: assets.GetAsset,
 		},
 		{
 			Name:        "UpdateAssets",
 			Method:      http.MethodPatch,
 			Pattern:     "/locations/{locationID}/assets/{assetID}",
-			HandlerFunc: handlers.UpdateAsset,
+			MinRole:     model.Roles.Operator,
+			Request:     model.AssetPatch{},
+			Response:    handlers.IDResponse{},
+			HandlerFunc: assets.UpdateAsset,
 		},
 		{
 			Name:        "DeleteAsset",
 			Method:      http.MethodDelete,
 			Pattern:     "/locations/{locationID}/assets/{assetID}",
-			HandlerFunc: handlers.DeleteAsset,
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: assets.DeleteAsset,
+		},
+		{
+			Name:        "RestoreAsset",
+			Method:      http.MethodPost,
+			Pattern:     "/locations/{locationID}/assets/{assetID}/restore",
+			MinRole:     model.Roles.Admin,
+			Request:     openapi.NoBody{},
+			Response:    handlers.IDResponse{},
+			HandlerFunc: assets.RestoreAsset,
+		},
+		{
+			Name:        "TransferAsset",
+			Method:      http.MethodPost,
+			Pattern:     "/locations/{locationID}/assets/{assetID}/transfer",
+			MinRole:     model.Roles.Operator,
+			Request:     model.TransferAssetRequest{},
+			Response:    handlers.TransferResponse{},
+			HandlerFunc: transfers.TransferAsset,
+		},
+		{
+			Name:        "GetAssetTransfers",
+			Method:      http.MethodGet,
+			Pattern:     "/assets/{assetID}/transfers",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.TransferListResponse{},
+			HandlerFunc: transfers.GetAssetTransfers,
+		},
+		{
+			Name:        "RecordHeartbeat",
+			Method:      http.MethodPost,
+			Pattern:     "/assets/{assetID}/heartbeat",
+			MinRole:     model.Roles.Operator,
+			Request:     openapi.NoBody{},
+			Response:    handlers.HeartbeatResponse{},
+			HandlerFunc: heartbeats.RecordHeartbeat,
+		},
+		{
+			Name:        "GetStatusHistory",
+			Method:      http.MethodGet,
+			Pattern:     "/assets/{assetID}/history",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.StatusHistoryResponse{},
+			HandlerFunc: history.GetStatusHistory,
+		},
+		// Asset types
+		{
+			Name:        "CreateAssetType",
+			Method:      http.MethodPost,
+			Pattern:     "/asset-types",
+			MinRole:     model.Roles.Admin,
+			Request:     model.AssetTypeInput{},
+			Response:    model.AssetType{},
+			Status:      http.StatusCreated,
+			HandlerFunc: assetTypes.CreateAssetType,
+		},
+		{
+			Name:        "GetAssetTypes",
+			Method:      http.MethodGet,
+			Pattern:     "/asset-types",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.AssetTypeListResponse{},
+			HandlerFunc: assetTypes.GetAssetTypes,
+		},
+		{
+			Name:        "GetAssetType",
+			Method:      http.MethodGet,
+			Pattern:     "/asset-types/{id}",
+			MinRole:     model.Roles.Viewer,
+			Response:    model.AssetType{},
+			HandlerFunc: assetTypes.GetAssetType,
+		},
+		{
+			Name:        "DeleteAssetType",
+			Method:      http.MethodDelete,
+			Pattern:     "/asset-types/{id}",
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: assetTypes.DeleteAssetType,
+		},
+		// Live updates
+		{
+			Name:        "Stream",
+			Method:      http.MethodGet,
+			Pattern:     "/stream",
+			MinRole:     model.Roles.Viewer,
+			Response:    openapi.Content{Types: []string{"text/event-stream"}, Schema: events.Event{}},
+			HandlerFunc: streams.Stream,
+		},
+		// Telemetry
+		{
+			Name:        "CreateTelemetry",
+			Method:      http.MethodPost,
+			Pattern:     "/assets/{assetID}/telemetry",
+			MinRole:     model.Roles.Operator,
+			Request:     model.TelemetryBatch{},
+			Response:    handlers.TelemetryAcceptedResponse{},
+			Status:      http.StatusCreated,
+			HandlerFunc: telemetry.CreateTelemetry,
+		},
+		{
+			Name:        "GetTelemetry",
+			Method:      http.MethodGet,
+			Pattern:     "/assets/{assetID}/telemetry",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.TelemetryResponse{},
+			HandlerFunc: telemetry.GetTelemetry,
+		},
+		// Positions
+		{
+			Name:        "CreatePositions",
+			Method:      http.MethodPost,
+			Pattern:     "/assets/{assetID}/positions",
+			MinRole:     model.Roles.Operator,
+			Request:     model.PositionBatch{},
+			Response:    handlers.PositionsAcceptedResponse{},
+			Status:      http.StatusCreated,
+			HandlerFunc: positions.CreatePositions,
+		},
+		{
+			Name:        "GetTrack",
+			Method:      http.MethodGet,
+			Pattern:     "/assets/{assetID}/track",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.TrackResponse{},
+			HandlerFunc: positions.GetTrack,
+		},
+		// Geofences
+		{
+			Name:        "SetGeofence",
+			Method:      http.MethodPut,
+			Pattern:     "/locations/{locationID}/geofence",
+			MinRole:     model.Roles.Operator,
+			Request:     model.SetGeofenceRequest{},
+			Response:    model.Geofence{},
+			HandlerFunc: geofences.SetGeofence,
+		},
+		{
+			Name:        "GetGeofence",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{locationID}/geofence",
+			MinRole:     model.Roles.Viewer,
+			Response:    model.Geofence{},
+			HandlerFunc: geofences.GetGeofence,
+		},
+		{
+			Name:        "DeleteGeofence",
+			Method:      http.MethodDelete,
+			Pattern:     "/locations/{locationID}/geofence",
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: geofences.DeleteGeofence,
+		},
+		{
+			Name:        "GetLocationGeofenceEvents",
+			Method:      http.MethodGet,
+			Pattern:     "/locations/{locationID}/geofence-events",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.GeofenceEventListResponse{},
+			HandlerFunc: geofences.GetLocationGeofenceEvents,
+		},
+		{
+			Name:        "GetAssetGeofenceEvents",
+			Method:      http.MethodGet,
+			Pattern:     "/assets/{assetID}/geofence-events",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.GeofenceEventListResponse{},
+			HandlerFunc: geofences.GetAssetGeofenceEvents,
+		},
+		// Alerts
+		{
+			Name:        "CreateAlertRule",
+			Method:      http.MethodPost,
+			Pattern:     "/alert-rules",
+			MinRole:     model.Roles.Operator,
+			Request:     model.CreateAlertRuleRequest{},
+			Response:    model.AlertRule{},
+			Status:      http.StatusCreated,
+			HandlerFunc: alerts.CreateAlertRule,
+		},
+		{
+			Name:        "GetAlertRules",
+			Method:      http.MethodGet,
+			Pattern:     "/alert-rules",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.AlertRuleListResponse{},
+			HandlerFunc: alerts.GetAlertRules,
+		},
+		{
+			Name:        "GetAlertRule",
+			Method:      http.MethodGet,
+			Pattern:     "/alert-rules/{id}",
+			MinRole:     model.Roles.Viewer,
+			Response:    model.AlertRule{},
+			HandlerFunc: alerts.GetAlertRule,
+		},
+		{
+			Name:        "DeleteAlertRule",
+			Method:      http.MethodDelete,
+			Pattern:     "/alert-rules/{id}",
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: alerts.DeleteAlertRule,
+		},
+		{
+			Name:        "GetAlerts",
+			Method:      http.MethodGet,
+			Pattern:     "/alerts",
+			MinRole:     model.Roles.Viewer,
+			Response:    handlers.AlertListResponse{},
+			HandlerFunc: alerts.GetAlerts,
+		},
+		{
+			Name:        "GetAlert",
+			Method:      http.MethodGet,
+			Pattern:     "/alerts/{id}",
+			MinRole:     model.Roles.Viewer,
+			Response:    model.Alert{},
+			HandlerFunc: alerts.GetAlert,
+		},
+		{
+			Name:        "ResolveAlert",
+			Method:      http.MethodPost,
+			Pattern:     "/alerts/{id}/resolve",
+			MinRole:     model.Roles.Operator,
+			Request:     openapi.NoBody{},
+			Response:    model.Alert{},
+			HandlerFunc: alerts.ResolveAlert,
+		},
+		// API keys
+		{
+			Name:        "CreateAPIKey",
+			Method:      http.MethodPost,
+			Pattern:     "/api-keys",
+			MinRole:     model.Roles.Admin,
+			Request:     model.CreateAPIKeyRequest{},
+			Response:    handlers.APIKeyCreatedResponse{},
+			Status:      http.StatusCreated,
+			HandlerFunc: apiKeys.CreateAPIKey,
+		},
+		{
+			Name:        "GetAPIKeys",
+			Method:      http.MethodGet,
+			Pattern:     "/api-keys",
+			MinRole:     model.Roles.Admin,
+			Response:    handlers.APIKeyListResponse{},
+			HandlerFunc: apiKeys.GetAPIKeys,
+		},
+		{
+			Name:        "RevokeAPIKey",
+			Method:      http.MethodDelete,
+			Pattern:     "/api-keys/{id}",
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: apiKeys.RevokeAPIKey,
+		},
+		// Webhooks
+		{
+			Name:        "CreateWebhook",
+			Method:      http.MethodPost,
+			Pattern:     "/webhooks",
+			MinRole:     model.Roles.Admin,
+			Request:     model.CreateWebhookRequest{},
+			Response:    model.Webhook{},
+			Status:      http.StatusCreated,
+			HandlerFunc: webhooks.CreateWebhook,
+		},
+		{
+			Name:        "GetWebhooks",
+			Method:      http.MethodGet,
+			Pattern:     "/webhooks",
+			MinRole:     model.Roles.Admin,
+			Response:    handlers.WebhookListResponse{},
+			HandlerFunc: webhooks.GetWebhooks,
+		},
+		{
+			Name:        "GetWebhook",
+			Method:      http.MethodGet,
+			Pattern:     "/webhooks/{id}",
+			MinRole:     model.Roles.Admin,
+			Response:    model.Webhook{},
+			HandlerFunc: webhooks.GetWebhook,
+		},
+		{
+			Name:        "DeleteWebhook",
+			Method:      http.MethodDelete,
+			Pattern:     "/webhooks/{id}",
+			MinRole:     model.Roles.Admin,
+			Response:    openapi.NoBody{},
+			Status:      http.StatusNoContent,
+			HandlerFunc: webhooks.DeleteWebhook,
+		},
+		{
+			Name:        "GetWebhookDeliveries",
+			Method:      http.MethodGet,
+			Pattern:     "/webhooks/{id}/deliveries",
+			MinRole:     model.Roles.Admin,
+			Response:    handlers.DeliveryListResponse{},
+			HandlerFunc: webhooks.GetWebhookDeliveries,
+		},
+		{
+			Name:        "RedeliverWebhook",
+			Method:      http.MethodPost,
+			Pattern:     "/webhooks/{id}/deliveries/{deliveryID}/redeliver",
+			MinRole:     model.Roles.Admin,
+			Request:     openapi.NoBody{},
+			Response:    openapi.NoBody{},
+			Status:      http.StatusAccepted,
+			HandlerFunc: webhooks.RedeliverWebhook,
 		},
 	}
 }
 
 func AttachRoutes(router *Router, routes Routes) {
 	for _, route := range routes {
-		router.Handle(route.Method, route.Pattern, route.HandlerFunc)
+		var h http.Handler = route.HandlerFunc
+		if route.MinRole != "" {
+			h = middleware.RequireRole(route.MinRole)(h)
+		}
+		h = middleware.Metrics(route.Name)(h)
+
+		router.Handle(route.Method, route.Pattern, h)
 	}
 }
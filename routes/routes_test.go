@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"crud/domain"
+	"crud/openapi"
+	"crud/stream"
+)
+
+func TestRoutesHaveSchemaMetadata(t *testing.T) {
+	rs := NewRoutes(domain.NewMemoryStore(), stream.NewHub(0))
+
+	if err := openapi.Check(rs.Operations()); err != nil {
+		t.Fatalf("routes are missing OpenAPI metadata:\n%v", err)
+	}
+}
+
+func TestCheckRejectsRoutesWithoutMetadata(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		route Route
+		want  []string
+	}{
+		{
+			name:  "post without request or response",
+			route: Route{Name: "CreateThing", Method: http.MethodPost, Pattern: "/things"},
+			want:  []string{"CreateThing: no request type", "CreateThing: no response type"},
+		},
+		{
+			name:  "get without response",
+			route: Route{Name: "GetThing", Method: http.MethodGet, Pattern: "/things/{id}"},
+			want:  []string{"GetThing: no response type"},
+		},
+		{
+			name:  "patch without request",
+			route: Route{Name: "UpdateThing", Method: http.MethodPatch, Pattern: "/things/{id}", Response: openapi.NoBody{}},
+			want:  []string{"UpdateThing: no request type"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := openapi.Check(Routes{tc.route}.Operations())
+			if err == nil {
+				t.Fatal("Check() = nil, want an error")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Check() = %q, want it to mention %q", err, want)
+				}
+			}
+		})
+	}
+}
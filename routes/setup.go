@@ -1,22 +1,45 @@
 package routes
 
 import (
+	"crud/domain"
+	"crud/metrics"
 	"crud/middleware"
+	"crud/model"
+	"crud/stream"
+	"database/sql"
+	"net/http"
 )
 
-func SetupRouter() *Router {
+// SetupRouter builds the router served by both the server and the
+// serverless entry point, and registers their metrics collectors. pool is
+// the store's connection pool; its statistics are left out when it is nil.
+func SetupRouter(store domain.Store, hub *stream.Hub, pool *sql.DB) *Router {
 	router := NewRouter()
 
 	// middlewares
-	router.Use(middleware.RecoverMiddleware)
+	router.Use(middleware.RequestIDMiddleware)
 	router.Use(middleware.LoggingMiddleware)
+	router.Use(middleware.RecoverMiddleware)
+	if cfg, ok := middleware.CORSConfigFromEnv(); ok {
+		router.Use(middleware.CORS(cfg))
+	}
+	router.Use(middleware.AuthMiddleware(store))
 
 	// group
-	api := router.Group("/api/v1")
+	api := router.Group(APIPrefix)
 
 	// routes
-	apiRoutes := NewRoutes()
+	apiRoutes := NewRoutes(store, hub)
 	AttachRoutes(api, apiRoutes)
 
+	// metrics are served outside the API prefix, where Prometheus expects
+	// them. They expose asset counts and pool statistics, so the scraper
+	// needs a viewer API key, sent as a bearer token.
+	metrics.Default.Register("assets", metrics.Assets(store))
+	if pool != nil {
+		metrics.Default.Register("db", metrics.DBStats(pool))
+	}
+	router.Handle(http.MethodGet, "/metrics", middleware.RequireRole(model.Roles.Viewer)(metrics.Handler()))
+
 	return router
 }
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"crud/auth"
+	"crud/domain"
+	"crud/model"
+	"crud/stream"
+)
+
+func get(t *testing.T, h http.Handler, path, key string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func createKey(t *testing.T, store domain.Store, role model.Role) string {
+	t.Helper()
+
+	key, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	if err := store.CreateAPIKey(context.Background(), &model.APIKey{Name: "scraper", Prefix: prefix, Role: role}, hash); err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return key
+}
+
+func TestMetrics(t *testing.T) {
+	store := domain.NewMemoryStore()
+	router := SetupRouter(store, stream.NewHub(0), nil)
+	key := createKey(t, store, model.Roles.Viewer)
+
+	if res := get(t, router, APIPrefix+"/health", ""); res.StatusCode != http.StatusOK {
+		t.Fatalf("GET /health = %d, want 200", res.StatusCode)
+	}
+
+	res := get(t, router, "/metrics", key)
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("GET /metrics = %d, want 200", res.StatusCode)
+	}
+	if ct := res.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the text exposition format", ct)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	text := string(body)
+
+	for _, want := range []string{
+		"# HELP http_requests_total ",
+		"# TYPE http_requests_total counter\n",
+		`http_requests_total{route="HealthCheck",method="GET",code="200"} `,
+		"# TYPE http_request_duration_seconds histogram\n",
+		`http_request_duration_seconds_bucket{route="HealthCheck",method="GET",le="+Inf"} `,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics are missing %q", want)
+		}
+	}
+}
+
+func TestMetricsRequireAPIKey(t *testing.T) {
+	router := SetupRouter(domain.NewMemoryStore(), stream.NewHub(0), nil)
+
+	if res := get(t, router, "/metrics", ""); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous GET /metrics = %d, want 401", res.StatusCode)
+	}
+	if res := get(t, router, "/metrics", "not-a-key"); res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /metrics with an unknown key = %d, want 401", res.StatusCode)
+	}
+}
@@ -0,0 +1,48 @@
+package stream
+
+import (
+	"crud/events"
+	"crud/model"
+
+	"github.com/google/uuid"
+)
+
+// Filter selects the events a subscriber receives. Each non-empty field
+// narrows the selection; an event must match all of them.
+type Filter struct {
+	Types       []string
+	AssetIDs    []uuid.UUID
+	LocationIDs []uuid.UUID
+}
+
+func (f Filter) Match(e events.Event) bool {
+	if !events.Match(f.Types, e.Type) {
+		return false
+	}
+	if len(f.AssetIDs) > 0 && !containsID(f.AssetIDs, e.AssetID) {
+		return false
+	}
+	if len(f.LocationIDs) > 0 && !containsID(f.LocationIDs, e.LocationID) {
+		// a transfer is also news to the location the asset left
+		t, ok := e.Data.(model.AssetTransfer)
+		if !ok || !containsID(f.LocationIDs, t.FromLocationID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsID(ids []uuid.UUID, id *uuid.UUID) bool {
+	if id == nil {
+		return false
+	}
+
+	for _, v := range ids {
+		if v == *id {
+			return true
+		}
+	}
+
+	return false
+}
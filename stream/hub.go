@@ -0,0 +1,177 @@
+// Package stream keeps a bounded, sequenced history of published events and
+// fans them out to live subscribers, such as Server-Sent Events clients.
+package stream
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"crud/events"
+)
+
+const (
+	DefaultBufferSize = 1024
+
+	// subscriberBuffer is how many events a slow subscriber may fall behind
+	// before it is disconnected. It can resume from its last event ID.
+	subscriberBuffer = 64
+)
+
+// Message is an event with the stream ID it was assigned. IDs are only
+// meaningful to the Hub that issued them.
+type Message struct {
+	ID    string
+	Event events.Event
+}
+
+// Subscription receives messages until C is closed, which happens when the
+// subscriber falls too far behind, the hub is closed or Close is called.
+type Subscription struct {
+	C <-chan Message
+
+	c      chan Message
+	hub    *Hub
+	filter Filter
+}
+
+// Close stops delivery to the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.drop(s)
+}
+
+// Hub numbers events in publish order and keeps the most recent ones in a
+// ring buffer so that clients can reconnect without missing events.
+type Hub struct {
+	mu     sync.Mutex
+	epoch  string
+	seq    uint64
+	ring   []Message
+	start  int // index of the oldest message in ring
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub returns a Hub that remembers the last size events.
+func NewHub(size int) *Hub {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	return &Hub{
+		// IDs carry the hub's start time so that an ID issued before a
+		// restart is never mistaken for one issued after it.
+		epoch: strconv.FormatInt(time.Now().UnixMilli(), 36),
+		ring:  make([]Message, 0, size),
+		subs:  make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish is an events.Handler that records e and delivers it to matching
+// subscribers without blocking.
+func (h *Hub) Publish(ctx context.Context, e events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.seq++
+	m := Message{ID: h.epoch + "-" + strconv.FormatUint(h.seq, 10), Event: e}
+
+	if len(h.ring) < cap(h.ring) {
+		h.ring = append(h.ring, m)
+	} else {
+		h.ring[h.start] = m
+		h.start = (h.start + 1) % len(h.ring)
+	}
+
+	for s := range h.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+
+		select {
+		case s.c <- m:
+		default:
+			h.drop(s)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for events matching f. When lastID is set
+// the events published after it that are still buffered are returned as a
+// backlog, delivered before anything on the subscription. complete is false
+// if lastID is unknown or older than the buffer, in which case the client
+// has missed events and should reload its state.
+func (h *Hub) Subscribe(f Filter, lastID string) (sub *Subscription, backlog []Message, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := make(chan Message, subscriberBuffer)
+	sub = &Subscription{C: c, c: c, hub: h, filter: f}
+
+	if h.closed {
+		close(c)
+		return sub, nil, true
+	}
+	h.subs[sub] = struct{}{}
+
+	if lastID == "" {
+		return sub, nil, true
+	}
+
+	after, ok := h.parseID(lastID)
+	if !ok || after > h.seq {
+		return sub, nil, false
+	}
+
+	// sequence number of the oldest buffered message
+	oldest := h.seq - uint64(len(h.ring)) + 1
+	complete = after+1 >= oldest
+
+	for i := range h.ring {
+		m := h.ring[(h.start+i)%len(h.ring)]
+		if seq := h.seq - uint64(len(h.ring)-1-i); seq > after && f.Match(m.Event) {
+			backlog = append(backlog, m)
+		}
+	}
+
+	return sub, backlog, complete
+}
+
+// Close disconnects every subscriber and stops accepting events. It is meant
+// to be called on shutdown so that open streams do not hold the server up.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subs {
+		h.drop(s)
+	}
+}
+
+// drop removes s and closes its channel. h.mu must be held.
+func (h *Hub) drop(s *Subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	close(s.c)
+}
+
+func (h *Hub) parseID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != h.epoch {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
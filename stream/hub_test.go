@@ -0,0 +1,158 @@
+package stream
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"testing"
+
+	"crud/events"
+
+	"github.com/google/uuid"
+)
+
+func publish(h *Hub, n int, t events.Type, assetID *uuid.UUID) {
+	for range n {
+		h.Publish(context.Background(), events.New(t, assetID, nil, nil))
+	}
+}
+
+// id returns the stream ID the hub gave its seq-th message.
+func id(h *Hub, seq uint64) string {
+	return h.epoch + "-" + strconv.FormatUint(seq, 10)
+}
+
+func ids(backlog []Message) []string {
+	out := make([]string, 0, len(backlog))
+	for _, m := range backlog {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+func TestRingWraparound(t *testing.T) {
+	h := NewHub(3)
+	publish(h, 5, events.AssetCreated, nil)
+
+	// messages 3, 4 and 5 are buffered; 4 and 5 overwrote 1 and 2
+	if h.start != 2 {
+		t.Fatalf("start = %d, want 2", h.start)
+	}
+
+	sub, backlog, complete := h.Subscribe(Filter{}, id(h, 0))
+	defer sub.Close()
+	if complete {
+		t.Error("resuming from before the buffer reported complete")
+	}
+	if want := []string{id(h, 3), id(h, 4), id(h, 5)}; !slices.Equal(ids(backlog), want) {
+		t.Errorf("backlog = %v, want the buffered messages oldest first %v", ids(backlog), want)
+	}
+}
+
+func TestResumeAcrossWrap(t *testing.T) {
+	h := NewHub(4)
+	publish(h, 6, events.AssetCreated, nil)
+
+	for _, tc := range []struct {
+		after    uint64
+		want     []uint64
+		complete bool
+	}{
+		// oldest buffered is 3, so resuming after 2 misses nothing
+		{after: 2, want: []uint64{3, 4, 5, 6}, complete: true},
+		{after: 4, want: []uint64{5, 6}, complete: true},
+		{after: 6, want: nil, complete: true},
+		// 2 was overwritten
+		{after: 1, want: []uint64{3, 4, 5, 6}, complete: false},
+	} {
+		sub, backlog, complete := h.Subscribe(Filter{}, id(h, tc.after))
+		sub.Close()
+
+		want := []string{}
+		for _, seq := range tc.want {
+			want = append(want, id(h, seq))
+		}
+		if !slices.Equal(ids(backlog), want) || complete != tc.complete {
+			t.Errorf("after %d: backlog = %v, complete = %v; want %v, %v", tc.after, ids(backlog), complete, want, tc.complete)
+		}
+	}
+}
+
+func TestResumeFiltersBacklog(t *testing.T) {
+	h := NewHub(8)
+	assetID := uuid.New()
+	publish(h, 1, events.AssetCreated, &assetID)
+	publish(h, 1, events.AssetCreated, nil)
+	publish(h, 1, events.AssetUpdated, &assetID)
+
+	sub, backlog, complete := h.Subscribe(Filter{AssetIDs: []uuid.UUID{assetID}}, id(h, 0))
+	defer sub.Close()
+
+	if want := []string{id(h, 1), id(h, 3)}; !complete || !slices.Equal(ids(backlog), want) {
+		t.Errorf("backlog = %v, complete = %v; want %v, true", ids(backlog), complete, want)
+	}
+}
+
+func TestResumeUnknownID(t *testing.T) {
+	h := NewHub(4)
+	publish(h, 2, events.AssetCreated, nil)
+
+	for _, lastID := range []string{
+		"0-1",            // another hub's epoch, e.g. before a restart
+		h.epoch + "-9",   // not issued yet
+		h.epoch + "-abc", // not a sequence number
+		"no separator",
+	} {
+		sub, backlog, complete := h.Subscribe(Filter{}, lastID)
+		sub.Close()
+
+		if complete || len(backlog) != 0 {
+			t.Errorf("%q: backlog = %v, complete = %v; want none, false", lastID, ids(backlog), complete)
+		}
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	h := NewHub(0)
+	slow, _, _ := h.Subscribe(Filter{}, "")
+	fast, _, _ := h.Subscribe(Filter{}, "")
+	defer fast.Close()
+
+	for range subscriberBuffer + 1 {
+		publish(h, 1, events.AssetCreated, nil)
+		<-fast.C
+	}
+
+	// the slow subscriber gets what fit in its buffer, then a closed channel
+	for range subscriberBuffer {
+		if _, ok := <-slow.C; !ok {
+			t.Fatal("slow subscriber closed before its buffer was drained")
+		}
+	}
+	if _, ok := <-slow.C; ok {
+		t.Fatal("slow subscriber was not dropped")
+	}
+
+	// a dropped subscription can still be closed
+	slow.Close()
+
+	publish(h, 1, events.AssetCreated, nil)
+	if _, ok := <-fast.C; !ok {
+		t.Error("the subscriber that kept up was dropped")
+	}
+}
+
+func TestClose(t *testing.T) {
+	h := NewHub(4)
+	sub, _, _ := h.Subscribe(Filter{}, "")
+	h.Close()
+
+	if _, ok := <-sub.C; ok {
+		t.Error("subscription still open after Close")
+	}
+
+	late, _, complete := h.Subscribe(Filter{}, "")
+	if _, ok := <-late.C; ok || !complete {
+		t.Error("subscribing to a closed hub returned an open subscription")
+	}
+}
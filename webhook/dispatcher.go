@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"crud/domain"
+	"crud/events"
+	"crud/model"
+)
+
+const (
+	DefaultInterval    = 5 * time.Second
+	DefaultTimeout     = 10 * time.Second
+	DefaultMaxAttempts = 8
+	DefaultBaseBackoff = 30 * time.Second
+	DefaultMaxBackoff  = 6 * time.Hour
+
+	claimBatch      = 50
+	maxErrorLength  = 512
+	userAgent       = "iot-asset-tracking-webhooks/1"
+	maxResponseRead = 64 << 10
+)
+
+// Config tunes a Dispatcher. Zero fields take the defaults above.
+type Config struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher sends the deliveries the store queued for each event in the
+// background, retrying failures with exponential backoff until they succeed
+// or run out of attempts.
+type Dispatcher struct {
+	store  domain.WebhookStore
+	client *http.Client
+	cfg    Config
+	wake   chan struct{}
+}
+
+func NewDispatcher(store domain.WebhookStore, cfg Config) *Dispatcher {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultMaxAttempts
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = DefaultBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Notify is an events.Handler that wakes the dispatch loop. The store queues
+// an event's deliveries in the transaction that made the change, so they
+// are already waiting when the event is published; without Notify they are
+// sent on the next tick.
+func (d *Dispatcher) Notify(ctx context.Context, e events.Event) {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run dispatches due deliveries every interval, or sooner when Notify
+// reports an event, until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	slog.Info("webhook dispatcher started", "interval", d.cfg.Interval.String(), "maxAttempts", d.cfg.MaxAttempts)
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+
+		d.Dispatch(ctx)
+	}
+}
+
+// Dispatch sends every delivery that is currently due and returns how many
+// were attempted.
+func (d *Dispatcher) Dispatch(ctx context.Context) int {
+	// A claim holds deliveries for longer than one attempt can take, so a
+	// dispatcher that dies mid-flight only delays them.
+	lease := 2 * d.cfg.Timeout
+	total := 0
+
+	for ctx.Err() == nil {
+		batch, err := d.store.ClaimWebhookDeliveries(ctx, time.Now().UTC(), lease, claimBatch)
+		if err != nil {
+			slog.Error("webhook claim failed", slog.Any("error", err))
+			return total
+		}
+
+		var wg sync.WaitGroup
+		for _, del := range batch {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.attempt(ctx, del)
+			}()
+		}
+		wg.Wait()
+
+		total += len(batch)
+		if len(batch) < claimBatch {
+			break
+		}
+	}
+
+	return total
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, del model.WebhookDelivery) {
+	status, err := d.send(ctx, del)
+
+	// an attempt cut short by shutdown is not the receiver's fault; the
+	// lease expires and the delivery is retried on the next start
+	if ctx.Err() != nil {
+		return
+	}
+
+	a := model.DeliveryAttempt{AttemptedAtUTC: time.Now().UTC()}
+	if status != 0 {
+		a.ResponseStatus = &status
+	}
+
+	switch {
+	case err == nil:
+		a.Status = model.DeliveryStatuses.Delivered
+	case del.Attempts+1 >= d.cfg.MaxAttempts:
+		a.Status = model.DeliveryStatuses.Dead
+		a.Error = truncate(err.Error())
+		slog.Warn("webhook delivery dead", "deliveryID", del.ID, "webhookID", del.WebhookID, "attempts", del.Attempts+1, slog.Any("error", err))
+	default:
+		next := a.AttemptedAtUTC.Add(Backoff(del.Attempts+1, d.cfg.BaseBackoff, d.cfg.MaxBackoff))
+		a.Status = model.DeliveryStatuses.Pending
+		a.Error = truncate(err.Error())
+		a.NextAttemptAtUTC = &next
+	}
+
+	if err := d.store.RecordWebhookAttempt(context.WithoutCancel(ctx), del.ID, a); err != nil {
+		slog.Error("webhook attempt not recorded", "deliveryID", del.ID, slog.Any("error", err))
+	}
+}
+
+// send posts the delivery and returns the response status, or 0 if no
+// response was received.
+func (d *Dispatcher) send(ctx context.Context, del model.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDeliveryID, del.ID.String())
+	req.Header.Set(HeaderEvent, del.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(del.Secret, ts, del.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseRead))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before retry number attempt (1-based): base
+// doubled for each earlier attempt, capped at max, with up to 10% jitter so
+// that failures from one outage do not retry in lockstep.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	return d + rand.N(d/10+1)
+}
+
+// truncate cuts s to at most maxErrorLength bytes without splitting a
+// UTF-8 sequence.
+func truncate(s string) string {
+	if len(s) <= maxErrorLength {
+		return s
+	}
+
+	cut := maxErrorLength
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut]
+}
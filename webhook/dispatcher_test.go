@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"crud/domain"
+	"crud/events"
+	"crud/model"
+)
+
+func TestTruncate(t *testing.T) {
+	short := "receiver responded 500"
+	if got := truncate(short); got != short {
+		t.Errorf("truncate(%q) = %q", short, got)
+	}
+
+	// a multi-byte rune straddling the limit must not be split
+	s := strings.Repeat("a", maxErrorLength-1) + "é" + "tail"
+	got := truncate(s)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncate produced invalid UTF-8: %q", got[len(got)-4:])
+	}
+	if len(got) != maxErrorLength-1 {
+		t.Errorf("len = %d, want %d", len(got), maxErrorLength-1)
+	}
+}
+
+func TestWritesQueueDeliveries(t *testing.T) {
+	ctx := context.Background()
+	store := domain.NewMemoryStore()
+
+	var mu sync.Mutex
+	received := []string{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r.Header.Get(HeaderEvent))
+	}))
+	defer receiver.Close()
+
+	assetHook := &model.Webhook{URL: receiver.URL, Events: []string{"asset.*"}, Secret: "s"}
+	locationHook := &model.Webhook{URL: receiver.URL, Events: []string{"location.created"}, Secret: "s"}
+	for _, h := range []*model.Webhook{assetHook, locationHook} {
+		if err := store.CreateWebhook(ctx, h); err != nil {
+			t.Fatalf("CreateWebhook: %v", err)
+		}
+	}
+
+	location := &model.Location{Name: "Warehouse", Code: "WHSE"}
+	if err := store.CreateLocation(ctx, location); err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+	asset := &model.CreateAssetRequest{Name: "tracker", Status: model.Statuses.Online, LocationID: *location.ID}
+	if err := store.CreateAsset(ctx, asset); err != nil {
+		t.Fatalf("CreateAsset: %v", err)
+	}
+
+	// a write that fails queues nothing
+	if err := store.CreateAsset(ctx, &model.CreateAssetRequest{Name: "tracker", LocationID: *location.ID}); err == nil {
+		t.Fatal("CreateAsset accepted a duplicate name")
+	}
+
+	for _, tc := range []struct {
+		hook *model.Webhook
+		want string
+	}{
+		{assetHook, string(events.AssetCreated)},
+		{locationHook, string(events.LocationCreated)},
+	} {
+		deliveries, err := store.GetWebhookDeliveries(ctx, *tc.hook.ID, "", 10)
+		if err != nil {
+			t.Fatalf("GetWebhookDeliveries: %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].EventType != tc.want {
+			t.Fatalf("%v queued %+v, want one %s delivery", tc.hook.Events, deliveries, tc.want)
+		}
+	}
+
+	if n := NewDispatcher(store, Config{}).Dispatch(ctx); n != 2 {
+		t.Errorf("Dispatch attempted %d deliveries, want 2", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	slices.Sort(received)
+	if want := []string{string(events.AssetCreated), string(events.LocationCreated)}; !slices.Equal(received, want) {
+		t.Errorf("received %v, want %v", received, want)
+	}
+}